@@ -84,7 +84,7 @@ This will produce an error if the chart cannot be loaded.
 
 func newDependencyCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "dependency update|build|list",
+		Use:     "dependency update|build|list|tree|outdated",
 		Aliases: []string{"dep", "dependencies"},
 		Short:   "manage a chart's dependencies",
 		Long:    dependencyDesc,
@@ -94,6 +94,8 @@ func newDependencyCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	cmd.AddCommand(newDependencyListCmd(out))
 	cmd.AddCommand(newDependencyUpdateCmd(cfg, out))
 	cmd.AddCommand(newDependencyBuildCmd(cfg, out))
+	cmd.AddCommand(newDependencyTreeCmd(out))
+	cmd.AddCommand(newDependencyOutdatedCmd(cfg, out))
 
 	return cmd
 }
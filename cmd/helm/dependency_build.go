@@ -39,6 +39,19 @@ does.
 
 If no lock file is found, 'helm dependency build' will mirror the behavior
 of 'helm dependency update'.
+
+The lock file records the digest of the archive of each dependency downloaded
+from a chart repository or an OCI registry. A downloaded archive that does not
+match its digest, such as when a repository republished a version, fails the
+build. Use '--verify-digests' to also require a digest for every downloaded
+dependency.
+
+The archives downloaded by 'helm dependency update' and 'helm dependency build'
+are kept in a local chart cache, keyed by repository URL, chart name, version
+and digest. Use '--offline' to build the dependencies without network access:
+the archives matching the digests in the lock file are then only copied from
+this cache, and the command fails if one of them is missing. Set
+$HELM_CONTENT_CACHE to change the location of the cache.
 `
 
 func newDependencyBuildCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -63,7 +76,10 @@ func newDependencyBuildCmd(cfg *action.Configuration, out io.Writer) *cobra.Comm
 				RegistryClient:   cfg.RegistryClient,
 				RepositoryConfig: settings.RepositoryConfig,
 				RepositoryCache:  settings.RepositoryCache,
+				ContentCache:     settings.ContentCache,
 				Debug:            settings.Debug,
+				VerifyDigests:    client.VerifyDigests,
+				Offline:          client.Offline,
 			}
 			if client.Verify {
 				man.Verify = downloader.VerifyIfPossible
@@ -80,6 +96,8 @@ func newDependencyBuildCmd(cfg *action.Configuration, out io.Writer) *cobra.Comm
 	f.BoolVar(&client.Verify, "verify", false, "verify the packages against signatures")
 	f.StringVar(&client.Keyring, "keyring", defaultKeyring(), "keyring containing public keys")
 	f.BoolVar(&client.SkipRefresh, "skip-refresh", false, "do not refresh the local repository cache")
+	f.BoolVar(&client.VerifyDigests, "verify-digests", false, "fail if no digest is recorded in the lock file for a downloaded chart archive")
+	f.BoolVar(&client.Offline, "offline", false, "copy the dependencies from the local chart cache instead of downloading them")
 
 	return cmd
 }
@@ -0,0 +1,118 @@
+/*
+Copyright The Helm Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+)
+
+const dependencyOutdatedDesc = `
+List the dependencies of a chart that have newer versions than the versions
+recorded in the lock file.
+
+For each dependency from a chart repository or an OCI registry, the newer
+versions are split into the versions satisfying the version constraint in
+Chart.yaml, which 'helm dependency update' can pick, and the versions that do
+not, which require changing the constraint. The table shows the latest of
+each; JSON and YAML output list all of them.
+
+The versions are read from the cached repository indexes, so run
+'helm repo update' first to check for the latest versions, and from the tags
+of the charts in OCI registries.
+`
+
+func newDependencyOutdatedCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewDependency()
+	var outfmt output.Format
+
+	cmd := &cobra.Command{
+		Use:   "outdated CHART",
+		Short: "list the dependencies with newer versions than the locked ones",
+		Long:  dependencyOutdatedDesc,
+		Args:  require.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			chartpath := "."
+			if len(args) > 0 {
+				chartpath = filepath.Clean(args[0])
+			}
+			client.RepositoryConfig = settings.RepositoryConfig
+			client.RepositoryCache = settings.RepositoryCache
+			client.RegistryClient = cfg.RegistryClient
+			deps, err := client.Outdated(chartpath)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &outdatedWriter{deps})
+		},
+	}
+
+	bindOutputFlag(cmd, &outfmt)
+	return cmd
+}
+
+type outdatedWriter struct {
+	deps []*action.OutdatedDependency
+}
+
+func (w *outdatedWriter) WriteTable(out io.Writer) error {
+	if len(w.deps) == 0 {
+		_, err := fmt.Fprintln(out, "All dependencies are up to date.")
+		return err
+	}
+	table := uitable.New()
+	table.AddRow("NAME", "LOCKED", "CONSTRAINT", "COMPATIBLE", "INCOMPATIBLE", "REPOSITORY")
+	var failed []*action.OutdatedDependency
+	for _, d := range w.deps {
+		if d.Error != "" {
+			failed = append(failed, d)
+			table.AddRow(d.Name, d.Locked, d.Constraint, "unknown", "unknown", d.Repository)
+			continue
+		}
+		table.AddRow(d.Name, d.Locked, d.Constraint, latestVersion(d.Compatible), latestVersion(d.Incompatible), d.Repository)
+	}
+	if err := output.EncodeTable(out, table); err != nil {
+		return err
+	}
+	for _, d := range failed {
+		fmt.Fprintf(out, "WARNING: could not list the versions of %s: %s\n", d.Name, d.Error)
+	}
+	return nil
+}
+
+// latestVersion returns the first of versions sorted newest first.
+func latestVersion(versions []string) string {
+	if len(versions) == 0 {
+		return "-"
+	}
+	return versions[0]
+}
+
+func (w *outdatedWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.deps)
+}
+
+func (w *outdatedWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.deps)
+}
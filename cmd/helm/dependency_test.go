@@ -52,6 +52,37 @@ func TestDependencyListCmd(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+func TestDependencyTreeCmd(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "Dependencies with conditions and tags",
+		cmd:    "dependency tree testdata/testcharts/subchart",
+		golden: "output/dependency-tree.txt",
+	}, {
+		name:   "Dependencies in chart archive as JSON",
+		cmd:    "dependency tree testdata/testcharts/reqtest-0.1.0.tgz -o json",
+		golden: "output/dependency-tree.json",
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestDependencyOutdatedCmd(t *testing.T) {
+	repos := "--repository-config testdata/helmhome/helm/repositories.yaml --repository-cache testdata/helmhome/helm/repository"
+	tests := []cmdTestCase{{
+		name:   "Outdated dependencies",
+		cmd:    "dependency outdated testdata/testcharts/outdated " + repos,
+		golden: "output/dependency-outdated.txt",
+	}, {
+		name:   "Outdated dependencies as JSON",
+		cmd:    "dependency outdated testdata/testcharts/outdated -o json " + repos,
+		golden: "output/dependency-outdated.json",
+	}, {
+		name:   "No outdated dependencies",
+		cmd:    "dependency outdated testdata/testcharts/reqtest " + repos,
+		golden: "output/dependency-outdated-none.txt",
+	}}
+	runTestCmd(t, tests)
+}
+
 func TestDependencyFileCompletion(t *testing.T) {
 	checkFileCompletion(t, "dependency", false)
 }
@@ -0,0 +1,105 @@
+/*
+Copyright The Helm Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+)
+
+const dependencyTreeDesc = `
+Show the dependency tree of a chart.
+
+The tree lists the dependencies declared in Chart.yaml and, recursively, the
+dependencies of the dependencies, with their aliases, conditions, tags and the
+version they resolve to: the version of the chart in 'charts/' or, if the chart
+is missing, the version recorded in the lock file. Dependencies that are
+neither present nor locked are shown as unresolved.
+
+This can take chart archives and chart directories as input. It will not alter
+the contents of a chart.
+`
+
+func newDependencyTreeCmd(out io.Writer) *cobra.Command {
+	client := action.NewDependency()
+	var outfmt output.Format
+
+	cmd := &cobra.Command{
+		Use:   "tree CHART",
+		Short: "show the dependency tree of the given chart",
+		Long:  dependencyTreeDesc,
+		Args:  require.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			chartpath := "."
+			if len(args) > 0 {
+				chartpath = filepath.Clean(args[0])
+			}
+			tree, err := client.Tree(chartpath)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &dependencyTreeWriter{tree})
+		},
+	}
+
+	bindOutputFlag(cmd, &outfmt)
+	return cmd
+}
+
+type dependencyTreeWriter struct {
+	tree *action.DependencyNode
+}
+
+func (w *dependencyTreeWriter) WriteTable(out io.Writer) error {
+	table := uitable.New()
+	table.AddRow("NAME", "VERSION", "CONSTRAINT", "ALIAS", "REPOSITORY", "CONDITION", "TAGS")
+	table.AddRow(w.tree.Name, w.tree.Version)
+	addDependencyRows(table, w.tree.Dependencies, "")
+	return output.EncodeTable(out, table)
+}
+
+// addDependencyRows adds a row for each node, prefixing the names with the
+// branches of the tree.
+func addDependencyRows(table *uitable.Table, nodes []*action.DependencyNode, indent string) {
+	for i, n := range nodes {
+		branch, next := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+		version := n.Version
+		if version == "" {
+			version = "unresolved"
+		}
+		table.AddRow(indent+branch+n.Name, version, n.Constraint, n.Alias, n.Repository, n.Condition, strings.Join(n.Tags, ","))
+		addDependencyRows(table, n.Dependencies, indent+next)
+	}
+}
+
+func (w *dependencyTreeWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.tree)
+}
+
+func (w *dependencyTreeWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.tree)
+}
@@ -40,6 +40,16 @@ rebuild the dependencies to an exact version.
 Dependencies are not required to be represented in 'Chart.yaml'. For that
 reason, an update command will not remove charts unless they are (a) present
 in the Chart.yaml file, but (b) at the wrong version.
+
+Use '--transitive' to also resolve the dependencies of the dependencies, read
+from local charts and from the indexes of the chart repositories, and to record
+the whole dependency graph in the lock file. A chart required in several places
+of the graph is resolved to a single version satisfying every constraint; if
+there is none, the conflicting constraints are reported with the path to each
+of them. Dependencies whose packages do not include their resolved
+dependencies are unpacked into 'charts/' and their dependencies downloaded
+into their own 'charts/' directory. 'helm dependency build' reproduces the same
+graph from the lock file.
 `
 
 // newDependencyUpdateCmd creates a new dependency update command.
@@ -66,7 +76,9 @@ func newDependencyUpdateCmd(cfg *action.Configuration, out io.Writer) *cobra.Com
 				RegistryClient:   cfg.RegistryClient,
 				RepositoryConfig: settings.RepositoryConfig,
 				RepositoryCache:  settings.RepositoryCache,
+				ContentCache:     settings.ContentCache,
 				Debug:            settings.Debug,
+				Transitive:       client.Transitive,
 			}
 			if client.Verify {
 				man.Verify = downloader.VerifyAlways
@@ -79,6 +91,7 @@ func newDependencyUpdateCmd(cfg *action.Configuration, out io.Writer) *cobra.Com
 	f.BoolVar(&client.Verify, "verify", false, "verify the packages against signatures")
 	f.StringVar(&client.Keyring, "keyring", defaultKeyring(), "keyring containing public keys")
 	f.BoolVar(&client.SkipRefresh, "skip-refresh", false, "do not refresh the local repository cache")
+	f.BoolVar(&client.Transitive, "transitive", false, "also resolve the dependencies of the dependencies and record them in the lock file")
 
 	return cmd
 }
@@ -60,6 +60,11 @@ func addChartPathOptionsFlags(f *pflag.FlagSet, c *action.ChartPathOptions) {
 	f.BoolVar(&c.PassCredentialsAll, "pass-credentials", false, "pass credentials to all domains")
 }
 
+func addServerSideApplyFlags(f *pflag.FlagSet, serverSide, forceConflicts *bool) {
+	f.BoolVar(serverSide, "server-side", false, "update resources using server-side apply with the \"helm\" field manager instead of a client-side patch")
+	f.BoolVar(forceConflicts, "force-conflicts", false, "if set with --server-side, take ownership of fields managed by other field managers instead of failing on conflicts")
+}
+
 // bindOutputFlag will add the output flag to the given command and bind the
 // value to the given format pointer
 func bindOutputFlag(cmd *cobra.Command, varRef *output.Format) {
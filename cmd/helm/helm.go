@@ -17,15 +17,23 @@ limitations under the License.
 package main // import "helm.sh/helm/v3/cmd/helm"
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/yaml"
 
+	// Import the database/sql drivers of the SQLite and MySQL dialects of
+	// the SQL storage driver.
+	_ "github.com/go-sql-driver/mysql"
+	_ "modernc.org/sqlite"
 	// Import to initialize client auth plugins.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
@@ -76,6 +84,12 @@ func main() {
 	// run when each command's execute method is called
 	cobra.OnInitialize(func() {
 		helmDriver := os.Getenv("HELM_DRIVER")
+		encryptor, err := storageEncryptor()
+		if err != nil {
+			log.Fatal(err)
+		}
+		actionConfig.Encryptor = encryptor
+		actionConfig.SeparateCharts = settings.DriverSeparateCharts
 		if err := actionConfig.Init(settings.RESTClientGetter(), settings.Namespace(), helmDriver, debug); err != nil {
 			log.Fatal(err)
 		}
@@ -95,6 +109,38 @@ func main() {
 	}
 }
 
+// storageEncryptor returns the encryptor of release records configured by the
+// HELM_DRIVER_ENCRYPTION_* environment variables, or nil if encryption is not
+// configured.
+func storageEncryptor() (driver.Encryptor, error) {
+	keyFile, plugin := settings.DriverEncryptionKeyFile, settings.DriverEncryptionKMSPlugin
+	switch {
+	case keyFile != "" && plugin != "":
+		return nil, errors.New("only one of HELM_DRIVER_ENCRYPTION_KEYFILE and HELM_DRIVER_ENCRYPTION_KMS_PLUGIN can be set")
+	case keyFile != "":
+		enc, err := driver.NewKeyFileEncryptor(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return enc, nil
+	case plugin != "":
+		return driver.NewExecEncryptor(plugin), nil
+	}
+	return nil, nil
+}
+
+// interruptContext returns a context that is cancelled when the process
+// receives SIGINT or SIGTERM, giving a running operation the chance to mark
+// its release as failed. A second signal terminates the process as usual.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
 func checkOCIFeatureGate() func(_ *cobra.Command, _ []string) error {
 	return func(_ *cobra.Command, _ []string) error {
 		if !FeatureGateOCI.IsEnabled() {
@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"time"
@@ -33,6 +34,7 @@ import (
 	"helm.sh/helm/v3/pkg/cli/values"
 	"helm.sh/helm/v3/pkg/downloader"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/release"
 )
 
@@ -117,22 +119,36 @@ func newInstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			return compInstall(args, toComplete, client)
 		},
 		RunE: func(_ *cobra.Command, args []string) error {
+			// Only print the wait progress to stdout for table output
+			if outfmt == output.Table {
+				client.WaitProgress = waitProgress(out)
+			}
 			rel, err := runInstall(args, client, valueOpts, out)
 			if err != nil {
 				return err
 			}
 
-			return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false})
+			return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, nil})
 		},
 	}
 
 	addInstallFlags(cmd, cmd.Flags(), client, valueOpts)
+	addServerSideApplyFlags(cmd.Flags(), &client.ServerSideApply, &client.ForceConflicts)
+	cmd.Flags().BoolVar(&client.Adopt, "adopt", false, "take ownership of existing resources that are not owned by any release instead of failing. Resources owned by another release are still refused")
 	bindOutputFlag(cmd, &outfmt)
 	bindPostRenderFlag(cmd, &client.PostRenderer)
 
 	return cmd
 }
 
+// waitProgress prints the readiness of the resources whenever it changes
+// while waiting for them to be ready.
+func waitProgress(out io.Writer) kube.WaitProgressFunc {
+	return func(e kube.WaitEvent) {
+		fmt.Fprintln(out, e)
+	}
+}
+
 func addInstallFlags(cmd *cobra.Command, f *pflag.FlagSet, client *action.Install, valueOpts *values.Options) {
 	f.BoolVar(&client.CreateNamespace, "create-namespace", false, "create the release namespace if not present")
 	f.BoolVar(&client.DryRun, "dry-run", false, "simulate an install")
@@ -146,6 +162,7 @@ func addInstallFlags(cmd *cobra.Command, f *pflag.FlagSet, client *action.Instal
 	f.StringVar(&client.Description, "description", "", "add a custom description")
 	f.BoolVar(&client.Devel, "devel", false, "use development versions, too. Equivalent to version '>0.0.0-0'. If --version is set, this is ignored")
 	f.BoolVar(&client.DependencyUpdate, "dependency-update", false, "update dependencies if they are missing before installing the chart")
+	f.BoolVar(&client.Offline, "offline", false, "build missing dependencies from the local chart cache, without network access")
 	f.BoolVar(&client.DisableOpenAPIValidation, "disable-openapi-validation", false, "if set, the installation process will not validate rendered templates against the Kubernetes OpenAPI Schema")
 	f.BoolVar(&client.Atomic, "atomic", false, "if set, the installation process deletes the installation on failure. The --wait flag will be set automatically if --atomic is used")
 	f.BoolVar(&client.SkipCRDs, "skip-crds", false, "if set, no CRDs will be installed. By default, CRDs are installed if not already present")
@@ -214,7 +231,7 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 		// As of Helm 2.4.0, this is treated as a stopping condition:
 		// https://github.com/helm/helm/issues/2209
 		if err := action.CheckDependencies(chartRequested, req); err != nil {
-			if client.DependencyUpdate {
+			if client.DependencyUpdate || client.Offline {
 				man := &downloader.Manager{
 					Out:              out,
 					ChartPath:        cp,
@@ -223,9 +240,17 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 					Getters:          p,
 					RepositoryConfig: settings.RepositoryConfig,
 					RepositoryCache:  settings.RepositoryCache,
+					ContentCache:     settings.ContentCache,
 					Debug:            settings.Debug,
+					Offline:          client.Offline,
+				}
+				// Offline, the dependencies locked for the chart are built
+				// from the cache unless an update was requested.
+				update := man.Update
+				if !client.DependencyUpdate {
+					update = man.Build
 				}
-				if err := man.Update(); err != nil {
+				if err := update(); err != nil {
 					return nil, err
 				}
 				// Reload the chart with the updated Chart.lock file.
@@ -239,7 +264,10 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 	}
 
 	client.Namespace = settings.Namespace()
-	return client.Run(chartRequested, vals)
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	return client.RunWithContext(ctx, chartRequested, vals)
 }
 
 // checkIfInstallable validates if a chart can be installed
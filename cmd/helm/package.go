@@ -94,6 +94,8 @@ func newPackageCmd(out io.Writer) *cobra.Command {
 						Debug:            settings.Debug,
 						RepositoryConfig: settings.RepositoryConfig,
 						RepositoryCache:  settings.RepositoryCache,
+						ContentCache:     settings.ContentCache,
+						Offline:          client.Offline,
 					}
 
 					if err := downloadManager.Update(); err != nil {
@@ -119,6 +121,7 @@ func newPackageCmd(out io.Writer) *cobra.Command {
 	f.StringVar(&client.AppVersion, "app-version", "", "set the appVersion on the chart to this version")
 	f.StringVarP(&client.Destination, "destination", "d", ".", "location to write the chart.")
 	f.BoolVarP(&client.DependencyUpdate, "dependency-update", "u", false, `update dependencies from "Chart.yaml" to dir "charts/" before packaging`)
+	f.BoolVar(&client.Offline, "offline", false, "with --dependency-update, copy the dependencies from the local chart cache instead of downloading them")
 
 	return cmd
 }
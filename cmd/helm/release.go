@@ -0,0 +1,46 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const releaseHelp = `
+This command consists of multiple subcommands to manage the records of releases.
+`
+
+func newReleaseCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "release",
+		Short: "manage the records of releases",
+		Long:  releaseHelp,
+		Args:  require.NoArgs,
+	}
+
+	cmd.AddCommand(newReleaseUnlockCmd(cfg, out))
+	cmd.AddCommand(newReleaseReencryptCmd(cfg, out))
+	cmd.AddCommand(newReleaseTransferCmd(cfg, out))
+	cmd.AddCommand(newReleaseRenameCmd(cfg, out))
+
+	return cmd
+}
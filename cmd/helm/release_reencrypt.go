@@ -0,0 +1,61 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const releaseReencryptDesc = `
+This command rewrites the records of all releases in the namespace, encrypting
+them with the current encryption key.
+
+Release records are encrypted at rest when HELM_DRIVER_ENCRYPTION_KEYFILE or
+HELM_DRIVER_ENCRYPTION_KMS_PLUGIN is set. Each record stores the ID of the key
+it was encrypted with, so records encrypted with previous keys stay readable as
+long as those keys are available. After rotating keys, or enabling encryption,
+run this command to re-encrypt the history of the releases before removing the
+previous keys.
+`
+
+func newReleaseReencryptCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewReencrypt(cfg)
+
+	cmd := &cobra.Command{
+		Use:               "reencrypt",
+		Short:             "re-encrypt the records of all releases with the current key",
+		Long:              releaseReencryptDesc,
+		Args:              require.NoArgs,
+		ValidArgsFunction: noCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := client.Run()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "re-encrypted %d release record(s)\n", n)
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,55 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestReleaseReencryptCmd(t *testing.T) {
+	rels := []*release.Release{{
+		Name:    "funny-honey",
+		Info:    &release.Info{Status: release.StatusSuperseded},
+		Chart:   &chart.Chart{},
+		Version: 1,
+	}, {
+		Name:    "funny-honey",
+		Info:    &release.Info{Status: release.StatusDeployed},
+		Chart:   &chart.Chart{},
+		Version: 2,
+	}}
+
+	tests := []cmdTestCase{{
+		name:   "re-encrypt all releases",
+		cmd:    "release reencrypt",
+		golden: "output/release-reencrypt.txt",
+		rels:   rels,
+	}, {
+		name:   "re-encrypt without releases",
+		cmd:    "release reencrypt",
+		golden: "output/release-reencrypt-none.txt",
+	}, {
+		name:      "re-encrypt with arguments",
+		cmd:       "release reencrypt funny-honey",
+		golden:    "output/release-reencrypt-args.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const releaseRenameDesc = `
+This command renames a release without redeploying it.
+
+Every revision of the release is stored again under the new name, the labels and
+annotations recording the release owning its resources are rewritten, and the
+records under the old name are removed. The resources themselves are not
+redeployed, so nothing is deleted or restarted.
+
+Use '--to-namespace' to also move the records of the release to another
+namespace. Namespaced resources stay in the namespace they were deployed to;
+the chart must set their namespace explicitly, otherwise the next upgrade
+creates them in the new namespace.
+
+	$ helm release rename my-app my-app-v2
+	$ helm release rename my-app my-app --to-namespace apps
+`
+
+func newReleaseRenameCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewReleaseRename(cfg)
+
+	cmd := &cobra.Command{
+		Use:   "rename RELEASE_NAME NEW_NAME",
+		Short: "rename a release or move it to another namespace",
+		Long:  releaseRenameDesc,
+		Args:  require.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if client.Namespace != "" && client.Namespace != settings.Namespace() {
+				target := &action.Configuration{Encryptor: cfg.Encryptor}
+				if err := target.Init(settings.RESTClientGetter(), client.Namespace, os.Getenv("HELM_DRIVER"), debug); err != nil {
+					return err
+				}
+				client.TargetReleases = target.Releases
+			}
+
+			rel, err := client.Run(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			if client.DryRun {
+				fmt.Fprintf(out, "rename dry run complete, release %q can be renamed to %q in namespace %q\n", args[0], rel.Name, rel.Namespace)
+				return nil
+			}
+			fmt.Fprintf(out, "release %q renamed to %q in namespace %q\n", args[0], rel.Name, rel.Namespace)
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&client.Namespace, "to-namespace", "", "namespace to move the release to")
+	f.BoolVar(&client.DryRun, "dry-run", false, "simulate a rename")
+
+	return cmd
+}
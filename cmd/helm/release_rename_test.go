@@ -0,0 +1,61 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestReleaseRenameCmd(t *testing.T) {
+	rels := func() []*release.Release {
+		return []*release.Release{{
+			Name:      "old-app",
+			Namespace: "default",
+			Info:      &release.Info{Status: release.StatusSuperseded},
+			Chart:     &chart.Chart{},
+			Version:   1,
+		}, {
+			Name:      "old-app",
+			Namespace: "default",
+			Info:      &release.Info{Status: release.StatusDeployed},
+			Chart:     &chart.Chart{},
+			Version:   2,
+		}}
+	}
+
+	tests := []cmdTestCase{{
+		name:   "rename a release",
+		cmd:    "release rename old-app new-app",
+		golden: "output/release-rename.txt",
+		rels:   rels(),
+	}, {
+		name:   "rename a release dry run",
+		cmd:    "release rename old-app new-app --dry-run",
+		golden: "output/release-rename-dry-run.txt",
+		rels:   rels(),
+	}, {
+		name:      "rename a release to its own name",
+		cmd:       "release rename old-app old-app",
+		golden:    "output/release-rename-same.txt",
+		rels:      rels(),
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
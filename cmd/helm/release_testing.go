@@ -19,15 +19,18 @@ package main
 import (
 	"fmt"
 	"io"
+	"os"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"helm.sh/helm/v3/cmd/helm/require"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/release"
 )
 
 const releaseTestHelp = `
@@ -35,6 +38,17 @@ The test command runs the tests for a release.
 
 The argument this command takes is the name of a deployed release.
 The tests to be run are defined in the chart that was installed.
+
+Tests can be grouped into suites with the "helm.sh/test-suite" annotation, and
+selected by suite with '--suite' or by label with '--selector'. Tests of equal
+"helm.sh/hook-weight" run concurrently, at most '--parallelism' at once; tests
+of higher weight run once all tests of lower weight completed. With
+'--fail-fast', the running tests are stopped once a test fails, and the
+remaining tests are not run; both are recorded in the "Cancelled" phase and
+reported as skipped.
+
+Use '--report-file' to write a JUnit XML or JSON report of the tests, with the
+logs of every container of the test pods, for consumption by CI systems.
 `
 
 func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -42,6 +56,7 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 	var outfmt = output.Table
 	var outputLogs bool
 	var filter []string
+	var reportFormat, reportFile string
 
 	cmd := &cobra.Command{
 		Use:   "test [RELEASE]",
@@ -55,6 +70,9 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 			return compListReleases(toComplete, args, cfg)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if reportFile != "" && reportFormat != action.TestReportJUnit && reportFormat != action.TestReportJSON {
+				return errors.Errorf("invalid report format %q, must be one of: %s, %s", reportFormat, action.TestReportJUnit, action.TestReportJSON)
+			}
 			client.Namespace = settings.Namespace()
 			notName := regexp.MustCompile(`^!\s?name=`)
 			for _, f := range filter {
@@ -64,7 +82,9 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 					client.Filters["!name"] = append(client.Filters["!name"], notName.ReplaceAllLiteralString(f, ""))
 				}
 			}
-			rel, runErr := client.Run(args[0])
+			ctx, cancel := interruptContext()
+			defer cancel()
+			rel, runErr := client.RunWithContext(ctx, args[0])
 			// We only return an error if we weren't even able to get the
 			// release, otherwise we keep going so we can print status and logs
 			// if requested
@@ -72,7 +92,7 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 				return runErr
 			}
 
-			if err := outfmt.Write(out, &statusPrinter{rel, settings.Debug, false}); err != nil {
+			if err := outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, nil}); err != nil {
 				return err
 			}
 
@@ -84,6 +104,12 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 				}
 			}
 
+			if reportFile != "" {
+				if err := writeTestReport(client, rel, reportFormat, reportFile); err != nil {
+					return err
+				}
+			}
+
 			return runErr
 		},
 	}
@@ -91,7 +117,24 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 	f := cmd.Flags()
 	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
 	f.BoolVar(&outputLogs, "logs", false, "dump the logs from test pods (this runs after all tests are complete, but before any cleanup)")
+	f.StringVar(&reportFormat, "report-format", action.TestReportJUnit, fmt.Sprintf("format of the test report written to --report-file. Allowed values: %s, %s", action.TestReportJUnit, action.TestReportJSON))
+	f.StringVar(&reportFile, "report-file", "", "write a report of the tests, including the logs of every container of the test pods, to the given file")
+	f.StringSliceVar(&client.Suites, "suite", []string{}, "run only the tests of the given test suites, set by the \"helm.sh/test-suite\" annotation (can specify multiple or separate values with commas: suite1,suite2)")
+	f.StringVarP(&client.Selector, "selector", "l", "", "run only the tests matching the selector (label query), supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	f.IntVar(&client.Parallelism, "parallelism", 0, "maximum number of tests of equal weight run at once (0 runs them all at once)")
+	f.BoolVar(&client.FailFast, "fail-fast", false, "stop the remaining tests once a test fails, cleaning them up according to their delete policy")
 	f.StringSliceVar(&filter, "filter", []string{}, "specify tests by attribute (currently \"name\") using attribute=value syntax or '!attribute=value' to exclude a test (can specify multiple or separate values with commas: name=test1,name=test2)")
 
 	return cmd
 }
+
+// writeTestReport writes the report of the tests of a release to a file.
+func writeTestReport(client *action.ReleaseTesting, rel *release.Release, format, filename string) error {
+	report := client.Report(rel)
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.Wrap(err, "unable to create test report")
+	}
+	defer f.Close()
+	return report.Write(f, format)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const releaseTransferDesc = `
+This command moves resources from one release to another release in the same
+namespace.
+
+The resources selected with '--resource KIND/NAME' are removed from the manifest
+of the source release and added to the manifest of the target release, each in
+a new revision. The labels and annotations recording the release owning the
+resources are rewritten to the target release; the live objects are otherwise
+left untouched.
+
+Upgrading the source release no longer deletes the transferred resources, and
+the chart of the target release should render them from now on, otherwise the
+next upgrade of the target release deletes them.
+
+	$ helm release transfer old-app new-app --resource ConfigMap/settings --resource Secret/credentials
+`
+
+func newReleaseTransferCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewReleaseTransfer(cfg)
+
+	cmd := &cobra.Command{
+		Use:   "transfer SOURCE_RELEASE TARGET_RELEASE",
+		Short: "move resources from one release to another",
+		Long:  releaseTransferDesc,
+		Args:  require.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, to, err := client.Run(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			if client.DryRun {
+				fmt.Fprintf(out, "transfer dry run complete, release %q would be at revision %d and release %q at revision %d\n",
+					from.Name, from.Version, to.Name, to.Version)
+				return nil
+			}
+			fmt.Fprintf(out, "transferred %d resource(s) from release %q (revision %d) to release %q (revision %d)\n",
+				len(client.Resources), from.Name, from.Version, to.Name, to.Version)
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringArrayVar(&client.Resources, "resource", nil, "resource to transfer as KIND/NAME (can specify multiple)")
+	f.BoolVar(&client.DryRun, "dry-run", false, "simulate a transfer")
+
+	return cmd
+}
@@ -0,0 +1,60 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestReleaseTransferCmd(t *testing.T) {
+	rels := func() []*release.Release {
+		return []*release.Release{{
+			Name:     "old-app",
+			Info:     &release.Info{Status: release.StatusDeployed},
+			Chart:    &chart.Chart{},
+			Version:  3,
+			Manifest: "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n",
+		}, {
+			Name:    "new-app",
+			Info:    &release.Info{Status: release.StatusDeployed},
+			Chart:   &chart.Chart{},
+			Version: 1,
+		}}
+	}
+
+	tests := []cmdTestCase{{
+		name:   "transfer a resource",
+		cmd:    "release transfer old-app new-app --resource configmap/settings",
+		golden: "output/release-transfer.txt",
+		rels:   rels(),
+	}, {
+		name:   "transfer a resource dry run",
+		cmd:    "release transfer old-app new-app --resource ConfigMap/settings --dry-run",
+		golden: "output/release-transfer-dry-run.txt",
+		rels:   rels(),
+	}, {
+		name:      "transfer a missing resource",
+		cmd:       "release transfer old-app new-app --resource Secret/settings",
+		golden:    "output/release-transfer-missing.txt",
+		rels:      rels(),
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const releaseUnlockDesc = `
+This command recovers a release that is stuck in a pending state.
+
+When Helm is interrupted while installing, upgrading or rolling back a release,
+the last revision of the release stays pending and further operations on it are
+refused. Each pending revision records a lease naming the client that holds it
+and when the lease expires. Once the lease has expired, this command marks the
+revision as failed so the release can be upgraded or rolled back again.
+Revisions written by versions of Helm that do not record leases are unlocked as
+if their lease had expired.
+
+Use '--force' to unlock a release whose lease has not expired yet. Only do so if
+you are sure the operation holding the lease is no longer running.
+`
+
+func newReleaseUnlockCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewUnlock(cfg)
+
+	cmd := &cobra.Command{
+		Use:     "unlock RELEASE_NAME",
+		Aliases: []string{"recover"},
+		Short:   "recover a release stuck in a pending state",
+		Long:    releaseUnlockDesc,
+		Args:    require.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rel, err := client.Run(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "release %q unlocked, revision %d marked as %s\n", rel.Name, rel.Version, rel.Info.Status)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&client.Force, "force", false, "unlock the release even if the lease on it has not expired")
+
+	return cmd
+}
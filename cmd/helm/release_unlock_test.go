@@ -0,0 +1,68 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+func TestReleaseUnlockCmd(t *testing.T) {
+	rels := func(lease *release.Lease) []*release.Release {
+		return []*release.Release{{
+			Name:    "funny-honey",
+			Info:    &release.Info{Status: release.StatusDeployed},
+			Chart:   &chart.Chart{},
+			Version: 1,
+		}, {
+			Name:    "funny-honey",
+			Info:    &release.Info{Status: release.StatusPendingUpgrade, Lease: lease},
+			Chart:   &chart.Chart{},
+			Version: 2,
+		}}
+	}
+	expired := &release.Lease{Holder: "gone", AcquiredAt: helmtime.Time{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}, TTL: time.Hour}
+	held := &release.Lease{Holder: "busy", AcquiredAt: helmtime.Time{Time: time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)}, TTL: time.Hour}
+
+	tests := []cmdTestCase{{
+		name:   "unlock a pending release",
+		cmd:    "release unlock funny-honey",
+		golden: "output/release-unlock.txt",
+		rels:   rels(expired),
+	}, {
+		name:   "recover a pending release",
+		cmd:    "release recover funny-honey",
+		golden: "output/release-unlock.txt",
+		rels:   rels(expired),
+	}, {
+		name:      "unlock a release with a held lease",
+		cmd:       "release unlock funny-honey",
+		golden:    "output/release-unlock-held.txt",
+		rels:      rels(held),
+		wantError: true,
+	}, {
+		name:   "force unlock a release with a held lease",
+		cmd:    "release unlock funny-honey --force",
+		golden: "output/release-unlock.txt",
+		rels:   rels(held),
+	}}
+	runTestCmd(t, tests)
+}
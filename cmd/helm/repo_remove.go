@@ -81,12 +81,14 @@ func (o *repoRemoveOptions) run(out io.Writer) error {
 }
 
 func removeRepoCache(root, name string) error {
-	idx := filepath.Join(root, helmpath.CacheChartsFile(name))
-	if _, err := os.Stat(idx); err == nil {
-		os.Remove(idx)
+	for _, f := range []string{helmpath.CacheChartsFile(name), helmpath.CacheCompactIndexFile(name)} {
+		f = filepath.Join(root, f)
+		if _, err := os.Stat(f); err == nil {
+			os.Remove(f)
+		}
 	}
 
-	idx = filepath.Join(root, helmpath.CacheIndexFile(name))
+	idx := filepath.Join(root, helmpath.CacheIndexFile(name))
 	if _, err := os.Stat(idx); os.IsNotExist(err) {
 		return nil
 	} else if err != nil {
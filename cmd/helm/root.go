@@ -52,7 +52,10 @@ Environment variables:
 | $HELM_DATA_HOME                    | set an alternative location for storing Helm data.                                |
 | $HELM_DEBUG                        | indicate whether or not Helm is running in Debug mode                             |
 | $HELM_DRIVER                       | set the backend storage driver. Values are: configmap, secret, memory, postgres   |
+| $HELM_DRIVER_ENCRYPTION_KEYFILE    | set the key file used to encrypt releases stored by the storage driver.           |
+| $HELM_DRIVER_ENCRYPTION_KMS_PLUGIN | set the KMS plugin used to encrypt releases instead of a key file.                |
 | $HELM_DRIVER_SQL_CONNECTION_STRING | set the connection string the SQL storage driver should use.                      |
+| $HELM_DRIVER_SEPARATE_CHARTS       | store each chart once per digest when true. Not supported by the SQL driver.      |
 | $HELM_MAX_HISTORY                  | set the maximum number of helm release history.                                   |
 | $HELM_NAMESPACE                    | set the namespace used for the helm operations.                                   |
 | $HELM_NO_PLUGINS                   | disable plugins. Set HELM_NO_PLUGINS=1 to disable plugins.                        |
@@ -181,6 +184,7 @@ func newRootCmd(actionConfig *action.Configuration, out io.Writer, args []string
 		newHistoryCmd(actionConfig, out),
 		newInstallCmd(actionConfig, out),
 		newListCmd(actionConfig, out),
+		newReleaseCmd(actionConfig, out),
 		newReleaseTestCmd(actionConfig, out),
 		newRollbackCmd(actionConfig, out),
 		newStatusCmd(actionConfig, out),
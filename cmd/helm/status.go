@@ -17,12 +17,15 @@ limitations under the License.
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"strings"
 	"time"
 
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"helm.sh/helm/v3/cmd/helm/require"
@@ -44,11 +47,24 @@ The status consists of:
 - list of resources that this release consists of, sorted by kind
 - details on last test suite run, if applicable
 - additional notes provided by the chart
+
+Use '--drift' to compare the live resources of the deployed release with its
+manifest instead. Resources missing from the cluster and fields that differ
+from the manifest are reported, ignoring the status, the metadata populated by
+the API server and fields not set in the manifest. The command exits with a
+non-zero status if any resource drifted, so it can be used for monitoring.
+
+Use '--show-resources' to also list every resource of the manifest with its
+live state: ready, not-ready or missing. Readiness is checked like '--wait'
+does, and the reasons why pods are not ready, such as image pull errors and
+crash loops, are shown for pods and the resources managing them.
 `
 
 func newStatusCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	client := action.NewStatus(cfg)
+	drift := action.NewDrift(cfg)
 	var outfmt output.Format
+	var showDrift, showResources bool
 
 	cmd := &cobra.Command{
 		Use:   "status RELEASE_NAME",
@@ -62,15 +78,37 @@ func newStatusCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			return compListReleases(toComplete, args, cfg)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if showDrift {
+				drift.Version = client.Version
+				res, err := drift.Run(args[0])
+				if err != nil {
+					return err
+				}
+				if err := outfmt.Write(out, &driftPrinter{res}); err != nil {
+					return err
+				}
+				if res.Drifted() {
+					return errors.Errorf("release %q has drifted from revision %d", res.Name, res.Revision)
+				}
+				return nil
+			}
+
 			rel, err := client.Run(args[0])
 			if err != nil {
 				return err
 			}
 
+			var resources []action.ResourceStatus
+			if showResources {
+				if resources, err = client.Resources(rel); err != nil {
+					return err
+				}
+			}
+
 			// strip chart metadata from the output
 			rel.Chart = nil
 
-			return outfmt.Write(out, &statusPrinter{rel, false, client.ShowDescription})
+			return outfmt.Write(out, &statusPrinter{rel, false, client.ShowDescription, resources})
 		},
 	}
 
@@ -91,6 +129,8 @@ func newStatusCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 
 	bindOutputFlag(cmd, &outfmt)
 	f.BoolVar(&client.ShowDescription, "show-desc", false, "if set, display the description message of the named release")
+	f.BoolVar(&showDrift, "drift", false, "compare the live resources with the manifest of the deployed release, or of the revision set by --revision, and exit with a non-zero status if any drifted")
+	f.BoolVar(&showResources, "show-resources", false, "if set, display the live state of every resource of the named release")
 
 	return cmd
 }
@@ -99,14 +139,30 @@ type statusPrinter struct {
 	release         *release.Release
 	debug           bool
 	showDescription bool
+	// resources is the live state of the resources of the release, which is
+	// only displayed if set.
+	resources []action.ResourceStatus
+}
+
+// releaseWithResources adds the live state of its resources to a release.
+type releaseWithResources struct {
+	*release.Release
+	Resources []action.ResourceStatus `json:"resources"`
+}
+
+func (s statusPrinter) content() interface{} {
+	if s.resources == nil {
+		return s.release
+	}
+	return releaseWithResources{s.release, s.resources}
 }
 
 func (s statusPrinter) WriteJSON(out io.Writer) error {
-	return output.EncodeJSON(out, s.release)
+	return output.EncodeJSON(out, s.content())
 }
 
 func (s statusPrinter) WriteYAML(out io.Writer) error {
-	return output.EncodeYAML(out, s.release)
+	return output.EncodeYAML(out, s.content())
 }
 
 func (s statusPrinter) WriteTable(out io.Writer) error {
@@ -142,6 +198,24 @@ func (s statusPrinter) WriteTable(out io.Writer) error {
 		}
 	}
 
+	if len(s.release.Info.Adopted) > 0 {
+		fmt.Fprintln(out, "ADOPTED RESOURCES:")
+		for _, r := range s.release.Info.Adopted {
+			fmt.Fprintf(out, "[%s] %s\n", r.Kind, r.Name)
+		}
+	}
+
+	if s.resources != nil {
+		if len(s.resources) == 0 {
+			fmt.Fprintln(out, "RESOURCES: None")
+		} else {
+			fmt.Fprintln(out, "RESOURCES:")
+			if err := output.EncodeTable(out, resourceStatusTable(s.resources)); err != nil {
+				return err
+			}
+		}
+	}
+
 	if s.debug {
 		fmt.Fprintln(out, "USER-SUPPLIED VALUES:")
 		err := output.EncodeYAML(out, s.release.Config)
@@ -179,6 +253,70 @@ func (s statusPrinter) WriteTable(out io.Writer) error {
 	return nil
 }
 
+// resourceStatusTable lists the resources with one line per reason a resource
+// is not ready.
+func resourceStatusTable(resources []action.ResourceStatus) *uitable.Table {
+	table := uitable.New()
+	table.AddRow("KIND", "NAME", "NAMESPACE", "STATUS", "REASON")
+	for _, r := range resources {
+		reason := ""
+		if len(r.Reasons) > 0 {
+			reason = r.Reasons[0]
+		}
+		table.AddRow(r.Kind, r.Name, r.Namespace, r.Status, reason)
+		for i := 1; i < len(r.Reasons); i++ {
+			table.AddRow("", "", "", "", r.Reasons[i])
+		}
+	}
+	return table
+}
+
+type driftPrinter struct {
+	drift *action.ReleaseDrift
+}
+
+func (d driftPrinter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, d.drift)
+}
+
+func (d driftPrinter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, d.drift)
+}
+
+func (d driftPrinter) WriteTable(out io.Writer) error {
+	fmt.Fprintf(out, "NAME: %s\n", d.drift.Name)
+	fmt.Fprintf(out, "NAMESPACE: %s\n", d.drift.Namespace)
+	fmt.Fprintf(out, "REVISION: %d\n", d.drift.Revision)
+	if !d.drift.Drifted() {
+		fmt.Fprintln(out, "DRIFT: None")
+		return nil
+	}
+	fmt.Fprintf(out, "DRIFT: %d resource(s)\n", len(d.drift.Resources))
+	for _, r := range d.drift.Resources {
+		fmt.Fprintf(out, "%s %q in namespace %q: %s\n", r.Kind, r.Name, r.Namespace, r.Change)
+		for _, f := range r.Fields {
+			if f.Redacted {
+				fmt.Fprintf(out, "    %s: differs (value redacted)\n", f.Path)
+				continue
+			}
+			fmt.Fprintf(out, "    %s: expected %s, got %s\n", f.Path, driftValue(f.Expected), driftValue(f.Actual))
+		}
+	}
+	return nil
+}
+
+// driftValue formats a field value as JSON.
+func driftValue(v interface{}) string {
+	if v == nil {
+		return "<unset>"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
 func executionsByHookEvent(rel *release.Release) map[release.HookEvent][]*release.Hook {
 	result := make(map[release.HookEvent][]*release.Hook)
 	for _, h := range rel.Hooks {
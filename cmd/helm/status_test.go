@@ -17,9 +17,12 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
+	"helm.sh/helm/v3/internal/test"
+	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/release"
 	helmtime "helm.sh/helm/v3/pkg/time"
@@ -60,6 +63,24 @@ func TestStatusCmd(t *testing.T) {
 			Status: release.StatusDeployed,
 			Notes:  "release notes",
 		}),
+	}, {
+		name:   "get status of a deployed release with adopted resources",
+		cmd:    "status flummoxed-chickadee",
+		golden: "output/status-with-adopted.txt",
+		rels: releasesMockWithStatus(&release.Info{
+			Status: release.StatusDeployed,
+			Adopted: []release.ResourceReference{
+				{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "settings"},
+				{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"},
+			},
+		}),
+	}, {
+		name:   "get drift of a deployed release",
+		cmd:    "status flummoxed-chickadee --drift",
+		golden: "output/status-drift-none.txt",
+		rels: releasesMockWithStatus(&release.Info{
+			Status: release.StatusDeployed,
+		}),
 	}, {
 		name:   "get status of a deployed release with notes in json",
 		cmd:    "status flummoxed-chickadee -o json",
@@ -112,6 +133,90 @@ func TestStatusCmd(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+func TestDriftPrinter(t *testing.T) {
+	drift := &action.ReleaseDrift{
+		Name:      "flummoxed-chickadee",
+		Namespace: "default",
+		Revision:  2,
+		Resources: []action.ResourceDrift{{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Namespace:  "default",
+			Name:       "settings",
+			Change:     action.DriftMissing,
+		}, {
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Namespace:  "default",
+			Name:       "web",
+			Change:     action.DriftModified,
+			Fields: []action.FieldDrift{
+				{Path: "spec.replicas", Expected: int64(3), Actual: int64(5)},
+				{Path: "spec.template.spec.containers[0].image", Expected: "nginx:1.19", Actual: "nginx:1.21"},
+				{Path: "spec.paused", Expected: true},
+			},
+		}, {
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Namespace:  "default",
+			Name:       "credentials",
+			Change:     action.DriftModified,
+			Fields:     []action.FieldDrift{{Path: "data.password", Redacted: true}},
+		}},
+	}
+	var out bytes.Buffer
+	if err := (driftPrinter{drift}).WriteTable(&out); err != nil {
+		t.Fatal(err)
+	}
+	test.AssertGoldenString(t, out.String(), "output/status-drift.txt")
+}
+
+func TestStatusPrinterResources(t *testing.T) {
+	rel := &release.Release{
+		Name:      "flummoxed-chickadee",
+		Namespace: "default",
+		Version:   1,
+		Info: &release.Info{
+			LastDeployed: mustParseTime("2016-01-16T00:00:00Z"),
+			Status:       release.StatusDeployed,
+		},
+	}
+	resources := []action.ResourceStatus{{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Namespace:  "default",
+		Name:       "settings",
+		Status:     action.ResourceReady,
+	}, {
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Namespace:  "default",
+		Name:       "web",
+		Status:     action.ResourceNotReady,
+		Reasons: []string{
+			"pod web-6d4b75cb6d-8x2kq: container web: CrashLoopBackOff: back-off 5m0s restarting failed container",
+			"pod web-6d4b75cb6d-q7zfn: container web: ImagePullBackOff: Back-off pulling image \"nginx:nope\"",
+		},
+	}, {
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Namespace:  "default",
+		Name:       "token",
+		Status:     action.ResourceMissing,
+	}}
+	var out bytes.Buffer
+	if err := (statusPrinter{rel, false, false, resources}).WriteTable(&out); err != nil {
+		t.Fatal(err)
+	}
+	test.AssertGoldenString(t, out.String(), "output/status-resources.txt")
+
+	out.Reset()
+	if err := (statusPrinter{rel, false, false, resources}).WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	test.AssertGoldenString(t, out.String(), "output/status-resources.json")
+}
+
 func mustParseTime(t string) helmtime.Time {
 	res, _ := helmtime.Parse(time.RFC3339, t)
 	return res
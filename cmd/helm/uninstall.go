@@ -51,9 +51,12 @@ func newUninstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			return compListReleases(toComplete, args, cfg)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := interruptContext()
+			defer cancel()
+
 			for i := 0; i < len(args); i++ {
 
-				res, err := client.Run(args[i])
+				res, err := client.RunWithContext(ctx, args[i])
 				if err != nil {
 					return err
 				}
@@ -22,6 +22,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/gosuri/uitable"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
@@ -32,6 +33,7 @@ import (
 	"helm.sh/helm/v3/pkg/cli/values"
 	"helm.sh/helm/v3/pkg/downloader"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage/driver"
 )
 
@@ -67,6 +69,7 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	valueOpts := &values.Options{}
 	var outfmt output.Format
 	var createNamespace bool
+	var showDiff, diffLive bool
 
 	cmd := &cobra.Command{
 		Use:   "upgrade [RELEASE] [CHART]",
@@ -85,6 +88,14 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client.Namespace = settings.Namespace()
 
+			if diffLive && !client.DryRun {
+				return errors.New("--diff-live can only be used together with --dry-run")
+			}
+			if showDiff && !client.DryRun {
+				return errors.New("--diff can only be used together with --dry-run")
+			}
+			showDiff = showDiff || diffLive
+
 			// Fixes #7002 - Support reading values from STDIN for `upgrade` command
 			// Must load values AFTER determining if we have to call install so that values loaded from stdin are are not read twice
 			if client.Install {
@@ -112,12 +123,22 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 					instClient.DisableOpenAPIValidation = client.DisableOpenAPIValidation
 					instClient.SubNotes = client.SubNotes
 					instClient.Description = client.Description
+					instClient.ServerSideApply = client.ServerSideApply
+					instClient.ForceConflicts = client.ForceConflicts
+					instClient.Adopt = client.Adopt
+					if outfmt == output.Table {
+						instClient.WaitProgress = waitProgress(out)
+					}
 
 					rel, err := runInstall(args, instClient, valueOpts, out)
 					if err != nil {
 						return err
 					}
-					return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false})
+					if showDiff {
+						// There is no deployed release, so everything is added.
+						return writeDiff(out, cfg, rel, diffLive, outfmt)
+					}
+					return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, nil})
 				} else if err != nil {
 					return err
 				}
@@ -174,16 +195,27 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				warning("This chart is deprecated")
 			}
 
-			rel, err := client.Run(args[0], ch, vals)
+			if outfmt == output.Table {
+				client.WaitProgress = waitProgress(out)
+			}
+
+			ctx, cancel := interruptContext()
+			defer cancel()
+
+			rel, err := client.RunWithContext(ctx, args[0], ch, vals)
 			if err != nil {
 				return errors.Wrap(err, "UPGRADE FAILED")
 			}
 
+			if showDiff {
+				return writeDiff(out, cfg, rel, diffLive, outfmt)
+			}
+
 			if outfmt == output.Table {
 				fmt.Fprintf(out, "Release %q has been upgraded. Happy Helming!\n", args[0])
 			}
 
-			return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false})
+			return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, nil})
 		},
 	}
 
@@ -209,6 +241,10 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.BoolVar(&client.SubNotes, "render-subchart-notes", false, "if set, render subchart notes along with the parent")
 	f.StringVar(&client.Description, "description", "", "add a custom description")
 	f.BoolVar(&client.DependencyUpdate, "dependency-update", false, "update dependencies if they are missing before installing the chart")
+	addServerSideApplyFlags(f, &client.ServerSideApply, &client.ForceConflicts)
+	f.BoolVar(&client.Adopt, "adopt", false, "take ownership of existing resources that are not owned by any release instead of failing. Resources owned by another release are still refused")
+	f.BoolVar(&showDiff, "diff", false, "if set with --dry-run, show the differences between the deployed release and the proposed release instead of the release")
+	f.BoolVar(&diffLive, "diff-live", false, "like --diff, but compare the proposed release against the live cluster objects instead of the deployed release manifest")
 	addChartPathOptionsFlags(f, &client.ChartPathOptions)
 	addValueOptionsFlags(f, valueOpts)
 	bindOutputFlag(cmd, &outfmt)
@@ -227,3 +263,46 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 
 	return cmd
 }
+
+// writeDiff writes the differences between the deployed release and the
+// proposed release rel.
+func writeDiff(out io.Writer, cfg *action.Configuration, rel *release.Release, live bool, outfmt output.Format) error {
+	differ := action.NewDiff(cfg)
+	differ.Live = live
+	diff, err := differ.Run(rel)
+	if err != nil {
+		return err
+	}
+	return outfmt.Write(out, &diffPrinter{diff})
+}
+
+type diffPrinter struct {
+	diff *action.ReleaseDiff
+}
+
+func (p diffPrinter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, p.diff)
+}
+
+func (p diffPrinter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, p.diff)
+}
+
+func (p diffPrinter) WriteTable(out io.Writer) error {
+	if len(p.diff.Resources) == 0 {
+		fmt.Fprintf(out, "Release %q has no changes.\n", p.diff.Name)
+		return nil
+	}
+	table := uitable.New()
+	table.AddRow("KIND", "NAMESPACE", "NAME", "CHANGE")
+	for _, r := range p.diff.Resources {
+		table.AddRow(r.Kind, r.Namespace, r.Name, r.Change)
+	}
+	if err := output.EncodeTable(out, table); err != nil {
+		return err
+	}
+	for _, r := range p.diff.Resources {
+		fmt.Fprintf(out, "# %s %s (%s)\n%s\n", r.Kind, r.Name, r.Change, r.Diff)
+	}
+	return nil
+}
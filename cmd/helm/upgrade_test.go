@@ -176,6 +176,35 @@ func TestUpgradeCmd(t *testing.T) {
 			wantError: true,
 			rels:      []*release.Release{relWithStatusMock("funny-bunny", 2, ch, release.StatusPendingInstall)},
 		},
+		{
+			name:   "show the differences of an upgrade",
+			cmd:    "upgrade funny-bunny --dry-run --diff testdata/testcharts/alpine",
+			golden: "output/upgrade-diff.txt",
+			rels:   []*release.Release{relMock("funny-bunny", 2, ch)},
+		},
+		{
+			name:   "show the differences of an upgrade as JSON",
+			cmd:    "upgrade funny-bunny --dry-run --diff -o json testdata/testcharts/alpine",
+			golden: "output/upgrade-diff.json",
+			rels:   []*release.Release{relMock("funny-bunny", 2, ch)},
+		},
+		{
+			name:   "show the differences of an install with 'upgrade --install'",
+			cmd:    "upgrade wacky-bunny -i --dry-run --diff testdata/testcharts/alpine",
+			golden: "output/upgrade-diff-install.txt",
+		},
+		{
+			name:   "show no differences as JSON",
+			cmd:    fmt.Sprintf("upgrade wacky-bunny -i --dry-run --diff -o json '%s'", chartPath),
+			golden: "output/upgrade-diff-none.json",
+		},
+		{
+			name:      "show the live differences without --dry-run",
+			cmd:       fmt.Sprintf("upgrade funny-bunny --diff-live '%s'", chartPath),
+			golden:    "output/upgrade-diff-live-without-dry-run.txt",
+			wantError: true,
+			rels:      []*release.Release{relMock("funny-bunny", 2, ch)},
+		},
 	}
 	runTestCmd(t, tests)
 }
@@ -19,12 +19,16 @@ package registry // import "helm.sh/helm/v3/internal/experimental/registry"
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"sort"
+	"strings"
 
+	"github.com/containerd/containerd/remotes/docker"
 	auth "github.com/deislabs/oras/pkg/auth/docker"
 	"github.com/deislabs/oras/pkg/content"
 	"github.com/deislabs/oras/pkg/oras"
@@ -52,6 +56,8 @@ type (
 		resolver        *Resolver
 		cache           *Cache
 		columnWidth     uint
+		httpClient      *http.Client
+		plainHTTP       bool
 	}
 )
 
@@ -64,6 +70,9 @@ func NewClient(opts ...ClientOption) (*Client, error) {
 		opt(client)
 	}
 	// set defaults if fields are missing
+	if client.httpClient == nil {
+		client.httpClient = http.DefaultClient
+	}
 	if client.credentialsFile == "" {
 		client.credentialsFile = helmpath.CachePath("registry", CredentialsFileBasename)
 	}
@@ -77,7 +86,7 @@ func NewClient(opts ...ClientOption) (*Client, error) {
 		}
 	}
 	if client.resolver == nil {
-		resolver, err := client.authorizer.Resolver(context.Background(), http.DefaultClient, false)
+		resolver, err := client.authorizer.Resolver(context.Background(), client.httpClient, client.plainHTTP)
 		if err != nil {
 			return nil, err
 		}
@@ -123,6 +132,110 @@ func (c *Client) Logout(hostname string) error {
 	return nil
 }
 
+// Tags lists the tags of the repository of a reference in a registry,
+// following the pagination of the registry.
+func (c *Client) Tags(ref *Reference) ([]string, error) {
+	parts := strings.SplitN(ref.Repo, "/", 2)
+	if len(parts) != 2 {
+		return nil, errors.Errorf("invalid repository %s, must include a registry host", ref.Repo)
+	}
+	host, name := parts[0], parts[1]
+	scheme := "https"
+	if local, _ := docker.MatchLocalhost(host); local || c.plainHTTP {
+		scheme = "http"
+	}
+	next := fmt.Sprintf("%s://%s/v2/%s/tags/list", scheme, host, name)
+
+	authorizer := docker.NewDockerAuthorizer(docker.WithAuthClient(c.httpClient), docker.WithAuthCreds(c.credential))
+	var tags []string
+	for next != "" {
+		var page []string
+		var err error
+		if page, next, err = c.tagsPage(authorizer, next); err != nil {
+			return nil, errors.Wrapf(err, "failed to list the tags of %s", ref.Repo)
+		}
+		tags = append(tags, page...)
+	}
+	return tags, nil
+}
+
+// tagsPage fetches a page of the tags of a repository, returning the URL of
+// the next page, if any.
+func (c *Client) tagsPage(authorizer docker.Authorizer, u string) ([]string, string, error) {
+	// The registry challenges the first request, and the second one is
+	// authorized according to the challenge.
+	ctx := context.Background()
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := authorizer.Authorize(ctx, req); err != nil {
+			return nil, "", err
+		}
+		if resp, err = c.httpClient.Do(req.WithContext(ctx)); err != nil {
+			return nil, "", err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			break
+		}
+		err = authorizer.AddResponses(ctx, []*http.Response{resp})
+		resp.Body.Close()
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", errors.New(resp.Status)
+	}
+
+	var list struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, "", err
+	}
+	next, err := nextLink(resp.Request.URL, resp.Header.Get("Link"))
+	return list.Tags, next, err
+}
+
+// nextLink returns the URL of the link with the "next" relation of a Link
+// header, such as </v2/name/tags/list?last=b&n=2>; rel="next", resolved
+// against the URL of the request.
+func nextLink(base *url.URL, header string) (string, error) {
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.Split(link, ";")
+		target := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range parts[1:] {
+			param = strings.ReplaceAll(strings.TrimSpace(param), " ", "")
+			if !strings.HasPrefix(param, "rel=") || strings.Trim(strings.TrimPrefix(param, "rel="), `"`) != "next" {
+				continue
+			}
+			u, err := base.Parse(strings.Trim(target, "<>"))
+			if err != nil {
+				return "", errors.Wrap(err, "invalid next link")
+			}
+			return u.String(), nil
+		}
+	}
+	return "", nil
+}
+
+// credential returns the stored credentials for a registry host, if any.
+func (c *Client) credential(host string) (string, string, error) {
+	if cred, ok := c.authorizer.Client.(interface {
+		Credential(string) (string, string, error)
+	}); ok {
+		return cred.Credential(host)
+	}
+	return "", "", nil
+}
+
 // PushChart uploads a chart to a registry
 func (c *Client) PushChart(ref *Reference) error {
 	r, err := c.cache.FetchReference(ref)
@@ -18,6 +18,7 @@ package registry // import "helm.sh/helm/v3/internal/experimental/registry"
 
 import (
 	"io"
+	"net/http"
 )
 
 type (
@@ -74,3 +75,20 @@ func ClientOptColumnWidth(columnWidth uint) ClientOption {
 		client.columnWidth = columnWidth
 	}
 }
+
+// ClientOptHTTPClient returns a function that sets the HTTP client, such as one
+// with a custom TLS configuration, used for the requests to registries on a
+// client options set
+func ClientOptHTTPClient(httpClient *http.Client) ClientOption {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// ClientOptPlainHTTP returns a function that sets whether registries are
+// accessed over plain HTTP instead of HTTPS on a client options set
+func ClientOptPlainHTTP(plainHTTP bool) ClientOption {
+	return func(client *Client) {
+		client.plainHTTP = plainHTTP
+	}
+}
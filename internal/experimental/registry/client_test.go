@@ -196,6 +196,19 @@ func (suite *RegistryClientTestSuite) Test_3_PushChart() {
 	suite.Nil(err)
 }
 
+func (suite *RegistryClientTestSuite) Test_4_Tags() {
+	ref, err := ParseReference(fmt.Sprintf("%s/testrepo/testchart", suite.DockerRegistryHost))
+	suite.Nil(err)
+	tags, err := suite.RegistryClient.Tags(ref)
+	suite.Nil(err)
+	suite.Equal([]string{"1.2.3"}, tags)
+
+	ref, err = ParseReference(fmt.Sprintf("%s/testrepo/whodis", suite.DockerRegistryHost))
+	suite.Nil(err)
+	_, err = suite.RegistryClient.Tags(ref)
+	suite.NotNil(err)
+}
+
 func (suite *RegistryClientTestSuite) Test_4_PullChart() {
 
 	// non-existent ref
@@ -253,6 +266,99 @@ func TestRegistryClientTestSuite(t *testing.T) {
 	suite.Run(t, new(RegistryClientTestSuite))
 }
 
+// countingTransport counts the requests sent through it.
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestTagsPagination(t *testing.T) {
+	pages := map[string]struct {
+		tags string
+		link string
+	}{
+		"":      {`["0.1.0","0.2.0"]`, `</v2/charts/web/tags/list?last=0.2.0&n=2>; rel="next"`},
+		"0.2.0": {`["0.3.0","1.0.0"]`, `</v2/charts/web/tags/list?last=1.0.0&n=2>; rel="next"`},
+		"1.0.0": {`["1.1.0"]`, ``},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Query().Get("last")]
+		if r.URL.Path != "/v2/charts/web/tags/list" || !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if page.link != "" {
+			w.Header().Set("Link", page.link)
+		}
+		fmt.Fprintf(w, `{"name":"charts/web","tags":%s}`, page.tags)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "helm-registry-tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	authClient, err := auth.NewClient(filepath.Join(dir, CredentialsFileBasename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := NewCache(CacheOptRoot(filepath.Join(dir, CacheRootDir)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &countingTransport{}
+	client, err := NewClient(
+		ClientOptAuthorizer(&Authorizer{Client: authClient}),
+		ClientOptCache(cache),
+		ClientOptHTTPClient(&http.Client{Transport: transport}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := ParseReference(strings.TrimPrefix(srv.URL, "http://") + "/charts/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err := client.Tags(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := []string{"0.1.0", "0.2.0", "0.3.0", "1.0.0", "1.1.0"}; strings.Join(tags, ",") != strings.Join(expect, ",") {
+		t.Errorf("expected tags %v, got %v", expect, tags)
+	}
+	if transport.requests != 3 {
+		t.Errorf("expected 3 requests through the configured HTTP client, got %d", transport.requests)
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	base, err := url.Parse("https://registry.example.com/v2/charts/web/tags/list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for header, expect := range map[string]string{
+		``: ``,
+		`</v2/charts/web/tags/list?last=b&n=2>; rel="next"`:                                     `https://registry.example.com/v2/charts/web/tags/list?last=b&n=2`,
+		`<https://mirror.example.com/v2/charts/web/tags/list?last=b>; rel="next"`:               `https://mirror.example.com/v2/charts/web/tags/list?last=b`,
+		`</v2/charts/web/tags/list>; rel="prev", </v2/charts/web/tags/list?last=d>;rel=next`:    `https://registry.example.com/v2/charts/web/tags/list?last=d`,
+		`</v2/charts/web/tags/list>; rel="prev", </v2/charts/web/tags/list?last=d>; rel="next"`: `https://registry.example.com/v2/charts/web/tags/list?last=d`,
+	} {
+		next, err := nextLink(base, header)
+		if err != nil {
+			t.Errorf("%s: %s", header, err)
+		}
+		if next != expect {
+			t.Errorf("%s: expected next link %q, got %q", header, expect, next)
+		}
+	}
+}
+
 func initCompromisedRegistryTestServer() string {
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if strings.Contains(r.URL.Path, "manifests") {
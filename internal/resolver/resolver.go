@@ -40,6 +40,13 @@ const FeatureGateOCI = gates.Gate("HELM_EXPERIMENTAL_OCI")
 type Resolver struct {
 	chartpath string
 	cachepath string
+
+	// Transitive also resolves the dependencies of the dependencies, and
+	// records them in the lock. See resolveTransitive.
+	Transitive bool
+	// RepositoryConfig is the path to the repositories file, used to find
+	// the repositories of nested dependencies in transitive mode.
+	RepositoryConfig string
 }
 
 // New creates a new resolver for a given chart and a given helm home.
@@ -52,7 +59,29 @@ func New(chartpath, cachepath string) *Resolver {
 
 // Resolve resolves dependencies and returns a lock file with the resolution.
 func (r *Resolver) Resolve(reqs []*chart.Dependency, repoNames map[string]string) (*chart.Lock, error) {
+	resolve := r.resolveDirect
+	if r.Transitive {
+		resolve = r.resolveTransitive
+	}
+	locked, err := resolve(reqs, repoNames)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := HashReq(reqs, locked)
+	if err != nil {
+		return nil, err
+	}
 
+	return &chart.Lock{
+		Generated:    time.Now(),
+		Digest:       digest,
+		Dependencies: locked,
+	}, nil
+}
+
+// resolveDirect resolves the direct dependencies of the chart.
+func (r *Resolver) resolveDirect(reqs []*chart.Dependency, repoNames map[string]string) ([]*chart.Dependency, error) {
 	// Now we clone the dependencies, locking as we go.
 	locked := make([]*chart.Dependency, len(reqs))
 	missing := []string{}
@@ -155,6 +184,7 @@ func (r *Resolver) Resolve(reqs []*chart.Dependency, repoNames map[string]string
 			if constraint.Check(v) {
 				found = true
 				locked[i].Version = v.Original()
+				locked[i].Digest = NormalizeDigest(ver.Digest)
 				break
 			}
 		}
@@ -166,17 +196,7 @@ func (r *Resolver) Resolve(reqs []*chart.Dependency, repoNames map[string]string
 	if len(missing) > 0 {
 		return nil, errors.Errorf("can't get a valid version for repositories %s. Try changing the version constraint in Chart.yaml", strings.Join(missing, ", "))
 	}
-
-	digest, err := HashReq(reqs, locked)
-	if err != nil {
-		return nil, err
-	}
-
-	return &chart.Lock{
-		Generated:    time.Now(),
-		Digest:       digest,
-		Dependencies: locked,
-	}, nil
+	return locked, nil
 }
 
 // HashReq generates a hash of the dependencies.
@@ -192,6 +212,16 @@ func HashReq(req, lock []*chart.Dependency) (string, error) {
 	return "sha256:" + s, err
 }
 
+// NormalizeDigest prefixes a hexadecimal SHA-256 digest, as recorded in
+// repository indexes, with its algorithm. Empty and prefixed digests are
+// returned unchanged.
+func NormalizeDigest(digest string) string {
+	if digest == "" || strings.Contains(digest, ":") {
+		return digest
+	}
+	return "sha256:" + digest
+}
+
 // HashV2Req generates a hash of requirements generated in Helm v2.
 //
 // This should be used only to compare against another hash generated by the
@@ -16,6 +16,8 @@ limitations under the License.
 package resolver
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"helm.sh/helm/v3/pkg/chart"
@@ -79,7 +81,8 @@ func TestResolve(t *testing.T) {
 			},
 			expect: &chart.Lock{
 				Dependencies: []*chart.Dependency{
-					{Name: "alpine", Repository: "http://example.com", Version: "0.2.0"},
+					{Name: "alpine", Repository: "http://example.com", Version: "0.2.0",
+						Digest: "sha256:3c5e3a4f3a2a8d1fe2e4d0d5e2a4b9c3c1e8f7c5a52cbd3a9d7f5e0c9b1d2e3f"},
 				},
 			},
 		},
@@ -174,6 +177,93 @@ func TestResolve(t *testing.T) {
 			if d0.Version != e0.Version {
 				t.Errorf("%s: expected version %s, got %s", tt.name, e0.Version, d0.Version)
 			}
+			if d0.Digest != e0.Digest {
+				t.Errorf("%s: expected digest %s, got %s", tt.name, e0.Digest, d0.Digest)
+			}
+		})
+	}
+}
+
+func TestResolveTransitive(t *testing.T) {
+	repoNames := map[string]string{"frontend": "transitive", "backend": "transitive", "cycle-a": "transitive"}
+	r := New("testdata/chartpath", "testdata/repository")
+	r.Transitive = true
+	r.RepositoryConfig = "testdata/repositories.yaml"
+
+	l, err := r.Resolve([]*chart.Dependency{
+		{Name: "frontend", Repository: "https://example.com/charts", Version: "^1.0.0"},
+		{Name: "backend", Repository: "https://example.com/charts", Version: "1.0.0"},
+		{Name: "base", Repository: "file://base", Version: "0.1.0"},
+	}, repoNames)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// common is required by frontend and backend, and resolved to the
+	// highest version satisfying both.
+	common := &chart.Dependency{Name: "common", Repository: "https://example.com/charts", Version: "1.1.0",
+		Digest: "sha256:9f1c0c8e3d6b5a4f2e1d0c9b8a7f6e5d4c3b2a1f0e9d8c7b6a5f4e3d2c1b0a9f"}
+	expect := []*chart.Dependency{
+		{Name: "frontend", Repository: "https://example.com/charts", Version: "1.0.0", Dependencies: []*chart.Dependency{
+			{Name: "common", Repository: "https://example.com/charts/", Version: "1.1.0", Digest: common.Digest},
+		}},
+		{Name: "backend", Repository: "https://example.com/charts", Version: "1.0.0", Dependencies: []*chart.Dependency{
+			common,
+			{Name: "cache", Repository: "https://charts.example.org", Version: "1.0.0"},
+			{Name: "migrations", Repository: "file://../migrations", Version: "0.1.0"},
+		}},
+		{Name: "base", Repository: "file://base", Version: "0.1.0"},
+	}
+	if !reflect.DeepEqual(l.Dependencies, expect) {
+		for _, d := range l.Dependencies {
+			t.Errorf("unexpected lock of %s %s: %+v", d.Name, d.Version, d.Dependencies)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		req    []*chart.Dependency
+		expect []string
+	}{
+		{
+			name: "conflict",
+			req: []*chart.Dependency{
+				{Name: "frontend", Repository: "https://example.com/charts", Version: "^2.0.0"},
+				{Name: "backend", Repository: "https://example.com/charts", Version: "1.0.0"},
+			},
+			expect: []string{
+				"conflicting versions of chart common from https://example.com/charts",
+				"chartpath > frontend > common requires ^2.0.0",
+				"chartpath > backend > common requires ~1.1.0",
+			},
+		},
+		{
+			name: "cycle",
+			req: []*chart.Dependency{
+				{Name: "cycle-a", Repository: "https://example.com/charts", Version: "1.0.0"},
+			},
+			expect: []string{"dependency cycle: chartpath > cycle-a > cycle-b > cycle-a"},
+		},
+		{
+			name: "unsatisfied nested constraint",
+			req: []*chart.Dependency{
+				{Name: "frontend", Repository: "https://example.com/charts", Version: "^2.0.0"},
+				{Name: "base", Repository: "file://base", Version: "0.2.0"},
+			},
+			expect: []string{"can't get a valid version for repositories base"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.Resolve(tt.req, repoNames)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, e := range tt.expect {
+				if !strings.Contains(err.Error(), e) {
+					t.Errorf("expected error to contain %q, got %q", e, err)
+				}
+			}
 		})
 	}
 }
@@ -0,0 +1,385 @@
+/*
+Copyright The Helm Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/internal/urlutil"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// maxResolveIterations bounds the number of walks of the dependency graph
+// made to find a consistent set of versions.
+const maxResolveIterations = 20
+
+// graphNode is a chart on a path of the dependency graph.
+type graphNode struct {
+	name string
+	// key identifies the chart: its directory for local charts, and its name
+	// and repository for charts from repositories.
+	key string
+}
+
+// requirement is a version constraint on a chart at a path of the dependency
+// graph.
+type requirement struct {
+	path       string
+	constraint *semver.Constraints
+	version    string
+}
+
+// repoChart collects the requirements on a chart from a repository.
+type repoChart struct {
+	name         string
+	repository   string
+	versions     repo.ChartVersions
+	requirements []requirement
+}
+
+// graphWalker walks the dependency graph of a chart for resolveTransitive.
+type graphWalker struct {
+	r         *Resolver
+	repoNames map[string]string
+	repos     []*repo.Entry
+	indexes   map[string]*repo.IndexFile
+
+	// chosen is the version of each chart from a repository chosen after
+	// the previous walk, keyed by graphNode.key.
+	chosen map[string]string
+	// charts are the charts from repositories found by the current walk,
+	// keyed by graphNode.key.
+	charts  map[string]*repoChart
+	missing []string
+}
+
+// resolveTransitive resolves the dependencies of the chart and, recursively,
+// the dependencies of the charts from local directories and chart
+// repositories. The dependencies of charts from repositories are read from
+// the repository index.
+//
+// A chart from a repository required at several places of the graph is
+// resolved to the same version everywhere: the highest version satisfying
+// every constraint on it. If there is none, the error lists the constraints
+// with the path to each of them. The resolved dependencies of each dependency
+// are recorded in its Dependencies.
+//
+// Dependencies in the charts directory, dependencies from OCI registries,
+// the local dependencies of charts from repositories, which are packaged with
+// them, and the dependencies of charts from unknown repositories are locked
+// without being walked.
+func (r *Resolver) resolveTransitive(reqs []*chart.Dependency, repoNames map[string]string) ([]*chart.Dependency, error) {
+	w := &graphWalker{
+		r:         r,
+		repoNames: repoNames,
+		indexes:   make(map[string]*repo.IndexFile),
+		chosen:    make(map[string]string),
+	}
+	if r.RepositoryConfig != "" {
+		rf, err := repo.LoadFile(r.RepositoryConfig)
+		if err != nil && !os.IsNotExist(errors.Cause(err)) {
+			return nil, err
+		}
+		w.repos = rf.Repositories
+	}
+
+	chartpath, err := filepath.Abs(r.chartpath)
+	if err != nil {
+		return nil, err
+	}
+	root := graphNode{name: filepath.Base(chartpath), key: chartpath}
+	if md, err := chartutil.LoadChartfile(filepath.Join(chartpath, "Chart.yaml")); err == nil {
+		root.name = md.Name
+	}
+
+	for i := 0; i < maxResolveIterations; i++ {
+		w.charts = make(map[string]*repoChart)
+		w.missing = nil
+		locked, err := w.walk([]graphNode{root}, reqs, chartpath)
+		if err != nil {
+			return nil, err
+		}
+		if len(w.missing) > 0 {
+			return nil, errors.Errorf("can't get a valid version for repositories %s. Try changing the version constraint in Chart.yaml", strings.Join(w.missing, ", "))
+		}
+		chosen, err := w.choose()
+		if err != nil {
+			return nil, err
+		}
+		if reflect.DeepEqual(chosen, w.chosen) {
+			return locked, nil
+		}
+		w.chosen = chosen
+	}
+	return nil, errors.New("could not find a consistent set of versions for the dependency graph")
+}
+
+// walk resolves deps, the dependencies of the last chart of path. chartpath
+// is the directory of this chart, or empty if it comes from a repository.
+func (w *graphWalker) walk(path []graphNode, deps []*chart.Dependency, chartpath string) ([]*chart.Dependency, error) {
+	var locked []*chart.Dependency
+	for _, d := range deps {
+		constraint, err := semver.NewConstraint(d.Version)
+		if err != nil {
+			return nil, errors.Wrapf(err, "dependency %q of %s has an invalid version/constraint format", d.Name, pathString(path))
+		}
+		dep := &chart.Dependency{
+			Name:       d.Name,
+			Repository: d.Repository,
+			Version:    d.Version,
+		}
+		locked = append(locked, dep)
+
+		switch {
+		case d.Repository == "":
+			// Local chart subfolder
+			if chartpath != "" {
+				if _, err := GetLocalPath(filepath.Join("charts", d.Name), chartpath); err != nil {
+					return nil, err
+				}
+			}
+		case strings.HasPrefix(d.Repository, "file://"):
+			if chartpath == "" {
+				// Packaged with the chart from the repository.
+				continue
+			}
+			p, err := GetLocalPath(d.Repository, chartpath)
+			if err != nil {
+				return nil, err
+			}
+			ch, err := loader.LoadDir(p)
+			if err != nil {
+				return nil, err
+			}
+			v, err := semver.NewVersion(ch.Metadata.Version)
+			if err != nil {
+				// Not a legit entry.
+				continue
+			}
+			if !constraint.Check(v) {
+				w.missing = append(w.missing, pathString(append(path[1:len(path):len(path)], graphNode{name: d.Name})))
+				continue
+			}
+			dep.Version = ch.Metadata.Version
+
+			p, err = filepath.Abs(p)
+			if err != nil {
+				return nil, err
+			}
+			next, err := visit(path, graphNode{name: d.Name, key: p})
+			if err != nil {
+				return nil, err
+			}
+			if dep.Dependencies, err = w.walk(next, ch.Metadata.Dependencies, p); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(d.Repository, "oci://"):
+			if !FeatureGateOCI.IsEnabled() {
+				return nil, errors.Wrapf(FeatureGateOCI.Error(),
+					"repository %s is an OCI registry", d.Repository)
+			}
+		default:
+			if err := w.resolveRepoChart(path, d, dep, constraint); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return locked, nil
+}
+
+// resolveRepoChart locks dep, which comes from a chart repository, and walks
+// its dependencies.
+func (w *graphWalker) resolveRepoChart(path []graphNode, d, dep *chart.Dependency, constraint *semver.Constraints) error {
+	var repoName string
+	if len(path) == 1 {
+		repoName = w.repoNames[d.Name]
+	} else {
+		var err error
+		if repoName, dep.Repository, err = w.repoFor(d.Repository); err != nil {
+			return errors.Wrapf(err, "dependency %q of %s", d.Name, pathString(path))
+		}
+	}
+	// if the repository was not defined, but the dependency defines a repository url, bypass the cache
+	if repoName == "" {
+		return nil
+	}
+
+	index, err := w.index(repoName)
+	if err != nil {
+		return err
+	}
+	vs, ok := index.Entries[d.Name]
+	if !ok {
+		return errors.Errorf("%s chart not found in repo %s", d.Name, dep.Repository)
+	}
+
+	node := graphNode{name: d.Name, key: d.Name + " " + strings.TrimSuffix(dep.Repository, "/")}
+	next, err := visit(path, node)
+	if err != nil {
+		return err
+	}
+	c, ok := w.charts[node.key]
+	if !ok {
+		c = &repoChart{name: d.Name, repository: dep.Repository, versions: vs}
+		w.charts[node.key] = c
+	}
+	c.requirements = append(c.requirements, requirement{
+		path:       pathString(next),
+		constraint: constraint,
+		version:    d.Version,
+	})
+
+	ver := pickVersion(vs, w.chosen[node.key], constraint)
+	if ver == nil {
+		w.missing = append(w.missing, pathString(next[1:]))
+		return nil
+	}
+	dep.Version = ver.Version
+	dep.Digest = NormalizeDigest(ver.Digest)
+	dep.Dependencies, err = w.walk(next, ver.Dependencies, "")
+	return err
+}
+
+// choose picks the highest version of each chart from a repository satisfying
+// every constraint on it.
+func (w *graphWalker) choose() (map[string]string, error) {
+	keys := make([]string, 0, len(w.charts))
+	for k := range w.charts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	chosen := make(map[string]string, len(w.charts))
+	for _, k := range keys {
+		c := w.charts[k]
+		constraints := make([]*semver.Constraints, len(c.requirements))
+		for i, req := range c.requirements {
+			constraints[i] = req.constraint
+		}
+		ver := pickVersion(c.versions, "", constraints...)
+		if ver == nil {
+			return nil, conflictError(c)
+		}
+		chosen[k] = ver.Version
+	}
+	return chosen, nil
+}
+
+// repoFor returns the name and the URL of the repository of a nested
+// dependency, given as a URL or an alias of a configured repository. The name
+// is empty if the repository is not configured.
+func (w *graphWalker) repoFor(repository string) (string, string, error) {
+	for _, prefix := range []string{"@", "alias:"} {
+		if !strings.HasPrefix(repository, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(repository, prefix)
+		for _, re := range w.repos {
+			if re.Name == name {
+				return re.Name, re.URL, nil
+			}
+		}
+		return "", "", errors.Errorf("no repository definition for %s. Please add it via 'helm repo add'", repository)
+	}
+	for _, re := range w.repos {
+		if urlutil.Equal(re.URL, repository) {
+			return re.Name, repository, nil
+		}
+	}
+	return "", repository, nil
+}
+
+// index loads the cached index of a repository.
+func (w *graphWalker) index(repoName string) (*repo.IndexFile, error) {
+	if index, ok := w.indexes[repoName]; ok {
+		return index, nil
+	}
+	index, err := repo.LoadIndexFile(filepath.Join(w.r.cachepath, helmpath.CacheIndexFile(repoName)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "no cached repository for %s found. (try 'helm repo update')", repoName)
+	}
+	w.indexes[repoName] = index
+	return index, nil
+}
+
+// pickVersion returns the preferred version if it satisfies the constraints,
+// and the highest version satisfying them otherwise.
+func pickVersion(vs repo.ChartVersions, preferred string, constraints ...*semver.Constraints) *repo.ChartVersion {
+	var found *repo.ChartVersion
+	// The version are already sorted and hence the first one to satisfy the constraint is used
+	for _, ver := range vs {
+		v, err := semver.NewVersion(ver.Version)
+		if err != nil || len(ver.URLs) == 0 {
+			// Not a legit entry.
+			continue
+		}
+		ok := true
+		for _, c := range constraints {
+			ok = ok && c.Check(v)
+		}
+		if !ok {
+			continue
+		}
+		if ver.Version == preferred {
+			return ver
+		}
+		if found == nil {
+			found = ver
+		}
+	}
+	return found
+}
+
+// visit appends a chart to a path, failing if the chart is already on it.
+func visit(path []graphNode, node graphNode) ([]graphNode, error) {
+	next := append(path[:len(path):len(path)], node)
+	for _, n := range path {
+		if n.key == node.key {
+			return nil, errors.Errorf("dependency cycle: %s", pathString(next))
+		}
+	}
+	return next, nil
+}
+
+// conflictError reports the constraints on a chart that no version satisfies.
+func conflictError(c *repoChart) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "conflicting versions of chart %s from %s, no version satisfies all the constraints:", c.name, c.repository)
+	for _, req := range c.requirements {
+		fmt.Fprintf(&b, "\n\t%s requires %s", req.path, req.version)
+	}
+	return errors.New(b.String())
+}
+
+func pathString(path []graphNode) string {
+	names := make([]string, len(path))
+	for i, n := range path {
+		names[i] = n.name
+	}
+	return strings.Join(names, " > ")
+}
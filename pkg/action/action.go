@@ -18,12 +18,14 @@ package action
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
+	gotime "time"
 
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -60,6 +62,10 @@ var (
 	errInvalidRevision = errors.New("invalid release revision")
 	// errPending indicates that another instance of Helm is already applying an operation on a release.
 	errPending = errors.New("another operation (install/upgrade/rollback) is in progress")
+	// errServerSideForce indicates that a forced replacement was requested together with server-side apply.
+	errServerSideForce = errors.New("forcing resource replacement is not supported with server-side apply")
+	// errForceConflicts indicates that forcing conflicts was requested without server-side apply.
+	errForceConflicts = errors.New("forcing conflicts requires server-side apply")
 )
 
 // ValidName is a regular expression for resource names.
@@ -94,6 +100,15 @@ type Configuration struct {
 	// Capabilities describes the capabilities of the Kubernetes cluster.
 	Capabilities *chartutil.Capabilities
 
+	// Encryptor encrypts the release records stored by the secret, configmap
+	// and sql drivers. It must be set before Init is called.
+	Encryptor driver.Encryptor
+
+	// SeparateCharts stores the chart of each release once per chart digest
+	// instead of embedding it in every revision. It must be set before Init
+	// is called, and is not supported by the sql driver.
+	SeparateCharts bool
+
 	Log func(string, ...interface{})
 }
 
@@ -306,6 +321,39 @@ func (cfg *Configuration) releaseContent(name string, version int) (*release.Rel
 	return cfg.Releases.Get(name, version)
 }
 
+// leaseGrace is added to the timeout of an operation to get the TTL of the
+// lease on its pending release, so that the lease covers the work done
+// between the waits of the operation.
+const leaseGrace = 5 * gotime.Minute
+
+// leasePending requests a lease on the pending release rel that lasts for
+// the timeout of the operation writing it. The lease is renewed every time
+// the release is recorded, so it only expires once the operation stops
+// making progress. Without a timeout the storage default TTL is used.
+func (cfg *Configuration) leasePending(rel *release.Release, timeout gotime.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	rel.Info.Lease = &release.Lease{Holder: cfg.Releases.LeaseHolder, TTL: timeout + leaseGrace}
+}
+
+// pendingError returns the error for an operation on a release whose last
+// revision is still pending. If the lease on the revision has expired, the
+// operation that wrote it was most likely interrupted and the error tells how
+// to recover the release.
+func pendingError(rel *release.Release) error {
+	lease := rel.Info.Lease
+	if lease == nil {
+		return errors.Errorf("%s, but the revision holds no lease, so it may have been written by an older version of Helm; if no operation is running, run 'helm release unlock %s' to recover the release",
+			errPending, rel.Name)
+	}
+	if !lease.Expired(gotime.Now()) {
+		return errPending
+	}
+	return errors.Errorf("%s, but the lease held by %q expired at %s; run 'helm release unlock %s' to recover the release",
+		errPending, lease.Holder, lease.ExpiresAt().Format(gotime.RFC3339), rel.Name)
+}
+
 // GetVersionSet retrieves a set of available k8s API versions
 func GetVersionSet(client discovery.ServerResourcesInterface) (chartutil.VersionSet, error) {
 	groups, resources, err := client.ServerGroupsAndResources()
@@ -361,6 +409,84 @@ func (cfg *Configuration) recordRelease(r *release.Release) {
 	}
 }
 
+// updateResources updates the current resources to the target resources,
+// using server-side apply instead of a client-side patch if serverSide is set.
+// Resources with the recreate policy are waited for up to the given timeout to
+// be deleted if the Kubernetes client supports it.
+func (cfg *Configuration) updateResources(ctx context.Context, current, target kube.ResourceList, force, serverSide, forceConflicts bool, timeout gotime.Duration) (*kube.Result, error) {
+	kubeClient, withContext := cfg.KubeClient.(kube.InterfaceUpdateWithContext)
+	if !serverSide {
+		if withContext {
+			return kubeClient.UpdateWithContext(ctx, current, target, force, timeout)
+		}
+		return cfg.KubeClient.Update(current, target, force)
+	}
+	ssa, ok := cfg.KubeClient.(kube.InterfaceServerSideApply)
+	if !ok {
+		return &kube.Result{}, errors.Errorf("kubernetes client %T does not support server-side apply", cfg.KubeClient)
+	}
+	if withContext {
+		return kubeClient.UpdateServerSideWithContext(ctx, current, target, forceConflicts, timeout)
+	}
+	return ssa.UpdateServerSide(current, target, forceConflicts)
+}
+
+// waitForResources waits for the resources to be ready, including jobs if
+// waitForJobs is set. It returns early if the context is cancelled. The
+// readiness of the resources is reported to progress if it is set and the
+// Kubernetes client supports it.
+func (cfg *Configuration) waitForResources(ctx context.Context, resources kube.ResourceList, timeout gotime.Duration, waitForJobs bool, progress kube.WaitProgressFunc) error {
+	err := cfg.wait(ctx, resources, timeout, waitForJobs, progress)
+	// The Kubernetes clients report a cancelled wait as a timeout.
+	if err != nil && ctx.Err() == context.Canceled {
+		return context.Canceled
+	}
+	return err
+}
+
+func (cfg *Configuration) wait(ctx context.Context, resources kube.ResourceList, timeout gotime.Duration, waitForJobs bool, progress kube.WaitProgressFunc) error {
+	if kc, ok := cfg.KubeClient.(kube.InterfaceWaitProgress); ok && progress != nil {
+		return kc.WaitWithProgress(ctx, resources, timeout, waitForJobs, progress)
+	}
+	if kc, ok := cfg.KubeClient.(kube.InterfaceWithContext); ok {
+		if waitForJobs {
+			return kc.WaitWithJobsWithContext(ctx, resources, timeout)
+		}
+		return kc.WaitWithContext(ctx, resources, timeout)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if waitForJobs {
+		return cfg.KubeClient.WaitWithJobs(resources, timeout)
+	}
+	return cfg.KubeClient.Wait(resources, timeout)
+}
+
+// watchUntilReady watches the resources until they are ready. It returns
+// early if the context is cancelled.
+func (cfg *Configuration) watchUntilReady(ctx context.Context, resources kube.ResourceList, timeout gotime.Duration) error {
+	if kc, ok := cfg.KubeClient.(kube.InterfaceWithContext); ok {
+		return kc.WatchUntilReadyWithContext(ctx, resources, timeout)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return cfg.KubeClient.WatchUntilReady(resources, timeout)
+}
+
+// validateServerSideApply checks that the server-side apply options of an
+// action can be used together.
+func validateServerSideApply(serverSide, force, forceConflicts bool) error {
+	if serverSide && force {
+		return errServerSideForce
+	}
+	if forceConflicts && !serverSide {
+		return errForceConflicts
+	}
+	return nil
+}
+
 // Init initializes the action configuration
 func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namespace, helmDriver string, log DebugLog) error {
 	kc := kube.New(getter)
@@ -376,10 +502,12 @@ func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namesp
 	case "secret", "secrets", "":
 		d := driver.NewSecrets(newSecretClient(lazyClient))
 		d.Log = log
+		d.Encryptor = cfg.Encryptor
 		store = storage.Init(d)
 	case "configmap", "configmaps":
 		d := driver.NewConfigMaps(newConfigMapClient(lazyClient))
 		d.Log = log
+		d.Encryptor = cfg.Encryptor
 		store = storage.Init(d)
 	case "memory":
 		var d *driver.Memory
@@ -403,13 +531,18 @@ func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namesp
 			namespace,
 		)
 		if err != nil {
-			panic(fmt.Sprintf("Unable to instantiate SQL driver: %v", err))
+			return errors.Wrap(err, "unable to instantiate SQL driver")
 		}
+		d.Encryptor = cfg.Encryptor
 		store = storage.Init(d)
 	default:
 		// Not sure what to do here.
 		panic("Unknown driver in HELM_DRIVER: " + helmDriver)
 	}
+	store.SeparateCharts = cfg.SeparateCharts
+	if _, ok := store.Driver.(driver.ChartStore); store.SeparateCharts && !ok {
+		return errors.Errorf("storing charts separately is not supported by the %s storage driver", store.Name())
+	}
 
 	cfg.RESTClientGetter = getter
 	cfg.KubeClient = kc
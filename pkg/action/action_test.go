@@ -25,6 +25,7 @@ import (
 	"testing"
 
 	dockerauth "github.com/deislabs/oras/pkg/auth/docker"
+	"k8s.io/apimachinery/pkg/util/wait"
 	fakeclientset "k8s.io/client-go/kubernetes/fake"
 
 	"helm.sh/helm/v3/internal/experimental/registry"
@@ -319,3 +320,19 @@ func TestGetVersionSet(t *testing.T) {
 		t.Error("Non-existent version is reported found.")
 	}
 }
+
+func TestWaitForResourcesCancelled(t *testing.T) {
+	cfg := actionConfigFixture(t)
+	// Like the real client, the fake one reports a cancelled wait as a timeout.
+	cfg.KubeClient = &kubefake.FailingKubeClient{WaitError: wait.ErrWaitTimeout}
+
+	if err := cfg.waitForResources(context.Background(), nil, 0, false, nil); err != wait.ErrWaitTimeout {
+		t.Errorf("expected %v, got %v", wait.ErrWaitTimeout, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := cfg.waitForResources(ctx, nil, 0, false, nil); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
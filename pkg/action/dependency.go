@@ -26,6 +26,7 @@ import (
 	"github.com/Masterminds/semver/v3"
 	"github.com/gosuri/uitable"
 
+	"helm.sh/helm/v3/internal/experimental/registry"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 )
@@ -38,6 +39,21 @@ type Dependency struct {
 	Keyring     string
 	SkipRefresh bool
 	ColumnWidth uint
+	// VerifyDigests fails the build if no digest is recorded in the lock file
+	// for a downloaded chart archive.
+	VerifyDigests bool
+	// Transitive also resolves the dependencies of the dependencies and
+	// records them in the lock file.
+	Transitive bool
+	// Offline builds the dependencies from the local chart cache only.
+	Offline bool
+
+	// RepositoryConfig and RepositoryCache locate the chart repositories
+	// and their cached indexes, used to list the versions of dependencies.
+	RepositoryConfig string
+	RepositoryCache  string
+	// RegistryClient lists the versions of dependencies in OCI registries.
+	RegistryClient *registry.Client
 }
 
 // NewDependency creates a new Dependency object with the given configuration.
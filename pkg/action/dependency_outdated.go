@@ -0,0 +1,218 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/internal/experimental/registry"
+	"helm.sh/helm/v3/internal/urlutil"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// OutdatedDependency describes the versions of a dependency newer than the
+// version locked for it.
+type OutdatedDependency struct {
+	Name       string `json:"name"`
+	Repository string `json:"repository"`
+	// Constraint is the version constraint declared in Chart.yaml.
+	Constraint string `json:"constraint"`
+	// Locked is the version recorded in the lock file.
+	Locked string `json:"locked"`
+	// Compatible are the newer versions satisfying the constraint, newest
+	// first.
+	Compatible []string `json:"compatible"`
+	// Incompatible are the newer versions not satisfying the constraint,
+	// newest first.
+	Incompatible []string `json:"incompatible"`
+	// Error is set if the versions of the dependency could not be listed.
+	Error string `json:"error,omitempty"`
+}
+
+// Outdated executes 'helm dependency outdated'.
+//
+// The versions recorded in the lock file of the chart are compared with the
+// versions in the cached indexes of the chart repositories, which are not
+// updated, and with the tags of the charts in OCI registries. Only the
+// dependencies with newer versions, or whose versions could not be listed,
+// are returned. Dependencies in the charts directory and local dependencies
+// are ignored.
+func (d *Dependency) Outdated(chartpath string) ([]*OutdatedDependency, error) {
+	c, err := loader.Load(chartpath)
+	if err != nil {
+		return nil, err
+	}
+	if c.Lock == nil {
+		return nil, errors.Errorf("no lock file found for chart %s, run 'helm dependency update' to create it", c.Name())
+	}
+	rf, err := repo.LoadFile(d.RepositoryConfig)
+	if err != nil && !os.IsNotExist(errors.Cause(err)) {
+		return nil, err
+	}
+
+	outdated := []*OutdatedDependency{}
+	seen := make(map[string]bool)
+	for i, lock := range c.Lock.Dependencies {
+		if lock.Repository == "" || strings.HasPrefix(lock.Repository, "file://") {
+			continue
+		}
+		var constraint string
+		if dep := lockedDependency(c.Metadata.Dependencies, i, lock); dep != nil {
+			constraint = dep.Version
+		}
+		// Aliased dependencies are locked once per alias.
+		key := lock.Name + " " + lock.Repository + " " + lock.Version + " " + constraint
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		o := &OutdatedDependency{
+			Name:         lock.Name,
+			Repository:   lock.Repository,
+			Locked:       lock.Version,
+			Constraint:   constraint,
+			Compatible:   []string{},
+			Incompatible: []string{},
+		}
+		if err := d.compareVersions(o, rf); err != nil {
+			o.Error = err.Error()
+			outdated = append(outdated, o)
+			continue
+		}
+		if len(o.Compatible) > 0 || len(o.Incompatible) > 0 {
+			outdated = append(outdated, o)
+		}
+	}
+	return outdated, nil
+}
+
+// lockedDependency returns the dependency of the chart the i-th lock entry
+// was resolved from. Lock entries are written in the order of the chart
+// dependencies, so the entry at the same index is used when it matches;
+// otherwise the first dependency with the same name and repository is.
+func lockedDependency(deps []*chart.Dependency, i int, lock *chart.Dependency) *chart.Dependency {
+	if i < len(deps) && deps[i].Name == lock.Name && deps[i].Repository == lock.Repository {
+		return deps[i]
+	}
+	for _, dep := range deps {
+		if dep.Name == lock.Name && dep.Repository == lock.Repository {
+			return dep
+		}
+	}
+	return nil
+}
+
+// compareVersions lists the versions of a dependency newer than the locked
+// one, splitting them by whether they satisfy its constraint.
+func (d *Dependency) compareVersions(o *OutdatedDependency, rf *repo.File) error {
+	locked, err := semver.NewVersion(o.Locked)
+	if err != nil {
+		return errors.Wrapf(err, "invalid locked version %s", o.Locked)
+	}
+	constraint, err := semver.NewConstraint(o.Constraint)
+	if err != nil {
+		return errors.Wrapf(err, "invalid version constraint %q", o.Constraint)
+	}
+	available, err := d.availableVersions(o.Name, o.Repository, rf)
+	if err != nil {
+		return err
+	}
+
+	var versions []*semver.Version
+	for _, a := range available {
+		v, err := semver.NewVersion(a)
+		if err != nil || !v.GreaterThan(locked) {
+			continue
+		}
+		// Pre-releases are only listed for dependencies locked to one.
+		if v.Prerelease() != "" && locked.Prerelease() == "" {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	for _, v := range versions {
+		if constraint.Check(v) {
+			o.Compatible = append(o.Compatible, v.Original())
+		} else {
+			o.Incompatible = append(o.Incompatible, v.Original())
+		}
+	}
+	return nil
+}
+
+// availableVersions lists the versions of a chart in a chart repository or an
+// OCI registry.
+func (d *Dependency) availableVersions(name, repository string, rf *repo.File) ([]string, error) {
+	if strings.HasPrefix(repository, "oci://") {
+		if d.RegistryClient == nil {
+			return nil, errors.New("no registry client configured")
+		}
+		ref, err := registry.ParseReference(strings.TrimSuffix(strings.TrimPrefix(repository, "oci://"), "/") + "/" + name)
+		if err != nil {
+			return nil, err
+		}
+		return d.RegistryClient.Tags(ref)
+	}
+
+	entry, err := repoEntry(repository, rf)
+	if err != nil {
+		return nil, err
+	}
+	index, err := repo.LoadIndexFile(filepath.Join(d.RepositoryCache, helmpath.CacheIndexFile(entry.Name)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "no cached repository for %s found. (try 'helm repo update')", entry.Name)
+	}
+	var versions []string
+	for _, cv := range index.Entries[name] {
+		versions = append(versions, cv.Version)
+	}
+	return versions, nil
+}
+
+// repoEntry returns the configured repository of a dependency, given as a URL
+// or as an alias of the repository name.
+func repoEntry(repository string, rf *repo.File) (*repo.Entry, error) {
+	for _, prefix := range []string{"@", "alias:"} {
+		if !strings.HasPrefix(repository, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(repository, prefix)
+		for _, re := range rf.Repositories {
+			if re.Name == name {
+				return re, nil
+			}
+		}
+		return nil, errors.Errorf("no repository definition for %s. Please add it via 'helm repo add'", repository)
+	}
+	for _, re := range rf.Repositories {
+		if urlutil.Equal(re.URL, strings.TrimSuffix(repository, "/")) {
+			return re, nil
+		}
+	}
+	return nil, errors.Errorf("no repository definition for %s", repository)
+}
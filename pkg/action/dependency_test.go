@@ -28,6 +28,8 @@ import (
 	"helm.sh/helm/v3/internal/test"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/repo"
 )
 
 func TestList(t *testing.T) {
@@ -159,3 +161,149 @@ func TestStatArchiveForStatus(t *testing.T) {
 	}
 	is.Equal("ok", statArchiveForStatus(where, dep))
 }
+
+func TestDependencyTree(t *testing.T) {
+	common := buildChart(withName("common"))
+	common.Metadata.Version = "0.1.3"
+	web := buildChart(withName("web"), withMetadataDependency(chart.Dependency{
+		Name: "common", Version: "~0.1.0", Repository: "https://example.com/charts",
+	}))
+	web.Metadata.Version = "1.2.0"
+	web.AddDependency(common)
+
+	c := buildChart(withName("app"),
+		withMetadataDependency(chart.Dependency{
+			Name: "web", Alias: "frontend", Version: "^1.0.0", Repository: "https://example.com/charts",
+			Condition: "frontend.enabled", Tags: []string{"front-end"},
+		}),
+		withMetadataDependency(chart.Dependency{
+			Name: "db", Version: "2.x", Repository: "https://example.com/charts",
+		}),
+		withMetadataDependency(chart.Dependency{
+			Name: "cache", Version: "1.0.0", Repository: "https://example.com/charts",
+		}))
+	c.AddDependency(web)
+	c.Lock = &chart.Lock{Dependencies: []*chart.Dependency{
+		{Name: "web", Version: "1.2.0", Repository: "https://example.com/charts"},
+		{Name: "db", Version: "2.1.0", Repository: "https://example.com/charts", Dependencies: []*chart.Dependency{
+			{Name: "disk", Version: "1.0.0", Repository: "https://example.com/charts"},
+		}},
+	}}
+
+	expect := &DependencyNode{
+		Name:    "app",
+		Version: "0.1.0",
+		Dependencies: []*DependencyNode{
+			{
+				Name: "web", Alias: "frontend", Constraint: "^1.0.0", Version: "1.2.0", Repository: "https://example.com/charts",
+				Condition: "frontend.enabled", Tags: []string{"front-end"},
+				Dependencies: []*DependencyNode{
+					{Name: "common", Constraint: "~0.1.0", Version: "0.1.3", Repository: "https://example.com/charts"},
+				},
+			},
+			{
+				Name: "db", Constraint: "2.x", Version: "2.1.0", Repository: "https://example.com/charts",
+				Dependencies: []*DependencyNode{
+					{Name: "disk", Version: "1.0.0", Repository: "https://example.com/charts"},
+				},
+			},
+			{Name: "cache", Constraint: "1.0.0", Repository: "https://example.com/charts"},
+		},
+	}
+	assert.Equal(t, expect, DependencyTree(c))
+}
+
+func TestDependencyOutdated(t *testing.T) {
+	is := assert.New(t)
+	dir, err := ioutil.TempDir("", "helmtest-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rf := repo.NewFile()
+	rf.Add(&repo.Entry{Name: "test", URL: "https://example.com/charts"})
+	if err := rf.WriteFile(filepath.Join(dir, "repositories.yaml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	index := repo.NewIndexFile()
+	for _, v := range []string{"1.0.0", "1.1.0", "1.2.0-rc.1", "2.0.0"} {
+		index.MustAdd(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "web", Version: v}, "web-"+v+".tgz", "https://example.com/charts", "")
+	}
+	index.MustAdd(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "db", Version: "2.1.0"}, "db-2.1.0.tgz", "https://example.com/charts", "")
+	for _, v := range []string{"0.1.0", "0.2.0"} {
+		index.MustAdd(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "api", Version: v}, "api-"+v+".tgz", "https://example.com/charts", "")
+		index.MustAdd(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "queue", Version: v}, "queue-"+v+".tgz", "https://example.com/charts", "")
+	}
+	if err := index.WriteFile(filepath.Join(dir, helmpath.CacheIndexFile("test")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := buildChart(withName("app"),
+		withMetadataDependency(chart.Dependency{Name: "web", Version: "^1.0.0", Repository: "https://example.com/charts/"}),
+		withMetadataDependency(chart.Dependency{Name: "web", Alias: "legacy", Version: "~1.0.0", Repository: "https://example.com/charts/"}),
+		withMetadataDependency(chart.Dependency{Name: "db", Version: "2.x", Repository: "https://example.com/charts"}),
+		withMetadataDependency(chart.Dependency{Name: "cache", Version: "1.0.0", Repository: "https://cache.example.com"}),
+		withMetadataDependency(chart.Dependency{Name: "api", Version: "~0.1.0", Repository: "@test"}),
+		withMetadataDependency(chart.Dependency{Name: "queue", Version: "~0.1.0", Repository: "alias:test"}),
+		withMetadataDependency(chart.Dependency{Name: "search", Version: "1.0.0", Repository: "@missing"}),
+		withMetadataDependency(chart.Dependency{Name: "local", Version: "0.1.0", Repository: "file://../local"}))
+	// Chart.lock is only packaged for charts with API version v2.
+	c.Metadata.APIVersion = chart.APIVersionV2
+	c.Lock = &chart.Lock{Dependencies: []*chart.Dependency{
+		{Name: "web", Version: "1.0.0", Repository: "https://example.com/charts/"},
+		{Name: "web", Version: "1.0.0", Repository: "https://example.com/charts/"},
+		{Name: "db", Version: "2.1.0", Repository: "https://example.com/charts"},
+		{Name: "cache", Version: "1.0.0", Repository: "https://cache.example.com"},
+		{Name: "api", Version: "0.1.0", Repository: "@test"},
+		{Name: "queue", Version: "0.1.0", Repository: "alias:test"},
+		{Name: "search", Version: "1.0.0", Repository: "@missing"},
+		{Name: "local", Version: "0.1.0", Repository: "file://../local"},
+	}}
+	archive, err := chartutil.Save(c, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewDependency()
+	client.RepositoryConfig = filepath.Join(dir, "repositories.yaml")
+	client.RepositoryCache = dir
+	outdated, err := client.Outdated(archive)
+	is.NoError(err)
+	is.Equal([]*OutdatedDependency{
+		{
+			Name: "web", Repository: "https://example.com/charts/", Constraint: "^1.0.0", Locked: "1.0.0",
+			Compatible: []string{"1.1.0"}, Incompatible: []string{"2.0.0"},
+		},
+		{
+			Name: "web", Repository: "https://example.com/charts/", Constraint: "~1.0.0", Locked: "1.0.0",
+			Compatible: []string{}, Incompatible: []string{"2.0.0", "1.1.0"},
+		},
+		{
+			Name: "cache", Repository: "https://cache.example.com", Constraint: "1.0.0", Locked: "1.0.0",
+			Compatible: []string{}, Incompatible: []string{},
+			Error: "no repository definition for https://cache.example.com",
+		},
+		{
+			Name: "api", Repository: "@test", Constraint: "~0.1.0", Locked: "0.1.0",
+			Compatible: []string{}, Incompatible: []string{"0.2.0"},
+		},
+		{
+			Name: "queue", Repository: "alias:test", Constraint: "~0.1.0", Locked: "0.1.0",
+			Compatible: []string{}, Incompatible: []string{"0.2.0"},
+		},
+		{
+			Name: "search", Repository: "@missing", Constraint: "1.0.0", Locked: "1.0.0",
+			Compatible: []string{}, Incompatible: []string{},
+			Error: "no repository definition for @missing. Please add it via 'helm repo add'",
+		},
+	}, outdated)
+
+	c.Lock = nil
+	archive, err = chartutil.Save(c, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Outdated(archive)
+	is.EqualError(err, "no lock file found for chart app, run 'helm dependency update' to create it")
+}
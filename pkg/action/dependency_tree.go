@@ -0,0 +1,133 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+// DependencyNode is a chart in the dependency tree of a chart.
+type DependencyNode struct {
+	Name  string `json:"name"`
+	Alias string `json:"alias,omitempty"`
+	// Constraint is the version constraint declared by the parent chart.
+	// It is empty for the root of the tree and for dependencies only known
+	// from a lock file.
+	Constraint string `json:"constraint,omitempty"`
+	// Version is the resolved version of the chart: the version of the chart
+	// in the charts directory of its parent or, if it is missing, the version
+	// locked for it. It is empty if the dependency is not resolved.
+	Version      string            `json:"version,omitempty"`
+	Repository   string            `json:"repository,omitempty"`
+	Condition    string            `json:"condition,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	Dependencies []*DependencyNode `json:"dependencies,omitempty"`
+}
+
+// Tree executes 'helm dependency tree'.
+func (d *Dependency) Tree(chartpath string) (*DependencyNode, error) {
+	c, err := loader.Load(chartpath)
+	if err != nil {
+		return nil, err
+	}
+	return DependencyTree(c), nil
+}
+
+// DependencyTree returns the dependency tree of a chart, rooted at the chart.
+//
+// The dependencies of the charts in the charts directory of their parent are
+// read from their Chart.yaml, and the dependencies of missing charts from the
+// lock file of their parent, if it records them.
+func DependencyTree(c *chart.Chart) *DependencyNode {
+	return &DependencyNode{
+		Name:         c.Name(),
+		Version:      c.Metadata.Version,
+		Dependencies: dependencyNodes(c, lockedDependencies(c, nil)),
+	}
+}
+
+// dependencyNodes returns the nodes of the dependencies of a chart, given the
+// dependencies locked for it.
+func dependencyNodes(c *chart.Chart, locked []*chart.Dependency) []*DependencyNode {
+	var nodes []*DependencyNode
+	for _, dep := range c.Metadata.Dependencies {
+		node := &DependencyNode{
+			Name:       dep.Name,
+			Alias:      dep.Alias,
+			Constraint: dep.Version,
+			Repository: dep.Repository,
+			Condition:  dep.Condition,
+			Tags:       dep.Tags,
+		}
+		lock := findDependency(locked, dep.Name)
+		switch sub := findSubchart(c, dep.Name); {
+		case sub != nil:
+			node.Version = sub.Metadata.Version
+			node.Dependencies = dependencyNodes(sub, lockedDependencies(sub, lock))
+		case lock != nil:
+			node.Version = lock.Version
+			node.Dependencies = lockedNodes(lock.Dependencies)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// lockedNodes returns the nodes of dependencies only known from a lock file.
+func lockedNodes(locked []*chart.Dependency) []*DependencyNode {
+	var nodes []*DependencyNode
+	for _, dep := range locked {
+		nodes = append(nodes, &DependencyNode{
+			Name:         dep.Name,
+			Version:      dep.Version,
+			Repository:   dep.Repository,
+			Dependencies: lockedNodes(dep.Dependencies),
+		})
+	}
+	return nodes
+}
+
+// lockedDependencies returns the dependencies locked for a chart, by its own
+// lock file or, if it has none, by the lock file of its parent.
+func lockedDependencies(c *chart.Chart, parentLock *chart.Dependency) []*chart.Dependency {
+	if c.Lock != nil {
+		return c.Lock.Dependencies
+	}
+	if parentLock != nil {
+		return parentLock.Dependencies
+	}
+	return nil
+}
+
+func findSubchart(c *chart.Chart, name string) *chart.Chart {
+	for _, sub := range c.Dependencies() {
+		if sub.Name() == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+func findDependency(deps []*chart.Dependency, name string) *chart.Dependency {
+	for _, dep := range deps {
+		if dep.Name == name {
+			return dep
+		}
+	}
+	return nil
+}
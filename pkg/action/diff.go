@@ -0,0 +1,445 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// DiffChange describes how a resource differs between two releases.
+type DiffChange string
+
+const (
+	// DiffAdded indicates a resource that is only present in the proposed release.
+	DiffAdded DiffChange = "added"
+	// DiffRemoved indicates a resource that is only present in the deployed release.
+	DiffRemoved DiffChange = "removed"
+	// DiffChanged indicates a resource that is present in both releases with different content.
+	DiffChanged DiffChange = "changed"
+)
+
+// ResourceDiff describes the difference of a single resource.
+type ResourceDiff struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Namespace  string     `json:"namespace,omitempty"`
+	Name       string     `json:"name"`
+	Change     DiffChange `json:"change"`
+	// Diff is a unified text diff of the resource.
+	Diff string `json:"diff"`
+}
+
+// ReleaseDiff describes the differences between the deployed and a proposed release.
+type ReleaseDiff struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// FromRevision is the revision compared against, or 0 if there is none.
+	FromRevision int `json:"fromRevision"`
+	ToRevision   int `json:"toRevision"`
+	// Live is true if the proposed release was compared against the live cluster objects.
+	Live      bool           `json:"live"`
+	Resources []ResourceDiff `json:"resources"`
+}
+
+// Diff is the action for comparing a proposed release against the deployed release.
+//
+// It provides the implementation of 'helm upgrade --dry-run --diff'.
+type Diff struct {
+	cfg *Configuration
+
+	// Live compares the proposed release against the objects currently in the
+	// cluster instead of the manifest stored with the deployed release.
+	Live bool
+	// Context is the number of context lines in the unified diffs.
+	Context int
+}
+
+// NewDiff creates a new Diff object with the given configuration.
+func NewDiff(cfg *Configuration) *Diff {
+	return &Diff{
+		cfg:     cfg,
+		Context: 3,
+	}
+}
+
+// Run compares the proposed release against the currently deployed revision of
+// the release with the same name.
+func (d *Diff) Run(proposed *release.Release) (*ReleaseDiff, error) {
+	if proposed == nil {
+		return nil, errMissingRelease
+	}
+
+	res := &ReleaseDiff{
+		Name:       proposed.Name,
+		Namespace:  proposed.Namespace,
+		ToRevision: proposed.Version,
+		Live:       d.Live,
+	}
+
+	current, err := d.deployedRelease(proposed.Name)
+	if err != nil {
+		return nil, err
+	}
+	var currentManifest string
+	if current != nil {
+		res.FromRevision = current.Version
+		currentManifest = current.Manifest
+	}
+
+	var from, to map[string]diffObject
+	if d.Live {
+		if from, err = d.liveObjects(currentManifest); err != nil {
+			return nil, err
+		}
+		if to, err = d.proposedObjects(proposed); err != nil {
+			return nil, err
+		}
+		// Only the fields set in the proposed objects are compared, as the
+		// live objects also have the fields defaulted by the API server.
+		for k, p := range to {
+			if l, ok := from[k]; ok {
+				l.fields = liveFields("", l.fields, p.fields).(map[string]interface{})
+				from[k] = l
+			}
+		}
+		for _, objs := range []map[string]diffObject{from, to} {
+			for k, obj := range objs {
+				if err := obj.render(); err != nil {
+					return nil, err
+				}
+				objs[k] = obj
+			}
+		}
+	} else {
+		if from, err = manifestObjects(currentManifest); err != nil {
+			return nil, errors.Wrap(err, "unable to parse deployed release manifest")
+		}
+		if to, err = manifestObjects(proposed.Manifest); err != nil {
+			return nil, errors.Wrap(err, "unable to parse proposed release manifest")
+		}
+	}
+
+	fromName, toName := "deployed", "proposed"
+	if d.Live {
+		fromName = "live"
+	}
+	res.Resources, err = diffObjects(from, to, fromName, toName, d.Context)
+	return res, err
+}
+
+// deployedRelease returns the release the proposed release is compared against.
+func (d *Diff) deployedRelease(name string) (*release.Release, error) {
+	rel, err := d.cfg.Releases.Deployed(name)
+	if err == nil {
+		return rel, nil
+	}
+	if !errors.Is(err, driver.ErrNoDeployedReleases) {
+		return nil, err
+	}
+	rel, err = d.cfg.Releases.Last(name)
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, nil
+	}
+	return rel, err
+}
+
+// liveObjects fetches the cluster objects of the given manifest.
+func (d *Diff) liveObjects(manifest string) (map[string]diffObject, error) {
+	objs := map[string]diffObject{}
+	if manifest == "" {
+		return objs, nil
+	}
+	resources, err := d.cfg.KubeClient.Build(bytes.NewBufferString(manifest), false)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to build kubernetes objects from deployed release manifest")
+	}
+	for _, info := range resources {
+		if err := info.Get(); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "unable to get %s %q", info.Mapping.GroupVersionKind.Kind, info.Name)
+		}
+		obj, err := newDiffObject(info.Object, info.Namespace, info.Name)
+		if err != nil {
+			return nil, err
+		}
+		objs[obj.key()] = obj
+	}
+	return objs, nil
+}
+
+// proposedObjects builds the objects of the proposed release as they would be
+// sent to the cluster.
+func (d *Diff) proposedObjects(proposed *release.Release) (map[string]diffObject, error) {
+	objs := map[string]diffObject{}
+	resources, err := d.cfg.KubeClient.Build(bytes.NewBufferString(proposed.Manifest), false)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to build kubernetes objects from proposed release manifest")
+	}
+	if err := resources.Visit(setMetadataVisitor(proposed.Name, proposed.Namespace, true)); err != nil {
+		return nil, err
+	}
+	for _, info := range resources {
+		obj, err := newDiffObject(info.Object, info.Namespace, info.Name)
+		if err != nil {
+			return nil, err
+		}
+		normalizeSecretData(obj.fields)
+		objs[obj.key()] = obj
+	}
+	return objs, nil
+}
+
+// liveFields returns the fields of a live object that are set in the proposed
+// object. Values equal to the proposed ones but written differently, such as
+// resource quantities, are replaced by the proposed values.
+func liveFields(path string, live, proposed interface{}) interface{} {
+	switch p := proposed.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return live
+		}
+		fields := make(map[string]interface{}, len(p))
+		for k, v := range p {
+			if lv, ok := l[k]; ok {
+				fields[k] = liveFields(fieldPath(path, k), lv, v)
+			}
+		}
+		return fields
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(p) {
+			return live
+		}
+		items := make([]interface{}, len(p))
+		for i := range p {
+			items[i] = liveFields(fmt.Sprintf("%s[%d]", path, i), l[i], p[i])
+		}
+		return items
+	default:
+		if equalDriftValues(path, p, live) {
+			return p
+		}
+		return live
+	}
+}
+
+// diffObject is a single resource prepared for comparison.
+type diffObject struct {
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+	content    string
+	// fields are the fields of a cluster object, rendered as content by
+	// render.
+	fields map[string]interface{}
+}
+
+// key identifies the resource regardless of the version of its API, so that
+// a resource moved to another version of its API is compared with itself.
+func (o *diffObject) key() string {
+	group := ""
+	if gv, err := schema.ParseGroupVersion(o.apiVersion); err == nil {
+		group = gv.Group
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", group, o.kind, o.namespace, o.name)
+}
+
+// render renders the fields of a cluster object as YAML.
+func (o *diffObject) render() error {
+	b, err := yaml.Marshal(o.fields)
+	if err != nil {
+		return err
+	}
+	o.content = string(b)
+	return nil
+}
+
+// diffHead is the part of a manifest needed to identify a resource.
+type diffHead struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// manifestObjects splits a release manifest into its resources.
+func manifestObjects(manifest string) (map[string]diffObject, error) {
+	objs := map[string]diffObject{}
+	for _, content := range releaseutil.SplitManifests(manifest) {
+		var head diffHead
+		if err := yaml.Unmarshal([]byte(content), &head); err != nil {
+			return nil, err
+		}
+		if head.Kind == "" {
+			// Empty documents, e.g. templates that only render comments.
+			continue
+		}
+		obj := diffObject{
+			apiVersion: head.APIVersion,
+			kind:       head.Kind,
+			namespace:  head.Metadata.Namespace,
+			name:       head.Metadata.Name,
+			content:    content + "\n",
+		}
+		objs[obj.key()] = obj
+	}
+	return objs, nil
+}
+
+// newDiffObject prepares a cluster object for comparison, ignoring the fields
+// populated by the API server.
+func newDiffObject(obj runtime.Object, namespace, name string) (diffObject, error) {
+	fields, err := toUnstructuredContent(obj)
+	if err != nil {
+		return diffObject{}, err
+	}
+	stripServerPopulatedFields(fields)
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return diffObject{
+		apiVersion: gvk.GroupVersion().String(),
+		kind:       gvk.Kind,
+		namespace:  namespace,
+		name:       name,
+		fields:     fields,
+	}, nil
+}
+
+// toUnstructuredContent returns a copy of the object as a map.
+func toUnstructuredContent(obj runtime.Object) (map[string]interface{}, error) {
+	if u, ok := obj.(runtime.Unstructured); ok {
+		return runtime.DeepCopyJSON(u.UnstructuredContent()), nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
+
+// serverPopulatedMetadata lists the metadata fields managed by the API server.
+var serverPopulatedMetadata = []string{
+	"creationTimestamp",
+	"deletionGracePeriodSeconds",
+	"deletionTimestamp",
+	"generation",
+	"managedFields",
+	"resourceVersion",
+	"selfLink",
+	"uid",
+}
+
+// serverPopulatedAnnotations lists the annotations commonly set by controllers
+// and clients rather than by the chart.
+var serverPopulatedAnnotations = []string{
+	"deployment.kubernetes.io/revision",
+	"kubectl.kubernetes.io/last-applied-configuration",
+}
+
+// stripServerPopulatedFields removes the status and the metadata fields
+// populated by the API server from an unstructured object.
+func stripServerPopulatedFields(obj map[string]interface{}) {
+	delete(obj, "status")
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, field := range serverPopulatedMetadata {
+		delete(metadata, field)
+	}
+	if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+		for _, anno := range serverPopulatedAnnotations {
+			delete(annotations, anno)
+		}
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+}
+
+// diffObjects compares two sets of resources, returning the differences sorted
+// by kind, namespace and name.
+func diffObjects(from, to map[string]diffObject, fromName, toName string, context int) ([]ResourceDiff, error) {
+	keys := map[string]bool{}
+	for k := range from {
+		keys[k] = true
+	}
+	for k := range to {
+		keys[k] = true
+	}
+
+	diffs := []ResourceDiff{}
+	for k := range keys {
+		a, inFrom := from[k]
+		b, inTo := to[k]
+		if inFrom && inTo && a.content == b.content {
+			continue
+		}
+
+		ref, change := b, DiffChanged
+		switch {
+		case !inFrom:
+			change = DiffAdded
+		case !inTo:
+			ref, change = a, DiffRemoved
+		}
+
+		text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(a.content),
+			B:        difflib.SplitLines(b.content),
+			FromFile: fromName,
+			ToFile:   toName,
+			Context:  context,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to diff %s %q", ref.kind, ref.name)
+		}
+		diffs = append(diffs, ResourceDiff{
+			APIVersion: ref.apiVersion,
+			Kind:       ref.kind,
+			Namespace:  ref.namespace,
+			Name:       ref.name,
+			Change:     change,
+			Diff:       text,
+		})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Kind != diffs[j].Kind {
+			return diffs[i].Kind < diffs[j].Kind
+		}
+		if diffs[i].Namespace != diffs[j].Namespace {
+			return diffs[i].Namespace < diffs[j].Namespace
+		}
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs, nil
+}
@@ -0,0 +1,209 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+const diffDeployedManifest = `---
+# Source: chart/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  mode: slow
+---
+# Source: chart/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: removed
+---
+# Source: chart/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: unchanged
+`
+
+const diffProposedManifest = `---
+# Source: chart/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  mode: fast
+---
+# Source: chart/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: unchanged
+---
+# Source: chart/templates/serviceaccount.yaml
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: added
+`
+
+func TestDiff(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	config := actionConfigFixture(t)
+	deployed := namedReleaseStub("differ", release.StatusDeployed)
+	deployed.Manifest = diffDeployedManifest
+	req.NoError(config.Releases.Create(deployed))
+
+	proposed := namedReleaseStub("differ", release.StatusPendingUpgrade)
+	proposed.Version = 2
+	proposed.Manifest = diffProposedManifest
+
+	diff, err := NewDiff(config).Run(proposed)
+	req.NoError(err)
+	is.Equal(1, diff.FromRevision)
+	is.Equal(2, diff.ToRevision)
+	req.Len(diff.Resources, 3)
+
+	changes := map[string]DiffChange{}
+	for _, r := range diff.Resources {
+		changes[r.Kind+"/"+r.Name] = r.Change
+	}
+	is.Equal(map[string]DiffChange{
+		"ConfigMap/settings":   DiffChanged,
+		"Secret/removed":       DiffRemoved,
+		"ServiceAccount/added": DiffAdded,
+	}, changes)
+
+	is.Equal("ConfigMap", diff.Resources[0].Kind)
+	is.True(strings.Contains(diff.Resources[0].Diff, "-  mode: slow\n+  mode: fast\n"), diff.Resources[0].Diff)
+}
+
+func TestDiffWithoutDeployedRelease(t *testing.T) {
+	config := actionConfigFixture(t)
+	proposed := namedReleaseStub("fresh", release.StatusPendingInstall)
+	proposed.Manifest = diffProposedManifest
+
+	diff, err := NewDiff(config).Run(proposed)
+	require.NoError(t, err)
+	assert.Equal(t, 0, diff.FromRevision)
+	require.Len(t, diff.Resources, 3)
+	for _, r := range diff.Resources {
+		assert.Equal(t, DiffAdded, r.Change)
+	}
+}
+
+func TestDiffAPIVersionChange(t *testing.T) {
+	config := actionConfigFixture(t)
+	deployed := namedReleaseStub("differ", release.StatusDeployed)
+	deployed.Manifest = "---\napiVersion: autoscaling/v1\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: web\nspec:\n  maxReplicas: 5\n"
+	require.NoError(t, config.Releases.Create(deployed))
+
+	proposed := namedReleaseStub("differ", release.StatusPendingUpgrade)
+	proposed.Version = 2
+	proposed.Manifest = "---\napiVersion: autoscaling/v2beta2\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: web\nspec:\n  maxReplicas: 5\n"
+
+	diff, err := NewDiff(config).Run(proposed)
+	require.NoError(t, err)
+	require.Len(t, diff.Resources, 1)
+	assert.Equal(t, DiffChanged, diff.Resources[0].Change)
+	assert.Equal(t, "autoscaling/v2beta2", diff.Resources[0].APIVersion)
+	assert.Contains(t, diff.Resources[0].Diff, "-apiVersion: autoscaling/v1\n+apiVersion: autoscaling/v2beta2\n")
+}
+
+func TestDiffLive(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	const metadata = `"labels":{"app.kubernetes.io/managed-by":"Helm"},"annotations":{"meta.helm.sh/release-name":"differ","meta.helm.sh/release-namespace":""}`
+	config := actionConfigFixture(t)
+	config.KubeClient = &driftKubeClient{
+		live: map[string]string{
+			// Fields defaulted and populated by the API server only
+			"settings": `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"settings","namespace":"default","uid":"1234",` + metadata + `},
+				"data":{"mode":"fast"}}`,
+			"web": `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"default","generation":3,` + metadata + `},
+				"spec":{"replicas":2,"strategy":{"type":"RollingUpdate"},"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.20","imagePullPolicy":"IfNotPresent",
+				"resources":{"limits":{"memory":"1073741824"}}}]}}},"status":{"replicas":2}}`,
+		},
+	}
+	deployed := namedReleaseStub("differ", release.StatusDeployed)
+	deployed.Manifest = `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  mode: fast
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`
+	req.NoError(config.Releases.Create(deployed))
+
+	proposed := namedReleaseStub("differ", release.StatusPendingUpgrade)
+	proposed.Version = 2
+	proposed.Manifest = `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  mode: fast
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.21
+        resources:
+          limits:
+            memory: 1Gi
+`
+
+	d := NewDiff(config)
+	d.Live = true
+	diff, err := d.Run(proposed)
+	req.NoError(err)
+	req.Len(diff.Resources, 1, "expected only the Deployment to differ: %v", diff.Resources)
+
+	r := diff.Resources[0]
+	is.Equal("Deployment", r.Kind)
+	is.Equal(DiffChanged, r.Change)
+	is.Contains(r.Diff, "-      - image: nginx:1.20\n+      - image: nginx:1.21\n")
+	for _, field := range []string{"strategy", "imagePullPolicy", "status", "-            memory"} {
+		is.NotContains(r.Diff, field)
+	}
+}
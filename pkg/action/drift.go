@@ -0,0 +1,372 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+// DriftChange describes how a live resource differs from the release manifest.
+type DriftChange string
+
+const (
+	// DriftMissing indicates a resource of the manifest that does not exist in the cluster.
+	DriftMissing DriftChange = "missing"
+	// DriftModified indicates a resource whose live fields differ from the manifest.
+	DriftModified DriftChange = "modified"
+)
+
+// FieldDrift describes a field of a live resource that differs from the manifest.
+type FieldDrift struct {
+	// Path is the path of the field, such as spec.template.spec.containers[0].image.
+	Path string `json:"path"`
+	// Expected is the value of the field in the manifest.
+	Expected interface{} `json:"expected"`
+	// Actual is the live value of the field, or nil if the field is not set.
+	Actual interface{} `json:"actual"`
+	// Redacted indicates a field of a Secret whose values are not reported.
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// ResourceDrift describes the drift of a single resource.
+type ResourceDrift struct {
+	APIVersion string       `json:"apiVersion"`
+	Kind       string       `json:"kind"`
+	Namespace  string       `json:"namespace,omitempty"`
+	Name       string       `json:"name"`
+	Change     DriftChange  `json:"change"`
+	Fields     []FieldDrift `json:"fields,omitempty"`
+}
+
+// ReleaseDrift describes the drift of the live resources of a release from its manifest.
+type ReleaseDrift struct {
+	Name      string          `json:"name"`
+	Namespace string          `json:"namespace"`
+	Revision  int             `json:"revision"`
+	Resources []ResourceDrift `json:"resources"`
+}
+
+// Drifted reports whether any resource drifted.
+func (d *ReleaseDrift) Drifted() bool {
+	return len(d.Resources) > 0
+}
+
+// Drift is the action for detecting live resources that no longer match the
+// manifest of a release.
+//
+// It provides the implementation of 'helm status --drift'.
+type Drift struct {
+	cfg *Configuration
+
+	// Version is the revision to compare against. The deployed revision is
+	// used if it is 0.
+	Version int
+}
+
+// NewDrift creates a new Drift object with the given configuration.
+func NewDrift(cfg *Configuration) *Drift {
+	return &Drift{
+		cfg: cfg,
+	}
+}
+
+// Run fetches every resource of the manifest of the given release and
+// compares it with the manifest.
+//
+// Only the fields set in the manifest are compared, so fields defaulted by the
+// API server or set by controllers are not reported, and the status and the
+// metadata populated by the API server are ignored.
+func (d *Drift) Run(name string) (*ReleaseDrift, error) {
+	if err := d.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, errors.Errorf("release name is invalid: %s", name)
+	}
+
+	var rel *release.Release
+	var err error
+	if d.Version > 0 {
+		rel, err = d.cfg.Releases.Get(name, d.Version)
+	} else {
+		rel, err = d.cfg.Releases.Deployed(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ReleaseDrift{
+		Name:      rel.Name,
+		Namespace: rel.Namespace,
+		Revision:  rel.Version,
+		Resources: []ResourceDrift{},
+	}
+	if len(releaseutil.SplitManifests(rel.Manifest)) == 0 {
+		return res, nil
+	}
+	resources, err := d.cfg.KubeClient.Build(bytes.NewBufferString(rel.Manifest), false)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to build kubernetes objects from release manifest")
+	}
+
+	for _, info := range resources {
+		desired, err := toUnstructuredContent(info.Object)
+		if err != nil {
+			return nil, err
+		}
+		stripServerPopulatedFields(desired)
+		normalizeSecretData(desired)
+
+		gvk := info.Object.GetObjectKind().GroupVersionKind()
+		drift := ResourceDrift{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Namespace:  info.Namespace,
+			Name:       info.Name,
+		}
+		if err := info.Get(); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "unable to get %s %q", gvk.Kind, info.Name)
+			}
+			drift.Change = DriftMissing
+			res.Resources = append(res.Resources, drift)
+			continue
+		}
+		live, err := toUnstructuredContent(info.Object)
+		if err != nil {
+			return nil, err
+		}
+		if drift.Fields = driftedFields("", desired, live); len(drift.Fields) > 0 {
+			if isSecret(desired) {
+				redactSecretFields(drift.Fields)
+			}
+			drift.Change = DriftModified
+			res.Resources = append(res.Resources, drift)
+		}
+	}
+
+	sort.Slice(res.Resources, func(i, j int) bool {
+		a, b := res.Resources[i], res.Resources[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return res, nil
+}
+
+func isSecret(obj map[string]interface{}) bool {
+	return obj["apiVersion"] == "v1" && obj["kind"] == "Secret"
+}
+
+// normalizeSecretData merges the stringData of a Secret into its data the way
+// the API server does, since stringData is write-only and never returned.
+func normalizeSecretData(obj map[string]interface{}) {
+	if !isSecret(obj) {
+		return
+	}
+	stringData, ok := obj["stringData"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	delete(obj, "stringData")
+	data, ok := obj["data"].(map[string]interface{})
+	if !ok {
+		data = map[string]interface{}{}
+		obj["data"] = data
+	}
+	// stringData takes precedence over data for the same key
+	for k, v := range stringData {
+		data[k] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(v)))
+	}
+}
+
+// redactSecretFields removes the values of the drifted data fields of a Secret,
+// so that only the drift of the field is reported.
+func redactSecretFields(fields []FieldDrift) {
+	for i, f := range fields {
+		if f.Path == "data" || strings.HasPrefix(f.Path, "data.") || strings.HasPrefix(f.Path, "data[") {
+			fields[i] = FieldDrift{Path: f.Path, Redacted: true}
+		}
+	}
+}
+
+// driftedFields returns the fields of desired whose live value differs. Lists
+// are compared element by element if their lengths match, and reported as a
+// whole otherwise.
+func driftedFields(path string, desired, live interface{}) []FieldDrift {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			if len(d) == 0 && live == nil {
+				return nil
+			}
+			return []FieldDrift{{Path: path, Expected: desired, Actual: live}}
+		}
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var fields []FieldDrift
+		for _, k := range keys {
+			fields = append(fields, driftedFields(fieldPath(path, k), d[k], l[k])...)
+		}
+		return fields
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(d) {
+			if len(d) == 0 && live == nil {
+				return nil
+			}
+			return []FieldDrift{{Path: path, Expected: desired, Actual: live}}
+		}
+		var fields []FieldDrift
+		for i := range d {
+			fields = append(fields, driftedFields(fmt.Sprintf("%s[%d]", path, i), d[i], l[i])...)
+		}
+		return fields
+	default:
+		if !equalDriftValues(path, desired, live) {
+			return []FieldDrift{{Path: path, Expected: desired, Actual: live}}
+		}
+		return nil
+	}
+}
+
+// fieldPath appends a key to a field path, quoting keys containing dots or
+// slashes such as annotation names.
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// quantityFields are the fields holding resource quantities, or maps of them.
+var quantityFields = map[string]bool{
+	"allocatable":          true,
+	"capacity":             true,
+	"default":              true,
+	"defaultRequest":       true,
+	"hard":                 true,
+	"limits":               true,
+	"max":                  true,
+	"maxLimitRequestRatio": true,
+	"min":                  true,
+	"overhead":             true,
+	"requests":             true,
+	"sizeLimit":            true,
+	"storage":              true,
+}
+
+// equalDriftValues compares scalar values, treating numbers of different
+// types as equal if their values are. Integers and the strings they are
+// written as are equal, as int-or-string fields may hold either. Resource
+// quantities are equal if their amounts are, such as 0.5 and 500m.
+func equalDriftValues(path string, a, b interface{}) bool {
+	if fa, ok := driftNumber(a); ok {
+		if fb, ok := driftNumber(b); ok {
+			return fa == fb
+		}
+	}
+	if isQuantityPath(path) {
+		if qa, ok := driftQuantity(a); ok {
+			qb, ok := driftQuantity(b)
+			return ok && qa.Cmp(qb) == 0
+		}
+	}
+	if s, ok := a.(string); ok {
+		if n, ok := driftNumber(b); ok {
+			return driftIntString(s, n)
+		}
+	}
+	if s, ok := b.(string); ok {
+		if n, ok := driftNumber(a); ok {
+			return driftIntString(s, n)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// isQuantityPath reports whether a field path leads to a resource quantity,
+// which is the case if one of its keys is a quantity field.
+func isQuantityPath(path string) bool {
+	if path == "data" || strings.HasPrefix(path, "data.") || strings.HasPrefix(path, "data[") {
+		return false
+	}
+	for _, key := range strings.Split(path, ".") {
+		if i := strings.Index(key, "["); i >= 0 {
+			key = key[:i]
+		}
+		if quantityFields[key] {
+			return true
+		}
+	}
+	return false
+}
+
+func driftQuantity(v interface{}) (resource.Quantity, bool) {
+	s, ok := v.(string)
+	if !ok {
+		n, ok := driftNumber(v)
+		if !ok {
+			return resource.Quantity{}, false
+		}
+		s = strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	q, err := resource.ParseQuantity(s)
+	return q, err == nil
+}
+
+func driftIntString(s string, n float64) bool {
+	i, err := strconv.ParseInt(s, 10, 64)
+	return err == nil && float64(i) == n
+}
+
+func driftNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
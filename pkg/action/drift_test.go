@@ -0,0 +1,270 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/kube"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+)
+
+// driftKubeClient builds the resources of a manifest as unstructured objects
+// fetched from the given live objects.
+type driftKubeClient struct {
+	kubefake.PrintingKubeClient
+	live map[string]string
+}
+
+func (c *driftKubeClient) Build(reader io.Reader, _ bool) (kube.ResourceList, error) {
+	b, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	client := &fake.RESTClient{
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			header := http.Header{"Content-Type": []string{"application/json"}}
+			body, ok := c.live[path.Base(req.URL.Path)]
+			if !ok {
+				body = `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`
+				return &http.Response{StatusCode: http.StatusNotFound, Header: header, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+		}),
+	}
+
+	var resources kube.ResourceList
+	for _, doc := range strings.Split(string(b), "---") {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		js, err := yaml.YAMLToJSON([]byte(doc))
+		if err != nil {
+			return nil, err
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(js); err != nil {
+			return nil, err
+		}
+		gvk := obj.GroupVersionKind()
+		resources.Append(&resource.Info{
+			Client:    client,
+			Name:      obj.GetName(),
+			Namespace: "default",
+			Object:    obj,
+			Mapping: &meta.RESTMapping{
+				Resource:         schema.GroupVersionResource{Group: gvk.Group, Version: gvk.Version, Resource: strings.ToLower(gvk.Kind) + "s"},
+				GroupVersionKind: gvk,
+				Scope:            meta.RESTScopeNamespace,
+			},
+		})
+	}
+	return resources, nil
+}
+
+const driftManifest = `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    example.com/owner: team-a
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.19
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  mode: production
+`
+
+func TestDrift(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	config.KubeClient = &driftKubeClient{
+		live: map[string]string{
+			// Modified fields, with defaulted and server-populated fields
+			"web": `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"default","uid":"1234","resourceVersion":"42",
+				"annotations":{"example.com/owner":"team-b","deployment.kubernetes.io/revision":"3"}},
+				"spec":{"replicas":5,"strategy":{"type":"RollingUpdate"},"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.21","imagePullPolicy":"IfNotPresent"}]}}},
+				"status":{"replicas":5}}`,
+		},
+	}
+	rel := releaseStub()
+	rel.Manifest = driftManifest
+	require.NoError(t, config.Releases.Create(rel))
+
+	res, err := NewDrift(config).Run(rel.Name)
+	is.NoError(err)
+	is.True(res.Drifted())
+	is.Equal(rel.Version, res.Revision)
+	is.Equal([]ResourceDrift{{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Namespace:  "default",
+		Name:       "settings",
+		Change:     DriftMissing,
+	}, {
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Namespace:  "default",
+		Name:       "web",
+		Change:     DriftModified,
+		Fields: []FieldDrift{
+			{Path: `metadata.annotations["example.com/owner"]`, Expected: "team-a", Actual: "team-b"},
+			{Path: "spec.replicas", Expected: int64(3), Actual: int64(5)},
+			{Path: "spec.template.spec.containers[0].image", Expected: "nginx:1.19", Actual: "nginx:1.21"},
+		},
+	}}, res.Resources)
+}
+
+func TestDriftNone(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	config.KubeClient = &driftKubeClient{
+		live: map[string]string{
+			"settings": `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"settings","namespace":"default","labels":{"app.kubernetes.io/managed-by":"Helm"}},"data":{"mode":"production"}}`,
+		},
+	}
+	rel := releaseStub()
+	rel.Manifest = "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\ndata:\n  mode: production\n"
+	require.NoError(t, config.Releases.Create(rel))
+
+	res, err := NewDrift(config).Run(rel.Name)
+	is.NoError(err)
+	is.False(res.Drifted())
+}
+
+func TestDriftSecretStringData(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	config.KubeClient = &driftKubeClient{
+		live: map[string]string{
+			// "secret" and "admin" base64 encoded
+			"credentials": `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"credentials","namespace":"default"},"type":"Opaque",
+				"data":{"password":"c2VjcmV0","username":"YWRtaW4="}}`,
+		},
+	}
+	rel := releaseStub()
+	rel.Manifest = `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: credentials
+type: Opaque
+data:
+  username: cm9vdA==
+stringData:
+  username: admin
+  password: secret
+`
+	require.NoError(t, config.Releases.Create(rel))
+
+	res, err := NewDrift(config).Run(rel.Name)
+	is.NoError(err)
+	is.False(res.Drifted(), "expected stringData to be compared with the encoded data: %v", res.Resources)
+
+	config.KubeClient.(*driftKubeClient).live["credentials"] = `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"credentials","namespace":"default"},"type":"Opaque",
+		"data":{"password":"Y2hhbmdlZA==","username":"YWRtaW4="}}`
+	res, err = NewDrift(config).Run(rel.Name)
+	is.NoError(err)
+	is.Equal([]ResourceDrift{{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Namespace:  "default",
+		Name:       "credentials",
+		Change:     DriftModified,
+		Fields:     []FieldDrift{{Path: "data.password", Redacted: true}},
+	}}, res.Resources)
+}
+
+func TestDriftedFields(t *testing.T) {
+	desired := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports":     []interface{}{map[string]interface{}{"port": int64(80)}},
+			"selector":  map[string]interface{}{},
+			"weight":    float64(1),
+			"protocols": []interface{}{"TCP", "UDP"},
+		},
+	}
+	live := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports":     []interface{}{map[string]interface{}{"port": float64(80), "protocol": "TCP"}},
+			"weight":    int64(1),
+			"protocols": []interface{}{"TCP"},
+		},
+	}
+	assert.Equal(t, []FieldDrift{
+		{Path: "spec.protocols", Expected: []interface{}{"TCP", "UDP"}, Actual: []interface{}{"TCP"}},
+	}, driftedFields("", desired, live))
+	assert.Empty(t, driftedFields("", desired, desired))
+	assert.Equal(t, []FieldDrift{{Path: "spec.type", Expected: "ClusterIP", Actual: nil}},
+		driftedFields("spec.type", "ClusterIP", nil))
+}
+
+func TestDriftedFieldsNormalized(t *testing.T) {
+	desired := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{
+				"resources": map[string]interface{}{
+					"limits":   map[string]interface{}{"cpu": float64(0.5), "memory": "1024Mi"},
+					"requests": map[string]interface{}{"cpu": "100m", "memory": "64Mi"},
+				},
+			}},
+			"ports": []interface{}{map[string]interface{}{"targetPort": int64(80)}},
+		},
+		"data": map[string]interface{}{"ratio": "0.5"},
+	}
+	live := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{
+				"resources": map[string]interface{}{
+					"limits":   map[string]interface{}{"cpu": "500m", "memory": "1Gi"},
+					"requests": map[string]interface{}{"cpu": "0.2", "memory": "64Mi"},
+				},
+			}},
+			"ports": []interface{}{map[string]interface{}{"targetPort": "80"}},
+		},
+		"data": map[string]interface{}{"ratio": "500m"},
+	}
+	assert.Equal(t, []FieldDrift{
+		{Path: "data.ratio", Expected: "0.5", Actual: "500m"},
+		{Path: "spec.containers[0].resources.requests.cpu", Expected: "100m", Actual: "0.2"},
+	}, driftedFields("", desired, live))
+}
@@ -17,17 +17,27 @@ package action
 
 import (
 	"bytes"
+	"context"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/release"
 	helmtime "helm.sh/helm/v3/pkg/time"
 )
 
 // execHook executes all of the hooks for the given hook event.
-func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent, timeout time.Duration) error {
+//
+// Hooks are executed in groups of equal weight, in ascending weight order. The
+// hooks of a group are watched concurrently as soon as they are created; the
+// next group is only started once every hook of the current group has
+// completed.
+//
+// If the context is cancelled, the running hooks are marked as failed.
+func (cfg *Configuration) execHook(ctx context.Context, rl *release.Release, hook release.HookEvent, timeout time.Duration) error {
 	executingHooks := []*release.Hook{}
 
 	for _, h := range rl.Hooks {
@@ -41,7 +51,57 @@ func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent,
 	// hooke are pre-ordered by kind, so keep order stable
 	sort.Stable(hookByWeight(executingHooks))
 
+	for _, group := range hookWeightGroups(executingHooks) {
+		if err := cfg.execHookGroup(ctx, rl, hook, group, timeout, 0, true); err != nil {
+			return err
+		}
+	}
+
+	// If all hooks are successful, check the annotation of each hook to determine whether the hook should be deleted
+	// under succeeded condition. If so, then clear the corresponding resource object in each hook
 	for _, h := range executingHooks {
+		if err := cfg.deleteHookByPolicy(h, release.HookSucceeded); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// execHookGroup creates the hooks of a group of equal weight in order and
+// waits for them to complete concurrently. If parallelism is positive, at most
+// that many hooks run at once, and the next hook is created as soon as a
+// running one completes. If failFast is set and any hook of the group fails,
+// the remaining hooks of the group are cancelled and marked as such. If a hook
+// cannot be created, the hooks after it are not created and marked as
+// cancelled. The failures of all hooks are reported.
+func (cfg *Configuration) execHookGroup(ctx context.Context, rl *release.Release, hook release.HookEvent, group []*release.Hook, timeout time.Duration, parallelism int, failFast bool) error {
+	groupCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(group))
+
+	// mu guards the hooks being watched while the release is recorded.
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var slots chan struct{}
+	if parallelism > 0 {
+		slots = make(chan struct{}, parallelism)
+	}
+
+	for i, h := range group {
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			case <-groupCtx.Done():
+			}
+			if groupCtx.Err() != nil {
+				// the group was cancelled while waiting for a hook to complete
+				stopHookGroup(group[i:], false, cancel)
+				break
+			}
+		}
+
 		// Set default delete policy to before-hook-creation
 		if h.DeletePolicies == nil || len(h.DeletePolicies) == 0 {
 			// TODO(jlegrone): Only apply before-hook-creation delete policy to run to completion
@@ -52,20 +112,24 @@ func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent,
 		}
 
 		if err := cfg.deleteHookByPolicy(h, release.HookBeforeHookCreation); err != nil {
-			return err
+			errs[i] = err
+			stopHookGroup(group[i+1:], failFast, cancel)
+			break
 		}
 
 		resources, err := cfg.KubeClient.Build(bytes.NewBufferString(h.Manifest), true)
 		if err != nil {
-			return errors.Wrapf(err, "unable to build kubernetes object for %s hook %s", hook, h.Path)
+			errs[i] = errors.Wrapf(err, "unable to build kubernetes object for %s hook %s", hook, h.Path)
+			stopHookGroup(group[i+1:], failFast, cancel)
+			break
 		}
 
 		// Record the time at which the hook was applied to the cluster
-		h.LastRun = release.HookExecution{
-			StartedAt: helmtime.Now(),
-			Phase:     release.HookPhaseRunning,
-		}
+		h.History = nil
+		startHookAttempt(h)
+		mu.Lock()
 		cfg.recordRelease(rl)
+		mu.Unlock()
 
 		// As long as the implementation of WatchUntilReady does not panic, HookPhaseFailed or HookPhaseSucceeded
 		// should always be set by this function. If we fail to do that for any reason, then HookPhaseUnknown is
@@ -74,37 +138,170 @@ func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent,
 
 		// Create hook resources
 		if _, err := cfg.KubeClient.Create(resources); err != nil {
-			h.LastRun.CompletedAt = helmtime.Now()
-			h.LastRun.Phase = release.HookPhaseFailed
-			return errors.Wrapf(err, "warning: Hook %s %s failed", hook, h.Path)
+			errs[i] = errors.Wrapf(err, "warning: Hook %s %s failed", hook, h.Path)
+			completeHookAttempt(h, errs[i])
+			stopHookGroup(group[i+1:], failFast, cancel)
+			break
 		}
 
-		// Watch hook resources until they have completed
-		err = cfg.KubeClient.WatchUntilReady(resources, timeout)
-		// Note the time of success/failure
-		h.LastRun.CompletedAt = helmtime.Now()
-		// Mark hook as succeeded or failed
-		if err != nil {
-			h.LastRun.Phase = release.HookPhaseFailed
-			// If a hook is failed, check the annotation of the hook to determine whether the hook should be deleted
-			// under failed condition. If so, then clear the corresponding resource object in the hook
-			if err := cfg.deleteHookByPolicy(h, release.HookFailed); err != nil {
-				return err
+		// Watch the created hook resources until they have completed
+		wg.Add(1)
+		go func(h *release.Hook, resources kube.ResourceList, err *error) {
+			defer wg.Done()
+			if *err = cfg.watchHook(groupCtx, hook, h, resources, timeout, &mu); *err != nil && failFast {
+				cancel()
 			}
-			return err
+			if slots != nil {
+				<-slots
+			}
+		}(h, resources, &errs[i])
+	}
+	wg.Wait()
+
+	// The hooks cancelled because of the failure of another hook did not fail
+	// themselves, and are only reported if the caller cancelled the context.
+	var failed, cancelled []error
+	for i, h := range group {
+		if errs[i] == nil {
+			continue
+		}
+		if errors.Is(errs[i], context.Canceled) {
+			if ctx.Err() == nil {
+				cancelHookAttempt(h)
+			} else {
+				cancelled = append(cancelled, errs[i])
+			}
+		} else {
+			failed = append(failed, errs[i])
+		}
+		if h.LastRun.Phase != release.HookPhaseFailed && h.LastRun.Phase != release.HookPhaseCancelled {
+			continue
+		}
+		// If a hook is failed or cancelled, check the annotation of the hook to determine whether the hook should
+		// be deleted under failed condition. If so, then clear the corresponding resource object in the hook
+		if err := cfg.deleteHookByPolicy(h, release.HookFailed); err != nil {
+			failed = append(failed, err)
 		}
-		h.LastRun.Phase = release.HookPhaseSucceeded
 	}
+	failed = append(failed, cancelled...)
 
-	// If all hooks are successful, check the annotation of each hook to determine whether the hook should be deleted
-	// under succeeded condition. If so, then clear the corresponding resource object in each hook
-	for _, h := range executingHooks {
-		if err := cfg.deleteHookByPolicy(h, release.HookSucceeded); err != nil {
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return failed[0]
+	default:
+		return errors.New(joinErrors(failed))
+	}
+}
+
+// stopHookGroup stops a group of hooks after one of them could not be created:
+// the hooks not created yet are marked as cancelled, and if failFast is set,
+// the hooks already created are cancelled as well.
+func stopHookGroup(skipped []*release.Hook, failFast bool, cancel context.CancelFunc) {
+	if failFast {
+		cancel()
+	}
+	for _, h := range skipped {
+		skipHook(h)
+	}
+}
+
+// watchHook waits for the resources of a created hook to complete. If the hook
+// fails and has retries left, its resources are deleted and, once they are
+// gone, recreated after the hook's backoff delay. Every attempt is recorded in
+// the hook's history while holding mu.
+func (cfg *Configuration) watchHook(ctx context.Context, hook release.HookEvent, h *release.Hook, resources kube.ResourceList, timeout time.Duration, mu *sync.Mutex) error {
+	err := cfg.watchUntilReady(ctx, resources, timeout)
+	mu.Lock()
+	completeHookAttempt(h, err)
+	mu.Unlock()
+
+	for retry := 1; err != nil && ctx.Err() == nil && retry <= h.Retries; retry++ {
+		cfg.Log("%s hook %s failed, retrying in %s (retry %d of %d): %s", hook, h.Path, h.Backoff, retry, h.Retries, err)
+		if _, errs := cfg.KubeClient.Delete(resources); len(errs) > 0 {
+			return errors.Errorf("unable to delete %s hook %s for retry: %s", hook, h.Path, joinErrors(errs))
+		}
+		// Jobs with finalizers or pods in graceful termination outlive the
+		// delete call, and recreating them would fail with AlreadyExists.
+		if kubeClient, ok := cfg.KubeClient.(kube.InterfaceDeletionWait); ok {
+			if derr := kubeClient.WaitForDelete(ctx, resources, timeout); derr != nil {
+				if derr == context.Canceled {
+					return err
+				}
+				return errors.Wrapf(derr, "unable to delete %s hook %s for retry", hook, h.Path)
+			}
+		}
+		select {
+		case <-time.After(h.Backoff):
+		case <-ctx.Done():
 			return err
 		}
+
+		mu.Lock()
+		startHookAttempt(h)
+		mu.Unlock()
+		resources, err = cfg.KubeClient.Build(bytes.NewBufferString(h.Manifest), true)
+		if err != nil {
+			err = errors.Wrapf(err, "unable to build kubernetes object for %s hook %s", hook, h.Path)
+		} else if _, err = cfg.KubeClient.Create(resources); err != nil {
+			err = errors.Wrapf(err, "warning: Hook %s %s failed", hook, h.Path)
+		} else {
+			err = cfg.watchUntilReady(ctx, resources, timeout)
+		}
+		mu.Lock()
+		completeHookAttempt(h, err)
+		mu.Unlock()
 	}
+	return err
+}
 
-	return nil
+// startHookAttempt marks the start of a new attempt to run the hook.
+func startHookAttempt(h *release.Hook) {
+	h.LastRun = release.HookExecution{
+		StartedAt: helmtime.Now(),
+		Phase:     release.HookPhaseRunning,
+	}
+}
+
+// completeHookAttempt records the outcome of the current attempt to run the hook.
+func completeHookAttempt(h *release.Hook, err error) {
+	// Note the time of success/failure
+	h.LastRun.CompletedAt = helmtime.Now()
+	// Mark hook as succeeded or failed
+	if err != nil {
+		h.LastRun.Phase = release.HookPhaseFailed
+	} else {
+		h.LastRun.Phase = release.HookPhaseSucceeded
+	}
+	h.History = append(h.History, h.LastRun)
+}
+
+// cancelHookAttempt records that the current attempt to run the hook was
+// cancelled because another hook failed.
+func cancelHookAttempt(h *release.Hook) {
+	h.LastRun.Phase = release.HookPhaseCancelled
+	if n := len(h.History); n > 0 {
+		h.History[n-1].Phase = release.HookPhaseCancelled
+	}
+}
+
+// skipHook records that the hook was not run because another hook failed.
+func skipHook(h *release.Hook) {
+	h.History = nil
+	h.LastRun = release.HookExecution{Phase: release.HookPhaseCancelled}
+}
+
+// hookWeightGroups splits hooks sorted by weight into groups of equal weight.
+func hookWeightGroups(hooks []*release.Hook) [][]*release.Hook {
+	var groups [][]*release.Hook
+	for i, h := range hooks {
+		if i == 0 || h.Weight != hooks[i-1].Weight {
+			groups = append(groups, []*release.Hook{})
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], h)
+	}
+	return groups
 }
 
 // hookByWeight is a sorter for hooks
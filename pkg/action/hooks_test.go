@@ -0,0 +1,213 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v3/pkg/kube"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+// watchCountingKubeClient tracks how many hooks are watched concurrently and
+// immediately fails the watch calls numbered in failOn. Other watch calls
+// succeed after a short delay unless the context is cancelled. It also counts
+// the waits for the deletion of hooks.
+type watchCountingKubeClient struct {
+	kubefake.FailingKubeClient
+
+	mu          sync.Mutex
+	calls       int
+	active      int
+	maxSeen     int
+	failOn      map[int]bool
+	deleteWaits int
+}
+
+func (c *watchCountingKubeClient) WaitForDelete(ctx context.Context, _ kube.ResourceList, _ time.Duration) error {
+	c.mu.Lock()
+	c.deleteWaits++
+	c.mu.Unlock()
+	return ctx.Err()
+}
+
+func (c *watchCountingKubeClient) WatchUntilReadyWithContext(ctx context.Context, _ kube.ResourceList, _ time.Duration) error {
+	c.mu.Lock()
+	c.calls++
+	call := c.calls
+	c.active++
+	if c.active > c.maxSeen {
+		c.maxSeen = c.active
+	}
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.active--
+		c.mu.Unlock()
+	}()
+
+	if c.failOn[call] {
+		return errors.Errorf("hook %d failed", call)
+	}
+	select {
+	case <-time.After(50 * time.Millisecond):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func hookStub(name string, weight int) *release.Hook {
+	return &release.Hook{
+		Name:     name,
+		Kind:     "Job",
+		Path:     "templates/" + name,
+		Manifest: manifestWithHook,
+		Events:   []release.HookEvent{release.HookPreUpgrade},
+		Weight:   weight,
+	}
+}
+
+func TestExecHookGroupsByWeight(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	client := &watchCountingKubeClient{}
+	config.KubeClient = client
+
+	rel := releaseStub()
+	rel.Hooks = []*release.Hook{hookStub("migrate-a", 0), hookStub("migrate-b", 0), hookStub("finish", 1)}
+	require.NoError(t, config.Releases.Create(rel))
+
+	require.NoError(t, config.execHook(context.Background(), rel, release.HookPreUpgrade, time.Minute))
+	is.Equal(3, client.calls)
+	is.Equal(2, client.maxSeen, "expected the hooks of equal weight to run concurrently")
+	for _, h := range rel.Hooks {
+		is.Equal(release.HookPhaseSucceeded, h.LastRun.Phase, h.Name)
+	}
+	is.False(rel.Hooks[2].LastRun.StartedAt.Before(rel.Hooks[0].LastRun.CompletedAt), "expected next weight group to start after the previous group completed")
+}
+
+func TestExecHookGroupFailure(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	client := &watchCountingKubeClient{failOn: map[int]bool{1: true}}
+	config.KubeClient = client
+
+	rel := releaseStub()
+	rel.Hooks = []*release.Hook{hookStub("migrate-a", 0), hookStub("migrate-b", 0), hookStub("finish", 1)}
+	require.NoError(t, config.Releases.Create(rel))
+
+	err := config.execHook(context.Background(), rel, release.HookPreUpgrade, time.Minute)
+	is.EqualError(err, "hook 1 failed")
+	is.Equal(2, client.calls, "expected the next weight group not to run")
+
+	// either hook of the group may be watched first
+	phases := []release.HookPhase{rel.Hooks[0].LastRun.Phase, rel.Hooks[1].LastRun.Phase}
+	is.ElementsMatch([]release.HookPhase{release.HookPhaseFailed, release.HookPhaseCancelled}, phases, "expected the failure to cancel the other hook of the group")
+	is.True(rel.Hooks[2].LastRun.StartedAt.IsZero())
+}
+
+// createFailingKubeClient fails the create call numbered failCreateOn.
+type createFailingKubeClient struct {
+	watchCountingKubeClient
+
+	creates      int
+	failCreateOn int
+}
+
+func (c *createFailingKubeClient) Create(resources kube.ResourceList) (*kube.Result, error) {
+	c.creates++
+	if c.creates == c.failCreateOn {
+		return nil, errors.Errorf("create %d failed", c.creates)
+	}
+	return c.watchCountingKubeClient.Create(resources)
+}
+
+func TestExecHookGroupCreateFailure(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	client := &createFailingKubeClient{failCreateOn: 2}
+	config.KubeClient = client
+
+	rel := releaseStub()
+	rel.Hooks = []*release.Hook{hookStub("migrate-a", 0), hookStub("migrate-b", 0), hookStub("migrate-c", 0)}
+	// migrate-c succeeded in a previous run
+	rel.Hooks[2].LastRun = release.HookExecution{StartedAt: helmtime.Now(), Phase: release.HookPhaseSucceeded}
+	require.NoError(t, config.Releases.Create(rel))
+
+	err := config.execHook(context.Background(), rel, release.HookPreUpgrade, time.Minute)
+	is.EqualError(err, "warning: Hook pre-upgrade templates/migrate-b failed: create 2 failed")
+	is.Equal(release.HookPhaseCancelled, rel.Hooks[0].LastRun.Phase, "expected the created hook to be cancelled")
+	is.Equal(release.HookPhaseFailed, rel.Hooks[1].LastRun.Phase)
+	is.Equal(release.HookPhaseCancelled, rel.Hooks[2].LastRun.Phase, "expected the hook not created to be cancelled")
+	is.True(rel.Hooks[2].LastRun.StartedAt.IsZero())
+}
+
+func TestExecHookCancelled(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	config.KubeClient = &watchCountingKubeClient{}
+
+	rel := releaseStub()
+	rel.Hooks = []*release.Hook{hookStub("migrate", 0)}
+	require.NoError(t, config.Releases.Create(rel))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	is.Equal(context.Canceled, config.execHook(ctx, rel, release.HookPreUpgrade, time.Minute))
+	is.Equal(release.HookPhaseFailed, rel.Hooks[0].LastRun.Phase)
+}
+
+func TestExecHookRetries(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	client := &watchCountingKubeClient{failOn: map[int]bool{1: true, 2: true}}
+	config.KubeClient = client
+
+	rel := releaseStub()
+	flaky := hookStub("flaky", 0)
+	flaky.Retries = 2
+	flaky.Backoff = time.Millisecond
+	rel.Hooks = []*release.Hook{flaky}
+	require.NoError(t, config.Releases.Create(rel))
+
+	require.NoError(t, config.execHook(context.Background(), rel, release.HookPreUpgrade, time.Minute))
+	is.Equal(3, client.calls)
+	is.Equal(2, client.deleteWaits, "expected each retry to wait for the deletion of the hook")
+	is.Equal(release.HookPhaseSucceeded, flaky.LastRun.Phase)
+	require.Len(t, flaky.History, 3)
+	is.Equal(release.HookPhaseFailed, flaky.History[0].Phase)
+	is.Equal(release.HookPhaseFailed, flaky.History[1].Phase)
+	is.Equal(flaky.LastRun, flaky.History[2])
+
+	// Once the retries are exhausted the hook fails.
+	client.calls = 0
+	client.failOn = map[int]bool{1: true, 2: true, 3: true}
+	is.EqualError(config.execHook(context.Background(), rel, release.HookPreUpgrade, time.Minute), "hook 3 failed")
+	is.Equal(release.HookPhaseFailed, flaky.LastRun.Phase)
+	is.Len(flaky.History, 3, "expected the history to only hold the attempts of the last execution")
+}
@@ -18,6 +18,7 @@ package action
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/url"
@@ -81,6 +82,7 @@ type Install struct {
 	WaitForJobs              bool
 	Devel                    bool
 	DependencyUpdate         bool
+	Offline                  bool
 	Timeout                  time.Duration
 	Namespace                string
 	ReleaseName              string
@@ -104,6 +106,19 @@ type Install struct {
 	// OutputDir/<ReleaseName>
 	UseReleaseName bool
 	PostRenderer   postrender.PostRenderer
+	// ServerSideApply creates resources using server-side apply instead of a
+	// client-side create or patch.
+	ServerSideApply bool
+	// ForceConflicts takes ownership of fields managed by other field managers
+	// when ServerSideApply is set.
+	ForceConflicts bool
+	// Adopt takes ownership of existing resources that are not owned by any
+	// release instead of refusing to install. Resources owned by a different
+	// release are still refused.
+	Adopt bool
+	// WaitProgress receives the readiness of the resources while waiting for
+	// them to be ready, if the Kubernetes client supports reporting it.
+	WaitProgress kube.WaitProgressFunc
 }
 
 // ChartPathOptions captures common options used for controlling chart paths
@@ -175,6 +190,13 @@ func (i *Install) installCRDs(crds []chart.CRD) error {
 //
 // If DryRun is set to true, this will prepare the release, but not install it
 func (i *Install) Run(chrt *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
+	return i.RunWithContext(context.Background(), chrt, vals)
+}
+
+// RunWithContext executes the installation like Run. If the context is
+// cancelled while waiting for hooks or resources, the release is marked as
+// failed.
+func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
 	// Check reachability of cluster unless in client-only mode (e.g. `helm template` without `--validate`)
 	if !i.ClientOnly {
 		if err := i.cfg.KubeClient.IsReachable(); err != nil {
@@ -186,6 +208,10 @@ func (i *Install) Run(chrt *chart.Chart, vals map[string]interface{}) (*release.
 		return nil, err
 	}
 
+	if err := validateServerSideApply(i.ServerSideApply, false, i.ForceConflicts); err != nil {
+		return nil, err
+	}
+
 	// Pre-install anything in the crd/ directory. We do this before Helm
 	// contacts the upstream server and builds the capabilities object.
 	if crds := chrt.CRDObjects(); !i.ClientOnly && !i.SkipCRDs && len(crds) > 0 {
@@ -258,6 +284,7 @@ func (i *Install) Run(chrt *chart.Chart, vals map[string]interface{}) (*release.
 
 	// Mark this release as in-progress
 	rel.SetStatus(release.StatusPendingInstall, "Initial install underway")
+	i.cfg.leasePending(rel, i.Timeout)
 
 	var toBeAdopted kube.ResourceList
 	resources, err := i.cfg.KubeClient.Build(bytes.NewBufferString(rel.Manifest), !i.DisableOpenAPIValidation)
@@ -278,10 +305,12 @@ func (i *Install) Run(chrt *chart.Chart, vals map[string]interface{}) (*release.
 	// deleting the release because the manifest will be pointing at that
 	// resource
 	if !i.ClientOnly && !isUpgrade && len(resources) > 0 {
-		toBeAdopted, err = existingResourceConflict(resources, rel.Name, rel.Namespace)
+		var adopted kube.ResourceList
+		toBeAdopted, adopted, err = existingResourceConflict(resources, rel.Name, rel.Namespace, i.Adopt)
 		if err != nil {
 			return nil, errors.Wrap(err, "rendered manifests contain a resource that already exists. Unable to continue with install")
 		}
+		rel.Info.Adopted = adoptedReferences(adopted)
 	}
 
 	// Bail out here if it is a dry run
@@ -334,7 +363,7 @@ func (i *Install) Run(chrt *chart.Chart, vals map[string]interface{}) (*release.
 
 	// pre-install hooks
 	if !i.DisableHooks {
-		if err := i.cfg.execHook(rel, release.HookPreInstall, i.Timeout); err != nil {
+		if err := i.cfg.execHook(ctx, rel, release.HookPreInstall, i.Timeout); err != nil {
 			return i.failRelease(rel, fmt.Errorf("failed pre-install: %s", err))
 		}
 	}
@@ -342,30 +371,24 @@ func (i *Install) Run(chrt *chart.Chart, vals map[string]interface{}) (*release.
 	// At this point, we can do the install. Note that before we were detecting whether to
 	// do an update, but it's not clear whether we WANT to do an update if the re-use is set
 	// to true, since that is basically an upgrade operation.
-	if len(toBeAdopted) == 0 && len(resources) > 0 {
+	if len(toBeAdopted) == 0 && len(resources) > 0 && !i.ServerSideApply {
 		if _, err := i.cfg.KubeClient.Create(resources); err != nil {
 			return i.failRelease(rel, err)
 		}
 	} else if len(resources) > 0 {
-		if _, err := i.cfg.KubeClient.Update(toBeAdopted, resources, false); err != nil {
+		if _, err := i.cfg.updateResources(ctx, toBeAdopted, resources, false, i.ServerSideApply, i.ForceConflicts, i.Timeout); err != nil {
 			return i.failRelease(rel, err)
 		}
 	}
 
 	if i.Wait {
-		if i.WaitForJobs {
-			if err := i.cfg.KubeClient.WaitWithJobs(resources, i.Timeout); err != nil {
-				return i.failRelease(rel, err)
-			}
-		} else {
-			if err := i.cfg.KubeClient.Wait(resources, i.Timeout); err != nil {
-				return i.failRelease(rel, err)
-			}
+		if err := i.cfg.waitForResources(ctx, resources, i.Timeout, i.WaitForJobs, i.WaitProgress); err != nil {
+			return i.failRelease(rel, err)
 		}
 	}
 
 	if !i.DisableHooks {
-		if err := i.cfg.execHook(rel, release.HookPostInstall, i.Timeout); err != nil {
+		if err := i.cfg.execHook(ctx, rel, release.HookPostInstall, i.Timeout); err != nil {
 			return i.failRelease(rel, fmt.Errorf("failed post-install: %s", err))
 		}
 	}
@@ -44,6 +44,8 @@ type Package struct {
 	AppVersion       string
 	Destination      string
 	DependencyUpdate bool
+	// Offline updates the dependencies from the local chart cache only.
+	Offline bool
 
 	RepositoryConfig string
 	RepositoryCache  string
@@ -0,0 +1,38 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+// Reencrypt is the action for re-encrypting the records of all releases.
+//
+// It provides the implementation of 'helm release reencrypt'.
+type Reencrypt struct {
+	cfg *Configuration
+}
+
+// NewReencrypt creates a new Reencrypt object with the given configuration.
+func NewReencrypt(cfg *Configuration) *Reencrypt {
+	return &Reencrypt{
+		cfg: cfg,
+	}
+}
+
+// Run rewrites the records of all releases in the namespace with the current
+// encryption key, returning the number of rewritten records.
+func (r *Reencrypt) Run() (int, error) {
+	r.cfg.Log("re-encrypting releases")
+	return r.cfg.Releases.Reencrypt()
+}
@@ -0,0 +1,153 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// ReleaseRename is the action for renaming a release or moving its records
+// to another namespace without redeploying it.
+//
+// It provides the implementation of 'helm release rename'.
+type ReleaseRename struct {
+	cfg *Configuration
+
+	// Namespace is the namespace to move the release to. The release stays
+	// in its namespace if empty.
+	Namespace string
+	// TargetReleases is the storage of the releases of Namespace. It must be
+	// set when Namespace differs from the namespace of the release.
+	TargetReleases *storage.Storage
+	// DryRun checks that the release can be renamed without changing it.
+	DryRun bool
+}
+
+// NewReleaseRename creates a new ReleaseRename object with the given
+// configuration.
+func NewReleaseRename(cfg *Configuration) *ReleaseRename {
+	return &ReleaseRename{
+		cfg: cfg,
+	}
+}
+
+// Run renames a release and returns its renamed last revision.
+//
+// Every revision of the release is stored again under the new name and
+// namespace, the labels and annotations recording the ownership of the live
+// resources of the release are rewritten, and the records under the old name
+// are removed. The live objects are otherwise left untouched, so namespaced
+// resources stay in the namespace they were deployed to.
+func (r *ReleaseRename) Run(name, newName string) (*release.Release, error) {
+	if err := r.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+	for _, n := range []string{name, newName} {
+		if err := chartutil.ValidateReleaseName(n); err != nil {
+			return nil, errors.Errorf("release name is invalid: %s", n)
+		}
+	}
+
+	history, err := r.cfg.Releases.History(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, driver.ErrReleaseNotFound
+	}
+	releaseutil.SortByRevision(history)
+	last := history[len(history)-1]
+	if last.Info.Status.IsPending() {
+		return nil, pendingError(last)
+	}
+
+	namespace, target := last.Namespace, r.cfg.Releases
+	if r.Namespace != "" && r.Namespace != last.Namespace {
+		if r.TargetReleases == nil {
+			return nil, errors.Errorf("no storage configured for namespace %q", r.Namespace)
+		}
+		namespace, target = r.Namespace, r.TargetReleases
+	}
+	if newName == name && namespace == last.Namespace {
+		return nil, errors.Errorf("release %q is already named %q in namespace %q", name, newName, namespace)
+	}
+	if existing, err := target.History(newName); err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, err
+	} else if len(existing) > 0 {
+		return nil, errors.Errorf("cannot rename release %q: release %q already exists in namespace %q", name, newName, namespace)
+	}
+
+	kubeClient, ok := r.cfg.KubeClient.(kube.InterfaceTransfer)
+	if !ok {
+		return nil, errors.Errorf("kubernetes client %T does not support transferring resources", r.cfg.KubeClient)
+	}
+	var resources kube.ResourceList
+	if last.Info.Status != release.StatusUninstalled && strings.TrimSpace(last.Manifest) != "" {
+		if resources, err = r.cfg.KubeClient.Build(strings.NewReader(last.Manifest), false); err != nil {
+			return nil, errors.Wrap(err, "unable to build kubernetes objects from release manifest")
+		}
+	}
+
+	renamed := make([]*release.Release, 0, len(history))
+	for _, rel := range history {
+		rr := *rel
+		rr.Name = newName
+		rr.Namespace = namespace
+		renamed = append(renamed, &rr)
+	}
+	if r.DryRun {
+		r.cfg.Log("dry run for renaming %s to %s", name, newName)
+		return renamed[len(renamed)-1], nil
+	}
+
+	r.cfg.Log("storing %d revision(s) of release %s as %s in namespace %s", len(renamed), name, newName, namespace)
+	for i, rel := range renamed {
+		if err := target.Create(rel); err != nil {
+			removeRevisions(r.cfg, target, renamed[:i]...)
+			return nil, errors.Wrapf(err, "failed to store revision %d as release %q", rel.Version, newName)
+		}
+	}
+
+	if len(resources) > 0 {
+		r.cfg.Log("transferring %d resource(s) to release %s", len(resources), newName)
+		if res, err := kubeClient.Transfer(resources, newName, namespace); err != nil {
+			if res != nil && len(res.Updated) > 0 {
+				if _, rerr := kubeClient.Transfer(res.Updated, name, last.Namespace); rerr != nil {
+					err = errors.Wrapf(err, "failed to restore the ownership of resources: %s", rerr)
+				}
+			}
+			removeRevisions(r.cfg, target, renamed...)
+			return nil, errors.Wrap(err, "rename failed")
+		}
+	}
+
+	for _, rel := range history {
+		if _, err := r.cfg.Releases.Delete(rel.Name, rel.Version); err != nil {
+			return nil, errors.Wrapf(err, "release renamed to %q, but revision %d of release %q could not be removed", newName, rel.Version, name)
+		}
+	}
+	return renamed[len(renamed)-1], nil
+}
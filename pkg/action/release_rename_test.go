@@ -0,0 +1,114 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+func renameAction(t *testing.T) *ReleaseRename {
+	t.Helper()
+	client := NewReleaseRename(actionConfigFixture(t))
+	for i, status := range []release.Status{release.StatusSuperseded, release.StatusDeployed} {
+		rel := namedReleaseStub("old", status)
+		rel.Namespace = "default"
+		rel.Version = i + 1
+		rel.Manifest = transferSourceManifest
+		require.NoError(t, client.cfg.Releases.Create(rel))
+	}
+	return client
+}
+
+func TestReleaseRename(t *testing.T) {
+	is := assert.New(t)
+	client := renameAction(t)
+
+	rel, err := client.Run("old", "new")
+	is.NoError(err)
+	is.Equal("new", rel.Name)
+	is.Equal(2, rel.Version)
+	is.Equal(release.StatusDeployed, rel.Info.Status)
+
+	history, err := client.cfg.Releases.History("new")
+	is.NoError(err)
+	is.Len(history, 2)
+	_, err = client.cfg.Releases.History("old")
+	is.True(errors.Is(err, driver.ErrReleaseNotFound))
+}
+
+func TestReleaseRenameNamespace(t *testing.T) {
+	is := assert.New(t)
+	client := renameAction(t)
+	target := storage.Init(driver.NewMemory())
+	client.Namespace = "apps"
+
+	_, err := client.Run("old", "old")
+	is.EqualError(err, `no storage configured for namespace "apps"`)
+
+	client.TargetReleases = target
+	rel, err := client.Run("old", "old")
+	is.NoError(err)
+	is.Equal("apps", rel.Namespace)
+
+	history, err := target.History("old")
+	is.NoError(err)
+	is.Len(history, 2)
+	_, err = client.cfg.Releases.History("old")
+	is.True(errors.Is(err, driver.ErrReleaseNotFound))
+}
+
+func TestReleaseRenameInvalid(t *testing.T) {
+	is := assert.New(t)
+	client := renameAction(t)
+	existing := namedReleaseStub("taken", release.StatusDeployed)
+	existing.Namespace = "default"
+	require.NoError(t, client.cfg.Releases.Create(existing))
+
+	_, err := client.Run("old", "old")
+	is.EqualError(err, `release "old" is already named "old" in namespace "default"`)
+	_, err = client.Run("old", "taken")
+	is.EqualError(err, `cannot rename release "old": release "taken" already exists in namespace "default"`)
+	_, err = client.Run("missing", "new")
+	is.True(errors.Is(err, driver.ErrReleaseNotFound))
+
+	pending := namedReleaseStub("old", release.StatusPendingUpgrade)
+	pending.Version = 3
+	require.NoError(t, client.cfg.Releases.Create(pending))
+	_, err = client.Run("old", "new")
+	is.Error(err)
+	is.Contains(err.Error(), "another operation")
+}
+
+func TestReleaseRenameDryRun(t *testing.T) {
+	is := assert.New(t)
+	client := renameAction(t)
+	client.DryRun = true
+
+	rel, err := client.Run("old", "new")
+	is.NoError(err)
+	is.Equal("new", rel.Name)
+	_, err = client.cfg.Releases.History("new")
+	is.True(errors.Is(err, driver.ErrReleaseNotFound))
+}
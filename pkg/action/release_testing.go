@@ -20,10 +20,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
 
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/release"
@@ -38,6 +42,18 @@ type ReleaseTesting struct {
 	// Used for fetching logs from test pods
 	Namespace string
 	Filters   map[string][]string
+	// Suites selects the tests of the given test suites, set by the
+	// helm.sh/test-suite annotation
+	Suites []string
+	// Selector selects the tests by label
+	Selector string
+	// Parallelism is the maximum number of tests of equal weight run at once.
+	// If it is not positive, all tests of equal weight run at once.
+	Parallelism int
+	// FailFast stops the tests once a test fails
+	FailFast bool
+
+	selector labels.Selector
 }
 
 // NewReleaseTesting creates a new ReleaseTesting object with the given configuration.
@@ -50,6 +66,12 @@ func NewReleaseTesting(cfg *Configuration) *ReleaseTesting {
 
 // Run executes 'helm test' against the given release.
 func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
+	return r.RunWithContext(context.Background(), name)
+}
+
+// RunWithContext executes 'helm test' like Run, cancelling the running tests
+// if the context is cancelled.
+func (r *ReleaseTesting) RunWithContext(ctx context.Context, name string) (*release.Release, error) {
 	if err := r.cfg.KubeClient.IsReachable(); err != nil {
 		return nil, err
 	}
@@ -58,6 +80,12 @@ func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
 		return nil, errors.Errorf("releaseTest: Release name is invalid: %s", name)
 	}
 
+	selector, err := labels.Parse(r.Selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "releaseTest: invalid selector")
+	}
+	r.selector = selector
+
 	// finds the non-deleted release with the given name
 	rel, err := r.cfg.Releases.Last(name)
 	if err != nil {
@@ -66,29 +94,16 @@ func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
 
 	skippedHooks := []*release.Hook{}
 	executingHooks := []*release.Hook{}
-	if len(r.Filters["!name"]) != 0 {
-		for _, h := range rel.Hooks {
-			if contains(r.Filters["!name"], h.Name) {
-				skippedHooks = append(skippedHooks, h)
-			} else {
-				executingHooks = append(executingHooks, h)
-			}
-		}
-		rel.Hooks = executingHooks
-	}
-	if len(r.Filters["name"]) != 0 {
-		executingHooks = nil
-		for _, h := range rel.Hooks {
-			if contains(r.Filters["name"], h.Name) {
-				executingHooks = append(executingHooks, h)
-			} else {
-				skippedHooks = append(skippedHooks, h)
-			}
+	for _, h := range rel.Hooks {
+		if r.isSkipped(h) {
+			skippedHooks = append(skippedHooks, h)
+		} else {
+			executingHooks = append(executingHooks, h)
 		}
-		rel.Hooks = executingHooks
 	}
+	rel.Hooks = executingHooks
 
-	if err := r.cfg.execHook(rel, release.HookTest, r.Timeout); err != nil {
+	if err := r.execTests(ctx, rel); err != nil {
 		rel.Hooks = append(skippedHooks, rel.Hooks...)
 		r.cfg.Releases.Update(rel)
 		return rel, err
@@ -98,6 +113,55 @@ func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
 	return rel, r.cfg.Releases.Update(rel)
 }
 
+// execTests runs the test hooks of the release in groups of equal weight, in
+// ascending weight order, keeping up to Parallelism tests running at once.
+// Unless FailFast is set, every test runs and all failures are reported;
+// otherwise the running tests are cancelled and the remaining ones are not
+// started once a test fails.
+func (r *ReleaseTesting) execTests(ctx context.Context, rel *release.Release) error {
+	tests := []*release.Hook{}
+	for _, h := range rel.Hooks {
+		if isTestHook(h) {
+			tests = append(tests, h)
+		}
+	}
+	sort.Stable(hookByWeight(tests))
+
+	var errs []error
+	for _, group := range hookWeightGroups(tests) {
+		if len(errs) > 0 && r.FailFast {
+			// the tests that did not run were cancelled by the failure
+			for _, h := range group {
+				skipHook(h)
+			}
+			continue
+		}
+		if err := r.cfg.execHookGroup(ctx, rel, release.HookTest, group, r.Timeout, r.Parallelism, r.FailFast); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Delete the succeeded tests whose delete policy asks for it. The failed
+	// ones were deleted according to their policy when they failed.
+	for _, h := range tests {
+		if h.LastRun.Phase != release.HookPhaseSucceeded {
+			continue
+		}
+		if err := r.cfg.deleteHookByPolicy(h, release.HookSucceeded); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return errors.New(joinErrors(errs))
+	}
+}
+
 // GetPodLogs will write the logs for all test pods in the given release into
 // the given writer. These can be immediately output to the user or captured for
 // other uses
@@ -136,3 +200,60 @@ func contains(arr []string, value string) bool {
 	}
 	return false
 }
+
+// testHookHead is the metadata of a test hook selecting it.
+type testHookHead struct {
+	Metadata struct {
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+}
+
+func parseTestHookHead(h *release.Hook) (*testHookHead, error) {
+	var head testHookHead
+	err := yaml.Unmarshal([]byte(h.Manifest), &head)
+	return &head, err
+}
+
+// isSkipped reports whether the filters, suites or selector exclude the given
+// hook.
+func (r *ReleaseTesting) isSkipped(h *release.Hook) bool {
+	if contains(r.Filters["!name"], h.Name) {
+		return true
+	}
+	if len(r.Filters["name"]) != 0 && !contains(r.Filters["name"], h.Name) {
+		return true
+	}
+	if !isTestHook(h) || len(r.Suites) == 0 && (r.selector == nil || r.selector.Empty()) {
+		return false
+	}
+
+	head, err := parseTestHookHead(h)
+	if err != nil {
+		r.cfg.Log("unable to parse test hook %s: %s", h.Name, err)
+		return true
+	}
+	if r.selector != nil && !r.selector.Matches(labels.Set(head.Metadata.Labels)) {
+		return true
+	}
+	if len(r.Suites) == 0 {
+		return false
+	}
+	for _, suite := range testSuites(head.Metadata.Annotations) {
+		if contains(r.Suites, suite) {
+			return false
+		}
+	}
+	return true
+}
+
+// testSuites returns the test suites set by the test suite annotation.
+func testSuites(annotations map[string]string) []string {
+	var suites []string
+	for _, suite := range strings.Split(annotations[release.HookTestSuiteAnnotation], ",") {
+		if suite = strings.TrimSpace(suite); suite != "" {
+			suites = append(suites, suite)
+		}
+	}
+	return suites
+}
@@ -0,0 +1,276 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"helm.sh/helm/v3/pkg/release"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+// Test report formats
+const (
+	TestReportJUnit = "junit"
+	TestReportJSON  = "json"
+)
+
+// TestReport is the machine-readable report of the tests of a release.
+type TestReport struct {
+	Release   string        `json:"release"`
+	Namespace string        `json:"namespace"`
+	Revision  int           `json:"revision"`
+	Tests     []*TestResult `json:"tests"`
+}
+
+// TestResult is the result of a single test hook.
+type TestResult struct {
+	Name string `json:"name"`
+	// Suites are the test suites the test belongs to
+	Suites []string `json:"suites,omitempty"`
+	// Phase is the phase of the last run of the test
+	Phase release.HookPhase `json:"phase"`
+	// Skipped is set for the tests excluded from the run and the tests
+	// cancelled because another test failed
+	Skipped     bool          `json:"skipped,omitempty"`
+	StartedAt   helmtime.Time `json:"started_at,omitempty"`
+	CompletedAt helmtime.Time `json:"completed_at,omitempty"`
+	// Logs are the logs of every container of the test pod
+	Logs []*ContainerLogs `json:"logs,omitempty"`
+}
+
+// ContainerLogs are the logs of a container of a test pod.
+type ContainerLogs struct {
+	Container string `json:"container"`
+	Log       string `json:"log"`
+}
+
+// Passed reports whether the test ran and succeeded.
+func (t *TestResult) Passed() bool {
+	return !t.Skipped && t.Phase == release.HookPhaseSucceeded
+}
+
+// Report returns the report of the last run of the tests of the given
+// release, including the logs of the test pods that still exist.
+func (r *ReleaseTesting) Report(rel *release.Release) *TestReport {
+	report := r.newTestReport(rel)
+	client, err := r.cfg.KubernetesClientSet()
+	if err != nil {
+		r.cfg.Log("unable to get kubernetes client to fetch pod logs: %s", err)
+		return report
+	}
+	r.collectLogs(client, report)
+	return report
+}
+
+func (r *ReleaseTesting) newTestReport(rel *release.Release) *TestReport {
+	report := &TestReport{
+		Release:   rel.Name,
+		Namespace: rel.Namespace,
+		Revision:  rel.Version,
+		Tests:     []*TestResult{},
+	}
+	for _, h := range rel.Hooks {
+		if !isTestHook(h) {
+			continue
+		}
+		result := &TestResult{Name: h.Name, Skipped: r.isSkipped(h)}
+		if head, err := parseTestHookHead(h); err == nil {
+			result.Suites = testSuites(head.Metadata.Annotations)
+		}
+		if !result.Skipped {
+			result.Phase = h.LastRun.Phase
+			result.StartedAt = h.LastRun.StartedAt
+			result.CompletedAt = h.LastRun.CompletedAt
+			result.Skipped = h.LastRun.Phase == release.HookPhaseCancelled
+		}
+		report.Tests = append(report.Tests, result)
+	}
+	return report
+}
+
+// collectLogs adds the logs of every container of the test pods to the
+// report. Test pods may have been deleted by their hook deletion policy, so
+// missing logs are not an error.
+func (r *ReleaseTesting) collectLogs(client kubernetes.Interface, report *TestReport) {
+	pods := client.CoreV1().Pods(r.Namespace)
+	for _, t := range report.Tests {
+		if t.StartedAt.IsZero() {
+			continue
+		}
+		pod, err := pods.Get(context.Background(), t.Name, metav1.GetOptions{})
+		if err != nil {
+			r.cfg.Log("unable to get test pod %s: %s", t.Name, err)
+			continue
+		}
+		containers := make([]v1.Container, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+		containers = append(containers, pod.Spec.InitContainers...)
+		for _, c := range append(containers, pod.Spec.Containers...) {
+			logs, err := podLogs(pods.GetLogs(t.Name, &v1.PodLogOptions{Container: c.Name}).Stream(context.Background()))
+			if err != nil {
+				r.cfg.Log("unable to get logs of container %s of test pod %s: %s", c.Name, t.Name, err)
+				continue
+			}
+			t.Logs = append(t.Logs, &ContainerLogs{Container: c.Name, Log: logs})
+		}
+	}
+}
+
+func podLogs(stream io.ReadCloser, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+	b, err := ioutil.ReadAll(stream)
+	return string(b), err
+}
+
+func isTestHook(h *release.Hook) bool {
+	for _, e := range h.Events {
+		if e == release.HookTest {
+			return true
+		}
+	}
+	return false
+}
+
+// Write writes the report in the given format.
+func (t *TestReport) Write(out io.Writer, format string) error {
+	switch format {
+	case TestReportJUnit:
+		return t.WriteJUnit(out)
+	case TestReportJSON:
+		return t.WriteJSON(out)
+	}
+	return errors.Errorf("invalid test report format %q, must be one of: %s, %s", format, TestReportJUnit, TestReportJSON)
+}
+
+// WriteJSON writes the report as JSON.
+func (t *TestReport) WriteJSON(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut *junitOutput  `xml:"system-out,omitempty"`
+}
+
+type junitOutput struct {
+	Data string `xml:",cdata"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes the report as JUnit XML, with one test suite for the
+// release and one test case per test hook.
+func (t *TestReport) WriteJUnit(out io.Writer) error {
+	suite := junitTestSuite{
+		Name:  t.Release,
+		Tests: len(t.Tests),
+	}
+	var started, completed helmtime.Time
+	for _, test := range t.Tests {
+		c := junitTestCase{
+			Name:      test.Name,
+			Classname: t.Release,
+			Time:      junitDuration(test.StartedAt, test.CompletedAt),
+		}
+		if logs := test.logs(); logs != "" {
+			c.SystemOut = &junitOutput{Data: logs}
+		}
+		switch {
+		case test.Skipped:
+			suite.Skipped++
+			c.Skipped = &struct{}{}
+		case !test.Passed():
+			suite.Failures++
+			c.Failure = &junitFailure{Message: fmt.Sprintf("%s: phase %s", test.Name, test.Phase)}
+		}
+		if !test.StartedAt.IsZero() && (started.IsZero() || test.StartedAt.Before(started)) {
+			started = test.StartedAt
+		}
+		if test.CompletedAt.After(completed) {
+			completed = test.CompletedAt
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	suite.Time = junitDuration(started, completed)
+	if !started.IsZero() {
+		suite.Timestamp = started.UTC().Format("2006-01-02T15:04:05")
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+// logs returns the logs of all containers, each headed by the container name.
+func (t *TestResult) logs() string {
+	var b strings.Builder
+	for _, l := range t.Logs {
+		fmt.Fprintf(&b, "CONTAINER LOGS: %s\n%s\n", l.Container, l.Log)
+	}
+	return b.String()
+}
+
+func junitDuration(start, end helmtime.Time) string {
+	if start.IsZero() || end.Before(start) {
+		return "0.000"
+	}
+	return fmt.Sprintf("%.3f", end.Sub(start).Seconds())
+}
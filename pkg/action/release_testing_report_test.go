@@ -0,0 +1,106 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"helm.sh/helm/v3/internal/test"
+	"helm.sh/helm/v3/pkg/release"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+func testReportRelease() *release.Release {
+	started := helmtime.Time{Time: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)}
+	hook := func(name string, phase release.HookPhase, d time.Duration) *release.Hook {
+		return &release.Hook{
+			Name:   name,
+			Kind:   "Pod",
+			Events: []release.HookEvent{release.HookTest},
+			LastRun: release.HookExecution{
+				StartedAt:   started,
+				CompletedAt: started.Add(d),
+				Phase:       phase,
+			},
+		}
+	}
+	return &release.Release{
+		Name:      "angry-bird",
+		Namespace: "default",
+		Version:   2,
+		Hooks: []*release.Hook{
+			{Name: "pre-install", Kind: "Job", Events: []release.HookEvent{release.HookPreInstall}},
+			hook("test-connection", release.HookPhaseSucceeded, 1500*time.Millisecond),
+			hook("test-auth", release.HookPhaseFailed, 3*time.Second),
+			hook("test-slow", release.HookPhaseUnknown, 0),
+			hook("test-cache", release.HookPhaseCancelled, 2*time.Second),
+		},
+	}
+}
+
+func TestReleaseTestingReport(t *testing.T) {
+	is := assert.New(t)
+	client := NewReleaseTesting(actionConfigFixture(t))
+	client.Namespace = "default"
+	client.Filters["!name"] = []string{"test-slow"}
+
+	clientset := fake.NewSimpleClientset(
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-connection", Namespace: "default"},
+			Spec: v1.PodSpec{
+				InitContainers: []v1.Container{{Name: "wait"}},
+				Containers:     []v1.Container{{Name: "curl"}, {Name: "sidecar"}},
+			},
+		},
+	)
+
+	report := client.newTestReport(testReportRelease())
+	client.collectLogs(clientset, report)
+
+	is.Len(report.Tests, 4)
+	is.True(report.Tests[0].Passed())
+	is.False(report.Tests[1].Passed())
+	is.True(report.Tests[2].Skipped)
+	is.True(report.Tests[3].Skipped, "expected the cancelled test to be skipped")
+	is.Equal(release.HookPhaseCancelled, report.Tests[3].Phase)
+
+	// the pod of test-auth was deleted, so only test-connection has logs
+	logs := report.Tests[0].Logs
+	if is.Len(logs, 3) {
+		is.Equal("wait", logs[0].Container)
+		is.Equal("curl", logs[1].Container)
+		is.Equal("sidecar", logs[2].Container)
+	}
+	is.Empty(report.Tests[1].Logs)
+
+	var junit bytes.Buffer
+	is.NoError(report.Write(&junit, TestReportJUnit))
+	test.AssertGoldenBytes(t, junit.Bytes(), "output/test-report-junit.xml")
+
+	var json bytes.Buffer
+	is.NoError(report.Write(&json, TestReportJSON))
+	test.AssertGoldenBytes(t, json.Bytes(), "output/test-report.json")
+
+	is.Error(report.Write(&json, "yaml"))
+}
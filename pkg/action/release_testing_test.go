@@ -0,0 +1,229 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func testHookStub(name, suite, tier string) *release.Hook {
+	return &release.Hook{
+		Name: name,
+		Kind: "Pod",
+		Path: "templates/tests/" + name,
+		Manifest: fmt.Sprintf(`apiVersion: v1
+kind: Pod
+metadata:
+  name: %s
+  labels:
+    tier: %s
+  annotations:
+    "helm.sh/hook": test
+    "helm.sh/test-suite": %q
+`, name, tier, suite),
+		Events: []release.HookEvent{release.HookTest},
+	}
+}
+
+func testingReleaseStub() *release.Release {
+	rel := releaseStub()
+	rel.Hooks = []*release.Hook{
+		testHookStub("test-db", "smoke,backend", "db"),
+		testHookStub("test-api", "backend", "api"),
+		testHookStub("test-ui", "frontend", "web"),
+		testHookStub("test-e2e", "", "web"),
+	}
+	return rel
+}
+
+func ranTests(rel *release.Release) []string {
+	var ran []string
+	for _, h := range rel.Hooks {
+		if !h.LastRun.StartedAt.IsZero() {
+			ran = append(ran, h.Name)
+		}
+	}
+	return ran
+}
+
+func TestReleaseTestingSelection(t *testing.T) {
+	tests := []struct {
+		name     string
+		suites   []string
+		selector string
+		filters  map[string][]string
+		expected []string
+	}{
+		{name: "all", expected: []string{"test-db", "test-api", "test-ui", "test-e2e"}},
+		{name: "suite", suites: []string{"backend"}, expected: []string{"test-db", "test-api"}},
+		{name: "suites", suites: []string{"smoke", "frontend"}, expected: []string{"test-db", "test-ui"}},
+		{name: "selector", selector: "tier=web", expected: []string{"test-ui", "test-e2e"}},
+		{name: "suite and selector", suites: []string{"backend"}, selector: "tier!=db", expected: []string{"test-api"}},
+		{name: "suite and filter", suites: []string{"backend"}, filters: map[string][]string{"!name": {"test-api"}}, expected: []string{"test-db"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := actionConfigFixture(t)
+			config.KubeClient = &watchCountingKubeClient{}
+			rel := testingReleaseStub()
+			require.NoError(t, config.Releases.Create(rel))
+
+			client := NewReleaseTesting(config)
+			client.Suites = tt.suites
+			client.Selector = tt.selector
+			if tt.filters != nil {
+				client.Filters = tt.filters
+			}
+			res, err := client.Run(rel.Name)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.expected, ranTests(res))
+		})
+	}
+}
+
+func TestReleaseTestingInvalidSelector(t *testing.T) {
+	config := actionConfigFixture(t)
+	client := NewReleaseTesting(config)
+	client.Selector = "tier in"
+	_, err := client.Run("angry-panda")
+	assert.Error(t, err)
+}
+
+func TestReleaseTestingParallelism(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	kubeClient := &watchCountingKubeClient{}
+	config.KubeClient = kubeClient
+	rel := testingReleaseStub()
+	require.NoError(t, config.Releases.Create(rel))
+
+	client := NewReleaseTesting(config)
+	client.Parallelism = 2
+	_, err := client.Run(rel.Name)
+	require.NoError(t, err)
+	is.Equal(4, kubeClient.calls)
+	is.Equal(2, kubeClient.maxSeen)
+}
+
+func TestReleaseTestingFailures(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	config.KubeClient = &watchCountingKubeClient{failOn: map[int]bool{1: true}}
+	rel := testingReleaseStub()
+	require.NoError(t, config.Releases.Create(rel))
+
+	client := NewReleaseTesting(config)
+	client.Parallelism = 1
+	res, err := client.Run(rel.Name)
+	is.EqualError(err, "hook 1 failed")
+
+	// every test runs despite the failure
+	phases := map[string]release.HookPhase{}
+	for _, h := range res.Hooks {
+		phases[h.Name] = h.LastRun.Phase
+	}
+	is.Equal(map[string]release.HookPhase{
+		"test-api": release.HookPhaseFailed,
+		"test-db":  release.HookPhaseSucceeded,
+		"test-e2e": release.HookPhaseSucceeded,
+		"test-ui":  release.HookPhaseSucceeded,
+	}, phases)
+}
+
+func TestReleaseTestingFailFast(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	kubeClient := &watchCountingKubeClient{failOn: map[int]bool{1: true}}
+	config.KubeClient = kubeClient
+	rel := testingReleaseStub()
+	require.NoError(t, config.Releases.Create(rel))
+
+	client := NewReleaseTesting(config)
+	client.Parallelism = 2
+	client.FailFast = true
+	res, err := client.Run(rel.Name)
+	is.EqualError(err, "hook 1 failed", "expected the cancelled tests not to be reported as failures")
+	is.LessOrEqual(kubeClient.calls, 2, "expected the remaining tests not to run")
+
+	// tests run in name order: one of the first two tests fails and cancels
+	// the other if it started, the remaining tests do not run
+	phases := map[string]release.HookPhase{}
+	for _, h := range res.Hooks {
+		phases[h.Name] = h.LastRun.Phase
+	}
+	is.ElementsMatch([]release.HookPhase{release.HookPhaseFailed, release.HookPhaseCancelled}, []release.HookPhase{phases["test-api"], phases["test-db"]})
+	is.Equal(release.HookPhaseCancelled, phases["test-e2e"])
+	is.Equal(release.HookPhaseCancelled, phases["test-ui"])
+
+	report := client.newTestReport(res)
+	for _, test := range report.Tests {
+		is.Equal(test.Phase == release.HookPhaseCancelled, test.Skipped, test.Name)
+	}
+}
+
+// poolKubeClient blocks the first watched test until the third one starts.
+type poolKubeClient struct {
+	watchCountingKubeClient
+	third chan struct{}
+}
+
+func (c *poolKubeClient) WatchUntilReadyWithContext(ctx context.Context, _ kube.ResourceList, _ time.Duration) error {
+	c.mu.Lock()
+	c.calls++
+	call := c.calls
+	c.mu.Unlock()
+
+	switch call {
+	case 1:
+		select {
+		case <-c.third:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("the third test did not start while the first one was running")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case 3:
+		close(c.third)
+	}
+	return nil
+}
+
+func TestReleaseTestingParallelismPool(t *testing.T) {
+	config := actionConfigFixture(t)
+	kubeClient := &poolKubeClient{third: make(chan struct{})}
+	config.KubeClient = kubeClient
+	rel := testingReleaseStub()
+	require.NoError(t, config.Releases.Create(rel))
+
+	client := NewReleaseTesting(config)
+	client.Parallelism = 2
+	_, err := client.Run(rel.Name)
+	require.NoError(t, err, "expected a test to start as soon as another one completed")
+	assert.Equal(t, 4, kubeClient.calls)
+}
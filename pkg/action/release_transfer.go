@@ -0,0 +1,242 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage"
+)
+
+// ReleaseTransfer is the action for moving resources from one release to
+// another.
+//
+// It provides the implementation of 'helm release transfer'.
+type ReleaseTransfer struct {
+	cfg *Configuration
+
+	// Resources selects the resources to transfer as KIND/NAME. Kinds are
+	// matched case-insensitively.
+	Resources []string
+	// DryRun computes the new revisions of both releases without storing
+	// them or changing the ownership of the resources.
+	DryRun bool
+}
+
+// NewReleaseTransfer creates a new ReleaseTransfer object with the given
+// configuration.
+func NewReleaseTransfer(cfg *Configuration) *ReleaseTransfer {
+	return &ReleaseTransfer{
+		cfg: cfg,
+	}
+}
+
+// Run transfers the selected resources from the source release to the target
+// release and returns the new revisions of both releases.
+//
+// The resources are removed from the manifest of the source release and added
+// to the manifest of the target release, each in a new revision, and the
+// labels and annotations recording their ownership are rewritten to the target
+// release. Nothing else is changed on the live objects. If the ownership of
+// any resource cannot be rewritten, the ownership of the resources already
+// transferred is restored and the new revisions are removed.
+func (t *ReleaseTransfer) Run(source, target string) (*release.Release, *release.Release, error) {
+	if err := t.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, nil, err
+	}
+	for _, name := range []string{source, target} {
+		if err := chartutil.ValidateReleaseName(name); err != nil {
+			return nil, nil, errors.Errorf("release name is invalid: %s", name)
+		}
+	}
+	if source == target {
+		return nil, nil, errors.New("source and target releases must differ")
+	}
+	if len(t.Resources) == 0 {
+		return nil, nil, errors.New("no resources selected to transfer")
+	}
+	kubeClient, ok := t.cfg.KubeClient.(kube.InterfaceTransfer)
+	if !ok {
+		return nil, nil, errors.Errorf("kubernetes client %T does not support transferring resources", t.cfg.KubeClient)
+	}
+
+	from, err := t.deployedRelease(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := t.deployedRelease(target)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	moved, remaining, err := t.splitManifest(from.Manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	nextFrom := nextTransferRevision(from, remaining,
+		fmt.Sprintf("Transferred %d resource(s) to release %s", len(t.Resources), target))
+	nextTo := nextTransferRevision(to, strings.TrimSuffix(to.Manifest, "\n")+"\n"+moved,
+		fmt.Sprintf("Received %d resource(s) from release %s", len(t.Resources), source))
+
+	if t.DryRun {
+		t.cfg.Log("dry run for transfer from %s to %s", source, target)
+		nextFrom.Info.Description = "Dry run complete"
+		nextTo.Info.Description = "Dry run complete"
+		return nextFrom, nextTo, nil
+	}
+
+	resources, err := t.cfg.KubeClient.Build(strings.NewReader(moved), false)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to build kubernetes objects to transfer")
+	}
+
+	// Storing the pending revisions locks both releases against concurrent
+	// operations while the ownership of the resources is rewritten.
+	if err := t.cfg.Releases.Create(nextFrom); err != nil {
+		return nil, nil, err
+	}
+	if err := t.cfg.Releases.Create(nextTo); err != nil {
+		removeRevisions(t.cfg, t.cfg.Releases, nextFrom)
+		return nil, nil, err
+	}
+
+	t.cfg.Log("transferring %d resource(s) from release %s to release %s", len(resources), source, target)
+	if res, err := kubeClient.Transfer(resources, to.Name, to.Namespace); err != nil {
+		if res != nil && len(res.Updated) > 0 {
+			if _, rerr := kubeClient.Transfer(res.Updated, from.Name, from.Namespace); rerr != nil {
+				err = errors.Wrapf(err, "failed to restore the ownership of transferred resources: %s", rerr)
+			}
+		}
+		removeRevisions(t.cfg, t.cfg.Releases, nextFrom, nextTo)
+		return nil, nil, errors.Wrap(err, "transfer failed")
+	}
+
+	from.Info.Status = release.StatusSuperseded
+	to.Info.Status = release.StatusSuperseded
+	nextFrom.SetStatus(release.StatusDeployed, nextFrom.Info.Description)
+	nextTo.SetStatus(release.StatusDeployed, nextTo.Info.Description)
+	for _, r := range []*release.Release{from, to, nextFrom, nextTo} {
+		t.cfg.recordRelease(r)
+	}
+	return nextFrom, nextTo, nil
+}
+
+// deployedRelease returns the last revision of a release, which must be
+// deployed.
+func (t *ReleaseTransfer) deployedRelease(name string) (*release.Release, error) {
+	rel, err := t.cfg.Releases.Last(name)
+	if err != nil {
+		return nil, err
+	}
+	if rel.Info.Status.IsPending() {
+		return nil, pendingError(rel)
+	}
+	if rel.Info.Status != release.StatusDeployed {
+		return nil, errors.Errorf("release %q has status %s, only deployed releases can transfer resources", name, rel.Info.Status)
+	}
+	return rel, nil
+}
+
+// splitManifest splits a release manifest into the manifests of the selected
+// resources and the remaining manifests. Every selector must match a
+// resource.
+func (t *ReleaseTransfer) splitManifest(manifest string) (moved, remaining string, err error) {
+	selected := map[string]bool{}
+	for _, r := range t.Resources {
+		kind, name, ok := splitResourceSelector(r)
+		if !ok {
+			return "", "", errors.Errorf("invalid resource %q, must be KIND/NAME", r)
+		}
+		selected[kind+"/"+name] = false
+	}
+
+	files := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(files))
+	for k := range files {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var movedDocs, remainingDocs strings.Builder
+	for _, k := range keys {
+		doc := "---\n" + files[k] + "\n"
+		var head releaseutil.SimpleHead
+		if err := yaml.Unmarshal([]byte(files[k]), &head); err != nil {
+			return "", "", errors.Wrapf(err, "YAML parse error on %s", k)
+		}
+		if head.Metadata != nil {
+			key := strings.ToLower(head.Kind) + "/" + head.Metadata.Name
+			if _, ok := selected[key]; ok {
+				selected[key] = true
+				movedDocs.WriteString(doc)
+				continue
+			}
+		}
+		remainingDocs.WriteString(doc)
+	}
+
+	for _, r := range t.Resources {
+		kind, name, _ := splitResourceSelector(r)
+		if !selected[kind+"/"+name] {
+			return "", "", errors.Errorf("resource %q not found in the release manifest", r)
+		}
+	}
+	return movedDocs.String(), remainingDocs.String(), nil
+}
+
+// removeRevisions removes revisions stored by an unsuccessful operation.
+func removeRevisions(cfg *Configuration, store *storage.Storage, rels ...*release.Release) {
+	for _, r := range rels {
+		if _, err := store.Delete(r.Name, r.Version); err != nil {
+			cfg.Log("warning: failed to remove revision %d of release %s: %s", r.Version, r.Name, err)
+		}
+	}
+}
+
+// splitResourceSelector splits a KIND/NAME selector, lowercasing the kind.
+func splitResourceSelector(s string) (kind, name string, ok bool) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return strings.ToLower(parts[0]), parts[1], true
+}
+
+// nextTransferRevision returns the pending revision following rel with the
+// given manifest.
+func nextTransferRevision(rel *release.Release, manifest, description string) *release.Release {
+	next := *rel
+	next.Version = rel.Version + 1
+	next.Manifest = manifest
+	next.Info = &release.Info{
+		FirstDeployed: rel.Info.FirstDeployed,
+		LastDeployed:  Timestamper(),
+		Status:        release.StatusPendingUpgrade,
+		Description:   description,
+		Notes:         rel.Info.Notes,
+	}
+	return &next
+}
@@ -0,0 +1,146 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+const transferSourceManifest = `---
+# Source: old/templates/settings.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+---
+# Source: old/templates/web.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`
+
+const transferTargetManifest = `---
+# Source: new/templates/api.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+`
+
+func transferAction(t *testing.T) *ReleaseTransfer {
+	t.Helper()
+	client := NewReleaseTransfer(actionConfigFixture(t))
+	from := namedReleaseStub("old", release.StatusDeployed)
+	from.Manifest = transferSourceManifest
+	to := namedReleaseStub("new", release.StatusDeployed)
+	to.Manifest = transferTargetManifest
+	require.NoError(t, client.cfg.Releases.Create(from))
+	require.NoError(t, client.cfg.Releases.Create(to))
+	return client
+}
+
+func TestReleaseTransfer(t *testing.T) {
+	is := assert.New(t)
+	client := transferAction(t)
+	client.Resources = []string{"configmap/settings"}
+
+	from, to, err := client.Run("old", "new")
+	is.NoError(err)
+	is.Equal(2, from.Version)
+	is.Equal(2, to.Version)
+	is.Equal(release.StatusDeployed, from.Info.Status)
+	is.Equal("Transferred 1 resource(s) to release new", from.Info.Description)
+	is.Equal("---\n# Source: old/templates/web.yaml\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n", from.Manifest)
+	is.Equal(transferTargetManifest+"---\n# Source: old/templates/settings.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n", to.Manifest)
+
+	for _, name := range []string{"old", "new"} {
+		previous, err := client.cfg.Releases.Get(name, 1)
+		is.NoError(err)
+		is.Equal(release.StatusSuperseded, previous.Info.Status)
+		last, err := client.cfg.Releases.Last(name)
+		is.NoError(err)
+		is.Equal(release.StatusDeployed, last.Info.Status)
+	}
+}
+
+func TestReleaseTransferDryRun(t *testing.T) {
+	is := assert.New(t)
+	client := transferAction(t)
+	client.Resources = []string{"Deployment/web"}
+	client.DryRun = true
+
+	from, to, err := client.Run("old", "new")
+	is.NoError(err)
+	is.Equal(2, from.Version)
+	is.Contains(to.Manifest, "name: web")
+
+	last, err := client.cfg.Releases.Last("old")
+	is.NoError(err)
+	is.Equal(1, last.Version)
+}
+
+func TestReleaseTransferInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		resources []string
+		target    string
+		err       string
+	}{
+		{"no resources", nil, "new", "no resources selected to transfer"},
+		{"same release", []string{"ConfigMap/settings"}, "old", "source and target releases must differ"},
+		{"invalid selector", []string{"settings"}, "new", `invalid resource "settings", must be KIND/NAME`},
+		{"missing resource", []string{"Secret/settings"}, "new", `resource "Secret/settings" not found in the release manifest`},
+		{"missing release", []string{"ConfigMap/settings"}, "nope", "release: not found"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client := transferAction(t)
+			client.Resources = tt.resources
+			_, _, err := client.Run("old", tt.target)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestReleaseTransferFailure(t *testing.T) {
+	is := assert.New(t)
+	client := transferAction(t)
+	client.Resources = []string{"ConfigMap/settings"}
+	client.cfg.KubeClient = &kubefake.FailingKubeClient{
+		PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard},
+		TransferError:      errors.New("forbidden"),
+	}
+
+	_, _, err := client.Run("old", "new")
+	is.EqualError(err, "transfer failed: forbidden")
+
+	// The releases are left as they were
+	for _, name := range []string{"old", "new"} {
+		last, err := client.cfg.Releases.Last(name)
+		is.NoError(err)
+		is.Equal(1, last.Version)
+		is.Equal(release.StatusDeployed, last.Info.Status)
+	}
+}
@@ -17,30 +17,28 @@ limitations under the License.
 package action
 
 import (
-	"strings"
-
 	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/releaseutil"
 )
 
-func filterManifestsToKeep(manifests []releaseutil.Manifest) (keep, remaining []releaseutil.Manifest) {
+// filterManifestsToKeep splits the manifests of a release being uninstalled
+// according to their resource policies: the manifests to keep, the manifests
+// to orphan, and the remaining manifests to delete.
+func filterManifestsToKeep(manifests []releaseutil.Manifest) (keep, orphan, remaining []releaseutil.Manifest) {
 	for _, m := range manifests {
-		if m.Head.Metadata == nil || m.Head.Metadata.Annotations == nil || len(m.Head.Metadata.Annotations) == 0 {
-			remaining = append(remaining, m)
-			continue
+		var annotations map[string]string
+		if m.Head.Metadata != nil {
+			annotations = m.Head.Metadata.Annotations
 		}
 
-		resourcePolicyType, ok := m.Head.Metadata.Annotations[kube.ResourcePolicyAnno]
-		if !ok {
-			remaining = append(remaining, m)
-			continue
-		}
-
-		resourcePolicyType = strings.ToLower(strings.TrimSpace(resourcePolicyType))
-		if resourcePolicyType == kube.KeepPolicy {
+		switch {
+		case kube.HasResourcePolicy(annotations, kube.KeepPolicy):
 			keep = append(keep, m)
+		case kube.HasResourcePolicy(annotations, kube.OrphanAndStripPolicy):
+			orphan = append(orphan, m)
+		default:
+			remaining = append(remaining, m)
 		}
-
 	}
-	return keep, remaining
+	return keep, orphan, remaining
 }
@@ -18,6 +18,7 @@ package action
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"github.com/pkg/errors"
 
 	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/release"
 	helmtime "helm.sh/helm/v3/pkg/time"
 )
@@ -45,6 +47,15 @@ type Rollback struct {
 	Force         bool // will (if true) force resource upgrade through uninstall/recreate if needed
 	CleanupOnFail bool
 	MaxHistory    int // MaxHistory limits the maximum number of revisions saved per release
+	// ServerSideApply updates resources using server-side apply instead of a
+	// client-side three-way merge patch.
+	ServerSideApply bool
+	// ForceConflicts takes ownership of fields managed by other field managers
+	// when ServerSideApply is set.
+	ForceConflicts bool
+	// WaitProgress receives the readiness of the resources while waiting for
+	// them to be ready, if the Kubernetes client supports reporting it.
+	WaitProgress kube.WaitProgressFunc
 }
 
 // NewRollback creates a new Rollback object with the given configuration.
@@ -56,10 +67,21 @@ func NewRollback(cfg *Configuration) *Rollback {
 
 // Run executes 'helm rollback' against the given release.
 func (r *Rollback) Run(name string) error {
+	return r.RunWithContext(context.Background(), name)
+}
+
+// RunWithContext executes 'helm rollback' against the given release like Run.
+// If the context is cancelled while waiting for hooks or resources, the
+// rolled back release is marked as failed.
+func (r *Rollback) RunWithContext(ctx context.Context, name string) error {
 	if err := r.cfg.KubeClient.IsReachable(); err != nil {
 		return err
 	}
 
+	if err := validateServerSideApply(r.ServerSideApply, r.Force, r.ForceConflicts); err != nil {
+		return err
+	}
+
 	r.cfg.Releases.MaxHistory = r.MaxHistory
 
 	r.cfg.Log("preparing rollback of %s", name)
@@ -76,7 +98,7 @@ func (r *Rollback) Run(name string) error {
 	}
 
 	r.cfg.Log("performing rollback of %s", name)
-	if _, err := r.performRollback(currentRelease, targetRelease); err != nil {
+	if _, err := r.performRollback(ctx, currentRelease, targetRelease); err != nil {
 		return err
 	}
 
@@ -136,11 +158,12 @@ func (r *Rollback) prepareRollback(name string) (*release.Release, *release.Rele
 		Manifest: previousRelease.Manifest,
 		Hooks:    previousRelease.Hooks,
 	}
+	r.cfg.leasePending(targetRelease, r.Timeout)
 
 	return currentRelease, targetRelease, nil
 }
 
-func (r *Rollback) performRollback(currentRelease, targetRelease *release.Release) (*release.Release, error) {
+func (r *Rollback) performRollback(ctx context.Context, currentRelease, targetRelease *release.Release) (*release.Release, error) {
 	if r.DryRun {
 		r.cfg.Log("dry run for %s", targetRelease.Name)
 		return targetRelease, nil
@@ -157,14 +180,15 @@ func (r *Rollback) performRollback(currentRelease, targetRelease *release.Releas
 
 	// pre-rollback hooks
 	if !r.DisableHooks {
-		if err := r.cfg.execHook(targetRelease, release.HookPreRollback, r.Timeout); err != nil {
+		if err := r.cfg.execHook(ctx, targetRelease, release.HookPreRollback, r.Timeout); err != nil {
+			r.failRelease(currentRelease, targetRelease, err)
 			return targetRelease, err
 		}
 	} else {
 		r.cfg.Log("rollback hooks disabled for %s", targetRelease.Name)
 	}
 
-	results, err := r.cfg.KubeClient.Update(current, target, r.Force)
+	results, err := r.cfg.updateResources(ctx, current, target, r.Force, r.ServerSideApply, r.ForceConflicts, r.Timeout)
 
 	if err != nil {
 		msg := fmt.Sprintf("Rollback %q failed: %s", targetRelease.Name, err)
@@ -200,26 +224,16 @@ func (r *Rollback) performRollback(currentRelease, targetRelease *release.Releas
 	}
 
 	if r.Wait {
-		if r.WaitForJobs {
-			if err := r.cfg.KubeClient.WaitWithJobs(target, r.Timeout); err != nil {
-				targetRelease.SetStatus(release.StatusFailed, fmt.Sprintf("Release %q failed: %s", targetRelease.Name, err.Error()))
-				r.cfg.recordRelease(currentRelease)
-				r.cfg.recordRelease(targetRelease)
-				return targetRelease, errors.Wrapf(err, "release %s failed", targetRelease.Name)
-			}
-		} else {
-			if err := r.cfg.KubeClient.Wait(target, r.Timeout); err != nil {
-				targetRelease.SetStatus(release.StatusFailed, fmt.Sprintf("Release %q failed: %s", targetRelease.Name, err.Error()))
-				r.cfg.recordRelease(currentRelease)
-				r.cfg.recordRelease(targetRelease)
-				return targetRelease, errors.Wrapf(err, "release %s failed", targetRelease.Name)
-			}
+		if err := r.cfg.waitForResources(ctx, target, r.Timeout, r.WaitForJobs, r.WaitProgress); err != nil {
+			r.failRelease(currentRelease, targetRelease, err)
+			return targetRelease, errors.Wrapf(err, "release %s failed", targetRelease.Name)
 		}
 	}
 
 	// post-rollback hooks
 	if !r.DisableHooks {
-		if err := r.cfg.execHook(targetRelease, release.HookPostRollback, r.Timeout); err != nil {
+		if err := r.cfg.execHook(ctx, targetRelease, release.HookPostRollback, r.Timeout); err != nil {
+			r.failRelease(currentRelease, targetRelease, err)
 			return targetRelease, err
 		}
 	}
@@ -239,3 +253,11 @@ func (r *Rollback) performRollback(currentRelease, targetRelease *release.Releas
 
 	return targetRelease, nil
 }
+
+// failRelease marks the rolled back release as failed so that it does not
+// remain pending.
+func (r *Rollback) failRelease(currentRelease, targetRelease *release.Release, err error) {
+	targetRelease.SetStatus(release.StatusFailed, fmt.Sprintf("Release %q failed: %s", targetRelease.Name, err.Error()))
+	r.cfg.recordRelease(currentRelease)
+	r.cfg.recordRelease(targetRelease)
+}
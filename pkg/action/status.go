@@ -17,9 +17,44 @@ limitations under the License.
 package action
 
 import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+
+	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+// ResourceReadiness is the live state of a resource of a release.
+type ResourceReadiness string
+
+const (
+	// ResourceReady indicates a resource that exists and is ready.
+	ResourceReady ResourceReadiness = "ready"
+	// ResourceNotReady indicates a resource that exists but is not ready yet.
+	ResourceNotReady ResourceReadiness = "not-ready"
+	// ResourceMissing indicates a resource of the manifest that does not exist
+	// in the cluster.
+	ResourceMissing ResourceReadiness = "missing"
 )
 
+// ResourceStatus describes the live state of a resource of a release.
+type ResourceStatus struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Namespace  string            `json:"namespace,omitempty"`
+	Name       string            `json:"name"`
+	Status     ResourceReadiness `json:"status"`
+	// Reasons explain why a resource is not ready, such as the image pull
+	// errors and crash loops of its pods.
+	Reasons []string `json:"reasons,omitempty"`
+}
+
 // Status is the action for checking the deployment status of releases.
 //
 // It provides the implementation of 'helm status'.
@@ -49,3 +84,70 @@ func (s *Status) Run(name string) (*release.Release, error) {
 
 	return s.cfg.releaseContent(name, s.Version)
 }
+
+// Resources returns the live state of every resource of the manifest of the
+// given release, sorted by kind, namespace and name.
+//
+// Readiness is checked like 'helm install --wait' does, except that jobs are
+// ready once complete. The reasons why pods are not ready are reported for
+// pods and the resources managing them.
+func (s *Status) Resources(rel *release.Release) ([]ResourceStatus, error) {
+	if len(releaseutil.SplitManifests(rel.Manifest)) == 0 {
+		return []ResourceStatus{}, nil
+	}
+	resources, err := s.cfg.KubeClient.Build(bytes.NewBufferString(rel.Manifest), false)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to build kubernetes objects from release manifest")
+	}
+	client, err := s.cfg.KubernetesClientSet()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get kubernetes client to check readiness")
+	}
+	return s.resourceStatuses(context.Background(), client, resources)
+}
+
+func (s *Status) resourceStatuses(ctx context.Context, client kubernetes.Interface, resources kube.ResourceList) ([]ResourceStatus, error) {
+	checker := kube.NewReadyChecker(client, s.cfg.Log, kube.CheckJobs(true))
+	statuses := make([]ResourceStatus, 0, len(resources))
+	for _, info := range resources {
+		gvk := info.Object.GetObjectKind().GroupVersionKind()
+		status := ResourceStatus{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Namespace:  info.Namespace,
+			Name:       info.Name,
+			Status:     ResourceReady,
+		}
+		if err := info.Get(); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "unable to get %s %q", gvk.Kind, info.Name)
+			}
+			status.Status = ResourceMissing
+			statuses = append(statuses, status)
+			continue
+		}
+		ready, err := checker.IsReady(ctx, info)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to check readiness of %s %q", gvk.Kind, info.Name)
+		}
+		if !ready {
+			status.Status = ResourceNotReady
+			if status.Reasons, err = checker.NotReadyReasons(ctx, info); err != nil {
+				return nil, errors.Wrapf(err, "unable to get the pods of %s %q", gvk.Kind, info.Name)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		a, b := statuses[i], statuses[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return statuses, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const statusResourcesManifest = `---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: nginx:nope
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: token
+`
+
+func TestStatusResources(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	kubeClient := &driftKubeClient{
+		live: map[string]string{
+			"web":      `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"web","namespace":"default"}}`,
+			"settings": `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"settings","namespace":"default"}}`,
+		},
+	}
+	config.KubeClient = kubeClient
+	resources, err := kubeClient.Build(bytes.NewBufferString(statusResourcesManifest), false)
+	require.NoError(t, err)
+
+	client := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "web",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull", Message: "manifest unknown"}},
+			}},
+		},
+	})
+	statuses, err := NewStatus(config).resourceStatuses(context.Background(), client, resources)
+	is.NoError(err)
+	is.Equal([]ResourceStatus{{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Namespace:  "default",
+		Name:       "settings",
+		Status:     ResourceReady,
+	}, {
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Namespace:  "default",
+		Name:       "web",
+		Status:     ResourceNotReady,
+		Reasons:    []string{"container web: ErrImagePull: manifest unknown"},
+	}, {
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Namespace:  "default",
+		Name:       "token",
+		Status:     ResourceMissing,
+	}}, statuses)
+}
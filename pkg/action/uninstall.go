@@ -17,12 +17,15 @@ limitations under the License.
 package action
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 
 	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/releaseutil"
 	helmtime "helm.sh/helm/v3/pkg/time"
@@ -50,6 +53,12 @@ func NewUninstall(cfg *Configuration) *Uninstall {
 
 // Run uninstalls the given release.
 func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error) {
+	return u.RunWithContext(context.Background(), name)
+}
+
+// RunWithContext uninstalls the given release like Run, cancelling the
+// pre-delete and post-delete hooks if the context is cancelled.
+func (u *Uninstall) RunWithContext(ctx context.Context, name string) (*release.UninstallReleaseResponse, error) {
 	if err := u.cfg.KubeClient.IsReachable(); err != nil {
 		return nil, err
 	}
@@ -94,10 +103,14 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 	rel.Info.Status = release.StatusUninstalling
 	rel.Info.Deleted = helmtime.Now()
 	rel.Info.Description = "Deletion in progress (or silently failed)"
+	u.cfg.leasePending(rel, u.Timeout)
 	res := &release.UninstallReleaseResponse{Release: rel}
 
 	if !u.DisableHooks {
-		if err := u.cfg.execHook(rel, release.HookPreDelete, u.Timeout); err != nil {
+		if err := u.cfg.execHook(ctx, rel, release.HookPreDelete, u.Timeout); err != nil {
+			if errors.Is(err, context.Canceled) {
+				u.failRelease(rel, err)
+			}
 			return res, err
 		}
 	} else {
@@ -118,7 +131,7 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 	res.Info = kept
 
 	if !u.DisableHooks {
-		if err := u.cfg.execHook(rel, release.HookPostDelete, u.Timeout); err != nil {
+		if err := u.cfg.execHook(ctx, rel, release.HookPostDelete, u.Timeout); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -155,13 +168,20 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 	return res, nil
 }
 
+// failRelease records a release whose uninstall was cancelled as failed, so
+// that it is not left uninstalling.
+func (u *Uninstall) failRelease(rel *release.Release, err error) {
+	rel.SetStatus(release.StatusFailed, fmt.Sprintf("Release %q failed: %s", rel.Name, err.Error()))
+	u.cfg.recordRelease(rel)
+}
+
 func (u *Uninstall) purgeReleases(rels ...*release.Release) error {
 	for _, rel := range rels {
 		if _, err := u.cfg.Releases.Delete(rel.Name, rel.Version); err != nil {
 			return err
 		}
 	}
-	return nil
+	return u.cfg.Releases.PruneCharts()
 }
 
 func joinErrors(errs []error) string {
@@ -190,11 +210,25 @@ func (u *Uninstall) deleteRelease(rel *release.Release) (string, []error) {
 		return rel.Manifest, []error{errors.Wrap(err, "corrupted release record. You must manually delete the resources")}
 	}
 
-	filesToKeep, filesToDelete := filterManifestsToKeep(files)
+	filesToKeep, filesToOrphan, filesToDelete := filterManifestsToKeep(files)
+	var orphaned []releaseutil.Manifest
+	if len(filesToOrphan) > 0 {
+		if kubeClient, ok := u.cfg.KubeClient.(kube.InterfaceOrphan); ok {
+			var orphanErrs []error
+			orphaned, orphanErrs = u.orphanResources(kubeClient, filesToOrphan)
+			errs = append(errs, orphanErrs...)
+		} else {
+			u.cfg.Log("uninstall: the kube client cannot orphan resources, keeping them instead")
+			filesToKeep = append(filesToKeep, filesToOrphan...)
+		}
+	}
 	var kept string
 	for _, f := range filesToKeep {
 		kept += "[" + f.Head.Kind + "] " + f.Head.Metadata.Name + "\n"
 	}
+	for _, f := range orphaned {
+		kept += "[" + f.Head.Kind + "] " + f.Head.Metadata.Name + " (orphaned)\n"
+	}
 
 	var builder strings.Builder
 	for _, file := range filesToDelete {
@@ -206,7 +240,27 @@ func (u *Uninstall) deleteRelease(rel *release.Release) (string, []error) {
 		return "", []error{errors.Wrap(err, "unable to build kubernetes objects for delete")}
 	}
 	if len(resources) > 0 {
-		_, errs = u.cfg.KubeClient.Delete(resources)
+		_, deleteErrs := u.cfg.KubeClient.Delete(resources)
+		errs = append(errs, deleteErrs...)
 	}
 	return kept, errs
 }
+
+// orphanResources strips the ownership of the resources of the given manifests,
+// returning the manifests of the resources no longer owned by the release.
+func (u *Uninstall) orphanResources(kubeClient kube.InterfaceOrphan, manifests []releaseutil.Manifest) ([]releaseutil.Manifest, []error) {
+	var builder strings.Builder
+	for _, m := range manifests {
+		builder.WriteString("\n---\n" + m.Content)
+	}
+	resources, err := u.cfg.KubeClient.Build(strings.NewReader(builder.String()), false)
+	if err != nil {
+		return nil, []error{errors.Wrap(err, "unable to build kubernetes objects to orphan")}
+	}
+	if len(resources) > 0 {
+		if _, errs := kubeClient.Orphan(resources); len(errs) > 0 {
+			return nil, errs
+		}
+	}
+	return manifests, nil
+}
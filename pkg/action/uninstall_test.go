@@ -17,9 +17,13 @@ limitations under the License.
 package action
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
 )
 
 func uninstallAction(t *testing.T) *Uninstall {
@@ -60,3 +64,85 @@ func TestUninstallRelease_deleteRelease(t *testing.T) {
 `
 	is.Contains(res.Info, expected)
 }
+
+func TestUninstallRelease_orphanAndStrip(t *testing.T) {
+	is := assert.New(t)
+
+	unAction := uninstallAction(t)
+	unAction.DisableHooks = true
+	unAction.DryRun = false
+	unAction.KeepHistory = true
+
+	rel := releaseStub()
+	rel.Name = "orphan-secret"
+	rel.Manifest = `{
+		"apiVersion": "v1",
+		"kind": "Secret",
+		"metadata": {
+		  "name": "secret",
+		  "annotations": {
+			"helm.sh/resource-policy": "orphan-and-strip"
+		  }
+		},
+		"type": "Opaque"
+	}`
+	unAction.cfg.Releases.Create(rel)
+	res, err := unAction.Run(rel.Name)
+	is.NoError(err)
+	expected := `These resources were kept due to the resource policy:
+[Secret] secret (orphaned)
+`
+	is.Contains(res.Info, expected)
+}
+
+func TestUninstallRelease_orphanAndStripUnsupported(t *testing.T) {
+	is := assert.New(t)
+
+	unAction := uninstallAction(t)
+	unAction.DisableHooks = true
+	unAction.DryRun = false
+	unAction.KeepHistory = true
+	// the kube client cannot strip the ownership of resources
+	unAction.cfg.KubeClient = struct{ kube.Interface }{unAction.cfg.KubeClient}
+
+	rel := releaseStub()
+	rel.Name = "orphan-secret"
+	rel.Manifest = `{
+		"apiVersion": "v1",
+		"kind": "Secret",
+		"metadata": {
+		  "name": "secret",
+		  "annotations": {
+			"helm.sh/resource-policy": "orphan-and-strip"
+		  }
+		},
+		"type": "Opaque"
+	}`
+	unAction.cfg.Releases.Create(rel)
+	res, err := unAction.Run(rel.Name)
+	is.NoError(err)
+	expected := `These resources were kept due to the resource policy:
+[Secret] secret
+`
+	is.Contains(res.Info, expected)
+	is.NotContains(res.Info, "(orphaned)")
+}
+
+func TestUninstallRelease_cancelledPreDeleteHook(t *testing.T) {
+	is := assert.New(t)
+
+	unAction := uninstallAction(t)
+	rel := releaseStub()
+	rel.Name = "cancelled"
+	unAction.cfg.Releases.Create(rel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := unAction.RunWithContext(ctx, rel.Name)
+	is.ErrorIs(err, context.Canceled)
+
+	got, err := unAction.cfg.Releases.Get(rel.Name, rel.Version)
+	is.NoError(err)
+	is.Equal(release.StatusFailed, got.Info.Status)
+	is.Contains(got.Info.Description, "context canceled")
+}
@@ -0,0 +1,52 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// Unlock is the action for recovering a release stuck in a pending state.
+//
+// It provides the implementation of 'helm release unlock'.
+type Unlock struct {
+	cfg *Configuration
+
+	// Force unlocks the release even if the lease on it has not expired.
+	Force bool
+}
+
+// NewUnlock creates a new Unlock object with the given configuration.
+func NewUnlock(cfg *Configuration) *Unlock {
+	return &Unlock{
+		cfg: cfg,
+	}
+}
+
+// Run marks the pending last revision of the given release as failed, so that
+// it can be upgraded or rolled back again.
+func (u *Unlock) Run(name string) (*release.Release, error) {
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, errors.Errorf("release name is invalid: %s", name)
+	}
+
+	u.cfg.Log("unlocking release %s", name)
+	return u.cfg.Releases.Unlock(name, u.Force)
+}
@@ -17,14 +17,20 @@ limitations under the License.
 package action
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"testing"
+	gotime "time"
+
+	"github.com/pkg/errors"
 
 	"helm.sh/helm/v3/pkg/chart"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"helm.sh/helm/v3/pkg/kube"
 	kubefake "helm.sh/helm/v3/pkg/kube/fake"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/time"
@@ -296,3 +302,154 @@ func TestUpgradeRelease_Pending(t *testing.T) {
 	_, err := upAction.Run(rel.Name, buildChart(), vals)
 	req.Contains(err.Error(), "progress", err)
 }
+
+func TestUpgradeRelease_ServerSideApply(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "server-side"
+	rel.Info.Status = release.StatusDeployed
+	upAction.cfg.Releases.Create(rel)
+
+	upAction.ServerSideApply = true
+	upAction.ForceConflicts = true
+	res, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.NoError(err)
+	is.Equal(release.StatusDeployed, res.Info.Status)
+
+	upAction.Force = true
+	_, err = upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	is.Equal(errServerSideForce, err)
+
+	upAction.Force = false
+	upAction.ServerSideApply = false
+	_, err = upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	is.Equal(errForceConflicts, err)
+}
+
+// updateTimeoutKubeClient records the timeouts updates are given.
+type updateTimeoutKubeClient struct {
+	kubefake.PrintingKubeClient
+	timeouts []gotime.Duration
+}
+
+func (c *updateTimeoutKubeClient) UpdateWithContext(ctx context.Context, original, target kube.ResourceList, force bool, timeout gotime.Duration) (*kube.Result, error) {
+	c.timeouts = append(c.timeouts, timeout)
+	return c.PrintingKubeClient.UpdateWithContext(ctx, original, target, force, timeout)
+}
+
+func (c *updateTimeoutKubeClient) UpdateServerSideWithContext(ctx context.Context, original, target kube.ResourceList, forceConflicts bool, timeout gotime.Duration) (*kube.Result, error) {
+	c.timeouts = append(c.timeouts, timeout)
+	return c.PrintingKubeClient.UpdateServerSideWithContext(ctx, original, target, forceConflicts, timeout)
+}
+
+func TestUpgradeRelease_UpdateTimeout(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	kubeClient := &updateTimeoutKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}}
+	upAction.cfg.KubeClient = kubeClient
+	rel := releaseStub()
+	rel.Name = "recreated"
+	rel.Info.Status = release.StatusDeployed
+	upAction.cfg.Releases.Create(rel)
+
+	upAction.Timeout = 7 * gotime.Minute
+	_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.NoError(err)
+
+	upAction.ServerSideApply = true
+	_, err = upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.NoError(err)
+	is.Equal([]gotime.Duration{upAction.Timeout, upAction.Timeout}, kubeClient.timeouts)
+}
+
+func TestUpgradeRelease_Cancelled(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "interrupted"
+	rel.Info.Status = release.StatusDeployed
+	upAction.cfg.Releases.Create(rel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	upAction.Wait = true
+	res, err := upAction.RunWithContext(ctx, rel.Name, buildChart(), map[string]interface{}{})
+	req.Error(err)
+	is.True(errors.Is(err, context.Canceled))
+	is.Equal(release.StatusFailed, res.Info.Status)
+
+	stored, err := upAction.cfg.Releases.Get(rel.Name, res.Version)
+	req.NoError(err)
+	is.Equal(release.StatusFailed, stored.Info.Status, "expected the release not to remain pending")
+}
+
+func TestUpgradeRelease_ExpiredLease(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "interrupted"
+	rel.Info.Status = release.StatusPendingUpgrade
+	upAction.cfg.Releases.Create(rel)
+
+	// A lease that is still held refuses the upgrade.
+	_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.Error(err)
+	is.Equal(errPending, err)
+
+	// An expired lease refuses the upgrade and explains how to recover.
+	rel.Info.Lease.AcquiredAt = rel.Info.Lease.AcquiredAt.Add(-2 * rel.Info.Lease.TTL)
+	req.NoError(upAction.cfg.Releases.Driver.Update("sh.helm.release.v1.interrupted.v1", rel))
+	_, err = upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.Error(err)
+	is.Contains(err.Error(), "helm release unlock interrupted")
+
+	_, err = NewUnlock(upAction.cfg).Run(rel.Name)
+	req.NoError(err)
+	res, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.NoError(err)
+	is.Equal(release.StatusDeployed, res.Info.Status)
+}
+
+func TestUpgradeRelease_LeaseTTL(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "leased"
+	rel.Info.Status = release.StatusDeployed
+	upAction.cfg.Releases.Create(rel)
+
+	upAction.Timeout = 10 * gotime.Minute
+	_, upgraded, err := upAction.prepareUpgrade(rel.Name, buildChart(), map[string]interface{}{})
+	req.NoError(err)
+	req.NotNil(upgraded.Info.Lease)
+	is.Equal(upAction.Timeout+leaseGrace, upgraded.Info.Lease.TTL)
+}
+
+func TestUpgradeRelease_LeaselessPending(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "leaseless"
+	rel.Info.Status = release.StatusPendingUpgrade
+	upAction.cfg.Releases.Create(rel)
+
+	// Revisions written by clients that predate leases hold none.
+	rel.Info.Lease = nil
+	req.NoError(upAction.cfg.Releases.Driver.Update("sh.helm.release.v1.leaseless.v1", rel))
+	_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.Error(err)
+	is.Contains(err.Error(), "run 'helm release unlock leaseless'")
+}
@@ -26,6 +26,7 @@ import (
 	"k8s.io/cli-runtime/pkg/resource"
 
 	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
 )
 
 var accessor = meta.NewAccessor()
@@ -37,10 +38,12 @@ const (
 	helmReleaseNamespaceAnnotation = "meta.helm.sh/release-namespace"
 )
 
-func existingResourceConflict(resources kube.ResourceList, releaseName, releaseNamespace string) (kube.ResourceList, error) {
-	var requireUpdate kube.ResourceList
-
-	err := resources.Visit(func(info *resource.Info, err error) error {
+// existingResourceConflict returns the resources that already exist and must be
+// updated rather than created. If adopt is set, existing resources not owned
+// by any release are taken over and returned as adopted too; only resources
+// owned by a different release are refused.
+func existingResourceConflict(resources kube.ResourceList, releaseName, releaseNamespace string, adopt bool) (requireUpdate, adopted kube.ResourceList, err error) {
+	err = resources.Visit(func(info *resource.Info, err error) error {
 		if err != nil {
 			return err
 		}
@@ -56,14 +59,51 @@ func existingResourceConflict(resources kube.ResourceList, releaseName, releaseN
 
 		// Allow adoption of the resource if it is managed by Helm and is annotated with correct release name and namespace.
 		if err := checkOwnership(existing, releaseName, releaseNamespace); err != nil {
-			return fmt.Errorf("%s exists and cannot be imported into the current release: %s", resourceString(info), err)
+			if !adopt {
+				return fmt.Errorf("%s exists and cannot be imported into the current release: %s", resourceString(info), err)
+			}
+			if err := checkUnowned(existing); err != nil {
+				return fmt.Errorf("%s exists and cannot be adopted into the current release: %s", resourceString(info), err)
+			}
+			adopted.Append(info)
 		}
 
 		requireUpdate.Append(info)
 		return nil
 	})
 
-	return requireUpdate, err
+	return requireUpdate, adopted, err
+}
+
+// checkUnowned returns an error if the object is owned by a Helm release.
+// Objects whose ownership metadata names the adopting release pass
+// checkOwnership and are never checked here.
+func checkUnowned(obj runtime.Object) error {
+	annos, err := accessor.Annotations(obj)
+	if err != nil {
+		return err
+	}
+	name, namespace := annos[helmReleaseNameAnnotation], annos[helmReleaseNamespaceAnnotation]
+	if name == "" && namespace == "" {
+		return nil
+	}
+	return fmt.Errorf("owned by release %q in namespace %q", name, namespace)
+}
+
+// adoptedReferences returns references to the adopted resources, to record
+// them in the release.
+func adoptedReferences(adopted kube.ResourceList) []release.ResourceReference {
+	var refs []release.ResourceReference
+	for _, info := range adopted {
+		apiVersion, kind := info.Mapping.GroupVersionKind.ToAPIVersionAndKind()
+		refs = append(refs, release.ResourceReference{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Namespace:  info.Namespace,
+			Name:       info.Name,
+		})
+	}
+	return refs
 }
 
 func checkOwnership(obj runtime.Object, releaseName, releaseNamespace string) error {
@@ -17,17 +17,27 @@ limitations under the License.
 package action
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
 	"testing"
 
 	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
 
 	appsv1 "k8s.io/api/apps/v1"
 
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest/fake"
 )
 
 func newDeploymentResource(name, namespace string) *resource.Info {
@@ -121,3 +131,80 @@ func TestSetMetadataVisitor(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), `Deployment "baz" in namespace "" cannot be owned`)
 }
+
+func TestCheckUnowned(t *testing.T) {
+	deployFoo := newDeploymentResource("foo", "ns-a")
+	assert.NoError(t, checkUnowned(deployFoo.Object))
+
+	// Resources only labeled as managed by Helm are not owned by a release
+	_ = accessor.SetLabels(deployFoo.Object, map[string]string{
+		appManagedByLabel: appManagedByHelm,
+	})
+	assert.NoError(t, checkUnowned(deployFoo.Object))
+
+	_ = accessor.SetAnnotations(deployFoo.Object, map[string]string{
+		helmReleaseNameAnnotation:      "rel-b",
+		helmReleaseNamespaceAnnotation: "ns-b",
+	})
+	assert.EqualError(t, checkUnowned(deployFoo.Object), `owned by release "rel-b" in namespace "ns-b"`)
+}
+
+func TestExistingResourceConflictAdopt(t *testing.T) {
+	existing := map[string]*appsv1.Deployment{
+		"unowned": {ObjectMeta: v1.ObjectMeta{Name: "unowned", Namespace: "ns-a"}},
+		"owned": {ObjectMeta: v1.ObjectMeta{
+			Name:        "owned",
+			Namespace:   "ns-a",
+			Labels:      map[string]string{appManagedByLabel: appManagedByHelm},
+			Annotations: map[string]string{helmReleaseNameAnnotation: "rel-a", helmReleaseNamespaceAnnotation: "ns-a"},
+		}},
+		"other": {ObjectMeta: v1.ObjectMeta{
+			Name:        "other",
+			Namespace:   "ns-a",
+			Labels:      map[string]string{appManagedByLabel: appManagedByHelm},
+			Annotations: map[string]string{helmReleaseNameAnnotation: "rel-b", helmReleaseNamespaceAnnotation: "ns-a"},
+		}},
+	}
+	client := &fake.RESTClient{
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			header := http.Header{"Content-Type": []string{runtime.ContentTypeJSON}}
+			d, ok := existing[path.Base(req.URL.Path)]
+			if !ok {
+				body := `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`
+				return &http.Response{StatusCode: http.StatusNotFound, Header: header, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+			}
+			d.APIVersion, d.Kind = "apps/v1", "Deployment"
+			body, err := json.Marshal(d)
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+		}),
+	}
+	resources := func(names ...string) kube.ResourceList {
+		var list kube.ResourceList
+		for _, name := range names {
+			info := newDeploymentResource(name, "ns-a")
+			info.Namespace = "ns-a"
+			info.Mapping.Scope = meta.RESTScopeNamespace
+			info.Client = client
+			list.Append(info)
+		}
+		return list
+	}
+
+	// Unowned resources are only accepted when adopting
+	_, _, err := existingResourceConflict(resources("unowned"), "rel-a", "ns-a", false)
+	assert.Error(t, err)
+
+	update, adopted, err := existingResourceConflict(resources("new", "unowned", "owned"), "rel-a", "ns-a", true)
+	assert.NoError(t, err)
+	assert.Len(t, update, 2)
+	assert.Len(t, adopted, 1)
+	assert.Equal(t, []release.ResourceReference{{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "ns-a", Name: "unowned"}}, adoptedReferences(adopted))
+
+	// Resources owned by another release are never adopted
+	_, _, err = existingResourceConflict(resources("other"), "rel-a", "ns-a", true)
+	assert.EqualError(t, err, `Deployment "other" in namespace "ns-a" exists and cannot be adopted into the current release: owned by release "rel-b" in namespace "ns-a"`)
+}
@@ -47,6 +47,16 @@ type Dependency struct {
 	ImportValues []interface{} `json:"import-values,omitempty"`
 	// Alias usable alias to be used for the chart
 	Alias string `json:"alias,omitempty"`
+	// Digest is the digest of the chart archive of the dependency, such as
+	// "sha256:1b2f...".
+	//
+	// It is only recorded in lock files, to verify that the archive downloaded
+	// when building the dependencies is the one that was locked.
+	Digest string `json:"digest,omitempty"`
+	// Dependencies are the resolved dependencies of the dependency.
+	//
+	// They are only recorded in lock files resolved transitively.
+	Dependencies []*Dependency `json:"dependencies,omitempty"`
 }
 
 // Validate checks for common problems with the dependency datastructure in
@@ -64,10 +64,22 @@ type EnvSettings struct {
 	RepositoryConfig string
 	// RepositoryCache is the path to the repository cache directory.
 	RepositoryCache string
+	// ContentCache is the path to the cache of the chart archives of
+	// dependencies, used to build dependencies offline.
+	ContentCache string
 	// PluginsDirectory is the path to the plugins directory.
 	PluginsDirectory string
 	// MaxHistory is the max release history maintained.
 	MaxHistory int
+	// DriverEncryptionKeyFile is the path to the key file used to encrypt
+	// release records at rest.
+	DriverEncryptionKeyFile string
+	// DriverEncryptionKMSPlugin is the path to the KMS plugin used to encrypt
+	// release records at rest.
+	DriverEncryptionKMSPlugin string
+	// DriverSeparateCharts stores the chart of each release once per chart
+	// digest instead of in every release record.
+	DriverSeparateCharts bool
 }
 
 func New() *EnvSettings {
@@ -84,8 +96,13 @@ func New() *EnvSettings {
 		RegistryConfig:   envOr("HELM_REGISTRY_CONFIG", helmpath.ConfigPath("registry.json")),
 		RepositoryConfig: envOr("HELM_REPOSITORY_CONFIG", helmpath.ConfigPath("repositories.yaml")),
 		RepositoryCache:  envOr("HELM_REPOSITORY_CACHE", helmpath.CachePath("repository")),
+		ContentCache:     envOr("HELM_CONTENT_CACHE", helmpath.CachePath("content")),
+
+		DriverEncryptionKeyFile:   os.Getenv("HELM_DRIVER_ENCRYPTION_KEYFILE"),
+		DriverEncryptionKMSPlugin: os.Getenv("HELM_DRIVER_ENCRYPTION_KMS_PLUGIN"),
 	}
 	env.Debug, _ = strconv.ParseBool(os.Getenv("HELM_DEBUG"))
+	env.DriverSeparateCharts, _ = strconv.ParseBool(os.Getenv("HELM_DRIVER_SEPARATE_CHARTS"))
 
 	// bind to kubernetes config flags
 	env.config = &genericclioptions.ConfigFlags{
@@ -149,6 +166,7 @@ func (s *EnvSettings) EnvVars() map[string]string {
 		"HELM_BIN":               os.Args[0],
 		"HELM_CACHE_HOME":        helmpath.CachePath(""),
 		"HELM_CONFIG_HOME":       helmpath.ConfigPath(""),
+		"HELM_CONTENT_CACHE":     s.ContentCache,
 		"HELM_DATA_HOME":         helmpath.DataPath(""),
 		"HELM_DEBUG":             fmt.Sprint(s.Debug),
 		"HELM_PLUGINS":           s.PluginsDirectory,
@@ -158,6 +176,10 @@ func (s *EnvSettings) EnvVars() map[string]string {
 		"HELM_NAMESPACE":         s.Namespace(),
 		"HELM_MAX_HISTORY":       strconv.Itoa(s.MaxHistory),
 
+		"HELM_DRIVER_ENCRYPTION_KEYFILE":    s.DriverEncryptionKeyFile,
+		"HELM_DRIVER_ENCRYPTION_KMS_PLUGIN": s.DriverEncryptionKMSPlugin,
+		"HELM_DRIVER_SEPARATE_CHARTS":       fmt.Sprint(s.DriverSeparateCharts),
+
 		// broken, these are populated from helm flags and not kubeconfig.
 		"HELM_KUBECONTEXT":   s.KubeContext,
 		"HELM_KUBETOKEN":     s.KubeToken,
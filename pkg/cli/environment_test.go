@@ -40,6 +40,8 @@ func TestEnvSettings(t *testing.T) {
 		kAsUser      string
 		kAsGroups    []string
 		kCaFile      string
+
+		separateCharts bool
 	}{
 		{
 			name:       "defaults",
@@ -58,13 +60,15 @@ func TestEnvSettings(t *testing.T) {
 		},
 		{
 			name:       "with envvars set",
-			envvars:    map[string]string{"HELM_DEBUG": "1", "HELM_NAMESPACE": "yourns", "HELM_KUBEASUSER": "pikachu", "HELM_KUBEASGROUPS": ",,,operators,snackeaters,partyanimals", "HELM_MAX_HISTORY": "5", "HELM_KUBECAFILE": "/tmp/ca.crt"},
+			envvars:    map[string]string{"HELM_DEBUG": "1", "HELM_NAMESPACE": "yourns", "HELM_KUBEASUSER": "pikachu", "HELM_KUBEASGROUPS": ",,,operators,snackeaters,partyanimals", "HELM_MAX_HISTORY": "5", "HELM_KUBECAFILE": "/tmp/ca.crt", "HELM_DRIVER_SEPARATE_CHARTS": "true"},
 			ns:         "yourns",
 			maxhistory: 5,
 			debug:      true,
 			kAsUser:    "pikachu",
 			kAsGroups:  []string{"operators", "snackeaters", "partyanimals"},
 			kCaFile:    "/tmp/ca.crt",
+
+			separateCharts: true,
 		},
 		{
 			name:       "with flags and envvars set",
@@ -114,6 +118,9 @@ func TestEnvSettings(t *testing.T) {
 			if tt.kCaFile != settings.KubeCaFile {
 				t.Errorf("expected kCaFile %q, got %q", tt.kCaFile, settings.KubeCaFile)
 			}
+			if tt.separateCharts != settings.DriverSeparateCharts {
+				t.Errorf("expected separateCharts %t, got %t", tt.separateCharts, settings.DriverSeparateCharts)
+			}
 		})
 	}
 }
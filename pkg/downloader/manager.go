@@ -35,6 +35,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"helm.sh/helm/v3/internal/experimental/registry"
+	"helm.sh/helm/v3/internal/fileutil"
 	"helm.sh/helm/v3/internal/resolver"
 	"helm.sh/helm/v3/internal/third_party/dep/fs"
 	"helm.sh/helm/v3/internal/urlutil"
@@ -43,6 +44,7 @@ import (
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/provenance"
 	"helm.sh/helm/v3/pkg/repo"
 )
 
@@ -71,6 +73,23 @@ type Manager struct {
 	Keyring string
 	// SkipUpdate indicates that the repository should not be updated first.
 	SkipUpdate bool
+	// VerifyDigests also fails if no digest is recorded in the lock file for a
+	// downloaded chart archive. An archive that does not match its recorded
+	// digest always fails.
+	VerifyDigests bool
+	// Transitive also resolves the dependencies of the dependencies and
+	// records the whole graph in the lock file. Dependencies whose archives
+	// do not include their resolved dependencies are expanded into the charts
+	// directory, with their dependencies downloaded into theirs.
+	Transitive bool
+	// ContentCache is the directory of the cache of dependency archives,
+	// keyed by repository URL, chart name and version. Downloaded archives
+	// are added to it if set.
+	ContentCache string
+	// Offline copies the archives of dependencies from ContentCache instead
+	// of downloading them, and does not update the repositories. A
+	// dependency missing from the cache is an error.
+	Offline bool
 	// Getter collection for the operation
 	Getters          []getter.Provider
 	RegistryClient   *registry.Client
@@ -82,7 +101,7 @@ type Manager struct {
 //
 // If the lockfile is not present, this will run a Manager.Update()
 //
-// If SkipUpdate is set, this will not update the repository.
+// If SkipUpdate or Offline is set, this will not update the repository.
 func (m *Manager) Build() error {
 	c, err := m.loadChartDir()
 	if err != nil {
@@ -129,12 +148,15 @@ func (m *Manager) Build() error {
 		}
 	}
 
-	// Check that all of the repos we're dependent on actually exist.
-	if err := m.hasAllRepos(lock.Dependencies); err != nil {
-		return err
+	// Check that all of the repos we're dependent on actually exist. They are
+	// not needed to copy the charts from the cache.
+	if !m.Offline {
+		if err := m.hasAllRepos(lock.Dependencies); err != nil {
+			return err
+		}
 	}
 
-	if !m.SkipUpdate {
+	if !m.skipUpdate() {
 		// For each repo in the file, update the cached copy of that repo
 		if err := m.UpdateRepositories(); err != nil {
 			return err
@@ -184,7 +206,7 @@ func (m *Manager) Update() error {
 
 	// For each of the repositories Helm is configured to know about, update
 	// the index information locally.
-	if !m.SkipUpdate {
+	if !m.skipUpdate() {
 		if err := m.UpdateRepositories(); err != nil {
 			return err
 		}
@@ -219,6 +241,11 @@ func (m *Manager) Update() error {
 	return writeLock(m.ChartPath, lock, c.Metadata.APIVersion == chart.APIVersionV1)
 }
 
+// skipUpdate reports whether the repositories must not be updated.
+func (m *Manager) skipUpdate() bool {
+	return m.SkipUpdate || m.Offline
+}
+
 func (m *Manager) loadChartDir() (*chart.Chart, error) {
 	if fi, err := os.Stat(m.ChartPath); err != nil {
 		return nil, errors.Wrapf(err, "could not find %s", m.ChartPath)
@@ -233,6 +260,8 @@ func (m *Manager) loadChartDir() (*chart.Chart, error) {
 // This returns a lock file, which has all of the dependencies normalized to a specific version.
 func (m *Manager) resolve(req []*chart.Dependency, repoNames map[string]string) (*chart.Lock, error) {
 	res := resolver.New(m.ChartPath, m.RepositoryCache)
+	res.Transitive = m.Transitive
+	res.RepositoryConfig = m.RepositoryConfig
 	return res.Resolve(req, repoNames)
 }
 
@@ -241,9 +270,13 @@ func (m *Manager) resolve(req []*chart.Dependency, repoNames map[string]string)
 // It will delete versions of the chart that exist on disk and might cause
 // a conflict.
 func (m *Manager) downloadAll(deps []*chart.Dependency) error {
-	repos, err := m.loadChartRepositories()
-	if err != nil {
-		return err
+	// The repositories are not needed to copy the charts from the cache.
+	var repos map[string]*repo.ChartRepository
+	if !m.Offline {
+		var err error
+		if repos, err = m.loadChartRepositories(); err != nil {
+			return err
+		}
 	}
 
 	destPath := filepath.Join(m.ChartPath, "charts")
@@ -268,7 +301,7 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 
 	fmt.Fprintf(m.Out, "Saving %d charts\n", len(deps))
 	var saveError error
-	churls := make(map[string]struct{})
+	churls := make(map[string]string)
 	for _, dep := range deps {
 		// No repository means the chart is in charts directory
 		if dep.Repository == "" {
@@ -305,6 +338,26 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 				break
 			}
 			dep.Version = ver
+			archive := filepath.Join(destPath, fmt.Sprintf("%s-%s.tgz", dep.Name, ver))
+			if err := m.vendorNested(dep, archive, destPath); err != nil {
+				saveError = err
+				break
+			}
+			continue
+		}
+
+		if m.Offline {
+			archive, err := m.copyFromContentCache(dep, destPath)
+			if err == nil {
+				_, err = m.checkArchive(dep, archive)
+			}
+			if err == nil {
+				err = m.vendorNested(dep, archive, destPath)
+			}
+			if err != nil {
+				saveError = err
+				break
+			}
 			continue
 		}
 
@@ -316,8 +369,12 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 			break
 		}
 
-		if _, ok := churls[churl]; ok {
+		if digest, ok := churls[churl]; ok {
 			fmt.Fprintf(m.Out, "Already downloaded %s from repo %s\n", dep.Name, dep.Repository)
+			if err := m.checkDigest(dep, digest); err != nil {
+				saveError = err
+				break
+			}
 			continue
 		}
 
@@ -354,13 +411,24 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 				getter.WithTagName(version))
 		}
 
-		_, _, err = dl.DownloadTo(churl, version, destPath)
+		archive, _, err := dl.DownloadTo(churl, version, destPath)
 		if err != nil {
 			saveError = errors.Wrapf(err, "could not download %s", churl)
 			break
 		}
 
-		churls[churl] = struct{}{}
+		digest, err := m.checkArchive(dep, archive)
+		if err != nil {
+			saveError = err
+			break
+		}
+		m.addToContentCache(dep, archive)
+		if err := m.vendorNested(dep, archive, destPath); err != nil {
+			saveError = err
+			break
+		}
+
+		churls[churl] = digest
 	}
 
 	if saveError == nil {
@@ -371,6 +439,9 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 				if err := m.safeDeleteDep(dep.Name, tmpPath); err != nil {
 					return err
 				}
+				if len(dep.Dependencies) > 0 {
+					m.safeDeleteExpandedDep(dep.Name, tmpPath)
+				}
 			}
 		}
 		if err := move(tmpPath, destPath); err != nil {
@@ -398,6 +469,213 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 	return nil
 }
 
+// checkArchive computes the digest of the archive of a dependency and checks
+// it with checkDigest.
+func (m *Manager) checkArchive(dep *chart.Dependency, archive string) (string, error) {
+	sum, err := provenance.DigestFile(archive)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not compute the digest of %s", archive)
+	}
+	digest := "sha256:" + sum
+	return digest, m.checkDigest(dep, digest)
+}
+
+// contentCachePath returns the path of the archive of a dependency in the
+// content cache, or an empty string if the dependency has no digest and no
+// archive of its version is cached.
+//
+// Archives are keyed by the URL of their repository, with repository aliases
+// resolved, and by their digest, so that archives of the same version with
+// different content are kept apart. Without a digest, the archive of the
+// version cached last is used.
+func (m *Manager) contentCachePath(dep *chart.Dependency) (string, error) {
+	repoURL, err := m.contentCacheRepository(dep.Repository)
+	if err != nil {
+		return "", err
+	}
+	k, err := key(strings.TrimSuffix(repoURL, "/"))
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(m.ContentCache, k, fmt.Sprintf("%s-%s", dep.Name, dep.Version))
+	if dep.Digest != "" {
+		return filepath.Join(dir, strings.TrimPrefix(dep.Digest, "sha256:")+".tgz"), nil
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	var latest os.FileInfo
+	for _, f := range files {
+		if filepath.Ext(f.Name()) == ".tgz" && (latest == nil || f.ModTime().After(latest.ModTime())) {
+			latest = f
+		}
+	}
+	if latest == nil {
+		return "", nil
+	}
+	return filepath.Join(dir, latest.Name()), nil
+}
+
+// contentCacheRepository returns the URL of the repository of a dependency,
+// resolving repository aliases with the repository configuration.
+func (m *Manager) contentCacheRepository(repository string) (string, error) {
+	name := strings.TrimPrefix(strings.TrimPrefix(repository, "@"), "alias:")
+	if name == repository {
+		return repository, nil
+	}
+	rf, err := loadRepoConfig(m.RepositoryConfig)
+	if err != nil {
+		return "", err
+	}
+	if rf != nil {
+		if entry := rf.Get(name); entry != nil {
+			return entry.URL, nil
+		}
+	}
+	return "", errors.Errorf("no repository definition for %s. Please add it via 'helm repo add'", repository)
+}
+
+// addToContentCache copies the downloaded archive of a dependency to the
+// content cache, if set. Failures are only reported.
+func (m *Manager) addToContentCache(dep *chart.Dependency, archive string) {
+	if m.ContentCache == "" {
+		return
+	}
+	dest, err := m.contentCachePath(dep)
+	if err == nil {
+		err = copyFile(archive, dest)
+	}
+	if err != nil {
+		fmt.Fprintf(m.Out, "WARNING: could not add %s %s to the chart cache: %s\n", dep.Name, dep.Version, err)
+	}
+}
+
+// copyFromContentCache copies the archive of a dependency from the content
+// cache to destPath and returns the path of the copy.
+//
+// The archive is checked against the digest locked for the dependency by the
+// caller, like a downloaded archive.
+func (m *Manager) copyFromContentCache(dep *chart.Dependency, destPath string) (string, error) {
+	if m.ContentCache == "" {
+		return "", errors.Errorf("cannot build dependency %s offline: no chart cache configured", dep.Name)
+	}
+	src, err := m.contentCachePath(dep)
+	if err != nil {
+		return "", err
+	}
+	notCached := src == ""
+	if !notCached {
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			notCached = true
+		} else if err != nil {
+			return "", err
+		}
+	}
+	if notCached {
+		return "", errors.Errorf("dependency %s %s from %s is not in the chart cache %s, run 'helm dependency update' with network access to add it", dep.Name, dep.Version, dep.Repository, m.ContentCache)
+	}
+	fmt.Fprintf(m.Out, "Copying %s from the chart cache\n", dep.Name)
+	dest := filepath.Join(destPath, fmt.Sprintf("%s-%s.tgz", dep.Name, dep.Version))
+	return dest, copyFile(src, dest)
+}
+
+// copyFile atomically copies a file, creating the directory of the copy.
+func copyFile(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return fileutil.AtomicWriteFile(dest, f, 0644)
+}
+
+// checkDigest compares the digest of the downloaded archive of a dependency
+// with the digest locked for it, failing if they differ, and records the
+// digest of the archive in the dependency.
+//
+// The digest of the archive is the digest indexed by chart repositories and
+// the digest of the chart layer of OCI artifacts.
+func (m *Manager) checkDigest(dep *chart.Dependency, digest string) error {
+	switch {
+	case dep.Digest == "" && m.VerifyDigests:
+		return errors.Errorf("no digest locked for dependency %s, update the dependencies to record it", dep.Name)
+	case dep.Digest != "" && dep.Digest != digest:
+		return errors.Errorf("digest mismatch for dependency %s %s: locked %s, downloaded %s", dep.Name, dep.Version, dep.Digest, digest)
+	}
+	dep.Digest = digest
+	return nil
+}
+
+// vendorNested makes sure the archive of a dependency includes the
+// dependencies resolved for it. If some of them are missing, the archive is
+// expanded into the charts directory and the missing dependencies are
+// downloaded into the charts directory of the expanded chart.
+//
+// Only dependencies from repositories and OCI registries are downloaded; the
+// other dependencies of a chart must be packaged with it.
+func (m *Manager) vendorNested(dep *chart.Dependency, archive, destPath string) error {
+	if len(dep.Dependencies) == 0 {
+		return nil
+	}
+	ch, err := loader.LoadFile(archive)
+	if err != nil {
+		return errors.Wrapf(err, "unable to load dependency %s", dep.Name)
+	}
+	vendored := make(map[string]*semver.Version)
+	for _, sub := range ch.Dependencies() {
+		if v, err := semver.NewVersion(sub.Metadata.Version); err == nil {
+			vendored[sub.Name()] = v
+		}
+	}
+
+	var missing []*chart.Dependency
+	for _, d := range dep.Dependencies {
+		// Dependencies packaged with charts from repositories are locked with
+		// their constraint.
+		if v, ok := vendored[d.Name]; ok {
+			if c, err := semver.NewConstraint(d.Version); err == nil && c.Check(v) {
+				continue
+			}
+		}
+		if d.Repository == "" || strings.HasPrefix(d.Repository, "file://") {
+			return errors.Errorf("dependency %s %s of %s is missing from its charts directory", d.Name, d.Version, dep.Name)
+		}
+		missing = append(missing, d)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(m.Out, "Expanding %s to add its dependencies\n", dep.Name)
+	if err := chartutil.ExpandFile(destPath, archive); err != nil {
+		return errors.Wrapf(err, "unable to expand dependency %s", dep.Name)
+	}
+	if err := os.Remove(archive); err != nil {
+		return err
+	}
+	nested := *m
+	nested.ChartPath = filepath.Join(destPath, ch.Name())
+	return nested.downloadAll(missing)
+}
+
+// safeDeleteExpandedDep deletes the expanded chart of the given dependency in
+// the given directory, if any. Errors are only reported.
+func (m *Manager) safeDeleteExpandedDep(name, dir string) {
+	chartPath := filepath.Join(dir, name)
+	ch, err := loader.LoadDir(chartPath)
+	if err != nil || ch.Name() != name {
+		return
+	}
+	if err := os.RemoveAll(chartPath); err != nil {
+		fmt.Fprintf(m.Out, "Could not delete %s: %s (Skipping)", chartPath, err)
+	}
+}
+
 func parseOCIRef(chartRef string) (string, string, error) {
 	refTagRegexp := regexp.MustCompile(`^(oci://[^:]+(:[0-9]{1,5})?[^:]+):(.*)$`)
 	caps := refTagRegexp.FindStringSubmatch(chartRef)
@@ -529,7 +807,7 @@ func (m *Manager) ensureMissingRepos(repoNames map[string]string, deps []*chart.
 	// repositories configured by the user. Here we update repos found in
 	// the dependencies that are not known to the user if update skipping
 	// is not configured.
-	if !m.SkipUpdate && len(ru) > 0 {
+	if !m.skipUpdate() && len(ru) > 0 {
 		fmt.Fprintln(m.Out, "Getting updates for unmanaged Helm repositories...")
 		if err := m.parallelRepoUpdate(ru); err != nil {
 			return repoNames, err
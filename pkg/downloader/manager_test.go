@@ -17,13 +17,18 @@ package downloader
 
 import (
 	"bytes"
+	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
+	"helm.sh/helm/v3/internal/resolver"
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/provenance"
 	"helm.sh/helm/v3/pkg/repo/repotest"
 )
 
@@ -463,6 +468,292 @@ func TestBuild_WithRepositoryAlias(t *testing.T) {
 	})
 }
 
+func TestBuild_VerifyDigests(t *testing.T) {
+	srv, err := repotest.NewTempServerWithCleanup(t, "testdata/*.tgz*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+	dir := func(p ...string) string {
+		return filepath.Join(append([]string{srv.Root()}, p...)...)
+	}
+
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:       "with-digests",
+			Version:    "0.1.0",
+			APIVersion: "v2",
+			Dependencies: []*chart.Dependency{{
+				Name:       "local-subchart",
+				Version:    "0.1.0",
+				Repository: srv.URL(),
+			}},
+		},
+	}
+	if err := chartutil.SaveDir(c, dir()); err != nil {
+		t.Fatal(err)
+	}
+
+	b := bytes.NewBuffer(nil)
+	m := &Manager{
+		ChartPath: dir(c.Metadata.Name),
+		Out:       b,
+		Getters: getter.Providers{getter.Provider{
+			Schemes: []string{"http", "https"},
+			New:     getter.NewHTTPGetter,
+		}},
+		RepositoryConfig: dir("repositories.yaml"),
+		RepositoryCache:  dir(),
+	}
+	if err := m.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := provenance.DigestFile("testdata/local-subchart-0.1.0.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loader.LoadDir(m.ChartPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := loaded.Lock
+	if got := lock.Dependencies[0].Digest; got != "sha256:"+sum {
+		t.Fatalf("expected digest sha256:%s to be locked, got %q", sum, got)
+	}
+
+	m.VerifyDigests = true
+	if err := m.Build(); err != nil {
+		t.Fatal(err)
+	}
+	m.VerifyDigests = false
+
+	// Lock a different digest, as if the repository republished the version.
+	lock.Dependencies[0].Digest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	if lock.Digest, err = resolver.HashReq(loaded.Metadata.Dependencies, lock.Dependencies); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeLock(m.ChartPath, lock, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Build(); err == nil || !strings.Contains(err.Error(), "digest mismatch for dependency local-subchart 0.1.0") {
+		t.Fatalf("expected a digest mismatch error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(m.ChartPath, "charts", "local-subchart-0.1.0.tgz")); err != nil {
+		t.Errorf("expected the previous dependencies to be restored: %s", err)
+	}
+
+	// Dependencies without a locked digest only fail with verification.
+	lock.Dependencies[0].Digest = ""
+	if lock.Digest, err = resolver.HashReq(loaded.Metadata.Dependencies, lock.Dependencies); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeLock(m.ChartPath, lock, false); err != nil {
+		t.Fatal(err)
+	}
+	m.VerifyDigests = true
+	if err := m.Build(); err == nil || !strings.Contains(err.Error(), "no digest locked for dependency local-subchart") {
+		t.Fatalf("expected a missing digest error, got %v", err)
+	}
+	m.VerifyDigests = false
+	if err := m.Build(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdate_Transitive(t *testing.T) {
+	srv, err := repotest.NewTempServerWithCleanup(t, "testdata/*.tgz*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	dir := func(p ...string) string {
+		return filepath.Join(append([]string{srv.Root()}, p...)...)
+	}
+
+	// frontend is published without its dependency.
+	frontend := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:       "frontend",
+			Version:    "0.1.0",
+			APIVersion: "v2",
+			Dependencies: []*chart.Dependency{{
+				Name:       "local-subchart",
+				Version:    "^0.1.0",
+				Repository: srv.URL(),
+			}},
+		},
+	}
+	if _, err := chartutil.Save(frontend, srv.Root()); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.CreateIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:       "app",
+			Version:    "0.1.0",
+			APIVersion: "v2",
+			Dependencies: []*chart.Dependency{{
+				Name:       "frontend",
+				Version:    "0.1.0",
+				Repository: srv.URL(),
+			}},
+		},
+	}
+	if err := chartutil.SaveDir(c, dir()); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manager{
+		ChartPath: dir(c.Metadata.Name),
+		Out:       new(bytes.Buffer),
+		Getters: getter.Providers{getter.Provider{
+			Schemes: []string{"http", "https"},
+			New:     getter.NewHTTPGetter,
+		}},
+		RepositoryConfig: dir("repositories.yaml"),
+		RepositoryCache:  dir(),
+		Transitive:       true,
+	}
+	if err := m.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func() {
+		t.Helper()
+		loaded, err := loader.LoadDir(m.ChartPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nested := loaded.Lock.Dependencies[0].Dependencies
+		if len(nested) != 1 || nested[0].Name != "local-subchart" || nested[0].Version != "0.1.0" {
+			t.Fatalf("expected local-subchart 0.1.0 to be locked for frontend, got %v", nested)
+		}
+		if _, err := os.Stat(filepath.Join(m.ChartPath, "charts", "frontend-0.1.0.tgz")); !os.IsNotExist(err) {
+			t.Errorf("expected the archive of frontend to be expanded")
+		}
+		deps := loaded.Dependencies()
+		if len(deps) != 1 || deps[0].Name() != "frontend" {
+			t.Fatalf("expected frontend to be vendored, got %v", deps)
+		}
+		if sub := deps[0].Dependencies(); len(sub) != 1 || sub[0].Name() != "local-subchart" {
+			t.Errorf("expected local-subchart to be vendored in frontend, got %v", sub)
+		}
+	}
+	check()
+
+	// Building again replaces the expanded chart.
+	if err := m.Build(); err != nil {
+		t.Fatal(err)
+	}
+	check()
+}
+
+func TestBuild_Offline(t *testing.T) {
+	srv, err := repotest.NewTempServerWithCleanup(t, "testdata/*.tgz*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+	dir := func(p ...string) string {
+		return filepath.Join(append([]string{srv.Root()}, p...)...)
+	}
+
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:       "offline",
+			Version:    "0.1.0",
+			APIVersion: "v2",
+			Dependencies: []*chart.Dependency{{
+				Name:       "local-subchart",
+				Version:    "0.1.0",
+				Repository: srv.URL(),
+			}},
+		},
+	}
+	if err := chartutil.SaveDir(c, dir()); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manager{
+		ChartPath: dir(c.Metadata.Name),
+		Out:       bytes.NewBuffer(nil),
+		Getters: getter.Providers{getter.Provider{
+			Schemes: []string{"http", "https"},
+			New:     getter.NewHTTPGetter,
+		}},
+		RepositoryConfig: dir("repositories.yaml"),
+		RepositoryCache:  dir(),
+		ContentCache:     dir("content"),
+	}
+	if err := m.Update(); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := m.contentCachePath(&chart.Dependency{Name: "local-subchart", Version: "0.1.0", Repository: srv.URL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cached); err != nil {
+		t.Fatalf("expected the dependency to be cached: %s", err)
+	}
+	sum, err := provenance.DigestFile(cached)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(cached) != sum+".tgz" {
+		t.Errorf("expected the dependency to be cached by digest, got %s", cached)
+	}
+	// Repository aliases share the cache of the repository.
+	aliased, err := m.contentCachePath(&chart.Dependency{Name: "local-subchart", Version: "0.1.0", Repository: "@test", Digest: "sha256:" + sum})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aliased != cached {
+		t.Errorf("expected the aliased dependency to be cached in %s, got %s", cached, aliased)
+	}
+	// Archives with another digest are not used.
+	other, err := m.contentCachePath(&chart.Dependency{Name: "local-subchart", Version: "0.1.0", Repository: srv.URL(), Digest: "sha256:0123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(other); !os.IsNotExist(err) {
+		t.Errorf("expected no archive cached with another digest, got %v", err)
+	}
+
+	// Neither the repository nor its configuration are needed offline.
+	srv.Stop()
+	m.RepositoryConfig = dir("missing.yaml")
+	m.Offline = true
+	if err := os.RemoveAll(dir(c.Metadata.Name, "charts")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Build(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir(c.Metadata.Name, "charts", "local-subchart-0.1.0.tgz")); err != nil {
+		t.Fatalf("expected the dependency to be copied from the cache: %s", err)
+	}
+
+	if err := os.RemoveAll(dir("content")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Build(); err == nil || !strings.Contains(err.Error(), "dependency local-subchart 0.1.0 from "+srv.URL()+" is not in the chart cache") {
+		t.Fatalf("expected a cache miss error, got %v", err)
+	}
+}
+
 func TestErrRepoNotFound_Error(t *testing.T) {
 	type fields struct {
 		Repos []string
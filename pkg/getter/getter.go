@@ -18,6 +18,7 @@ package getter
 
 import (
 	"bytes"
+	"io"
 	"time"
 
 	"github.com/pkg/errors"
@@ -125,6 +126,27 @@ type Getter interface {
 	Get(url string, options ...Option) (*bytes.Buffer, error)
 }
 
+// ErrNotModified is returned by a conditional request when the resource still
+// matches the validators of the request.
+var ErrNotModified = errors.New("not modified")
+
+// Validators are the cache validators of a fetched resource, used to make
+// conditional requests for it.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// ConditionalGetter is an optional interface of getters able to make
+// conditional requests.
+type ConditionalGetter interface {
+	// GetIfModified fetches the resource at url unless it still matches the
+	// validators, in which case it returns ErrNotModified. The content is
+	// streamed and must be closed by the caller. The validators of the
+	// fetched resource are returned with it.
+	GetIfModified(url string, v Validators, options ...Option) (io.ReadCloser, Validators, error)
+}
+
 // Constructor is the function for every getter which creates a specific instance
 // according to the configuration
 type Constructor func(options ...Option) (Getter, error)
@@ -43,12 +43,62 @@ func (g *HTTPGetter) Get(href string, options ...Option) (*bytes.Buffer, error)
 }
 
 func (g *HTTPGetter) get(href string) (*bytes.Buffer, error) {
+	resp, err := g.do(href, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to fetch %s : %s", href, resp.Status)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	_, err = io.Copy(buf, resp.Body)
+	return buf, err
+}
+
+// GetIfModified performs a conditional Get and streams the body.
+func (g *HTTPGetter) GetIfModified(href string, v Validators, options ...Option) (io.ReadCloser, Validators, error) {
+	for _, opt := range options {
+		opt(&g.opts)
+	}
+
+	header := make(http.Header)
+	if v.ETag != "" {
+		header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		header.Set("If-Modified-Since", v.LastModified)
+	}
+	resp, err := g.do(href, header)
+	if err != nil {
+		return nil, Validators{}, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, Validators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}, nil
+	case http.StatusNotModified:
+		resp.Body.Close()
+		return nil, v, ErrNotModified
+	}
+	resp.Body.Close()
+	return nil, Validators{}, errors.Errorf("failed to fetch %s : %s", href, resp.Status)
+}
+
+// do sends a GET request with the given additional headers.
+func (g *HTTPGetter) do(href string, header http.Header) (*http.Response, error) {
 	// Set a helm specific user agent so that a repo server and metrics can
 	// separate helm calls from other tools interacting with repos.
 	req, err := http.NewRequest(http.MethodGet, href, nil)
 	if err != nil {
 		return nil, err
 	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
 
 	req.Header.Set("User-Agent", version.GetUserAgent())
 	if g.opts.userAgent != "" {
@@ -80,18 +130,7 @@ func (g *HTTPGetter) get(href string) (*bytes.Buffer, error) {
 		return nil, err
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("failed to fetch %s : %s", href, resp.Status)
-	}
-
-	buf := bytes.NewBuffer(nil)
-	_, err = io.Copy(buf, resp.Body)
-	return buf, err
+	return client.Do(req)
 }
 
 // NewHTTPGetter constructs a valid http/https client as a Getter
@@ -276,6 +276,51 @@ func TestDownload(t *testing.T) {
 	}
 }
 
+func TestDownloadIfModified(t *testing.T) {
+	expect := "Call me Ishmael"
+	modTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"ishmael"`)
+		http.ServeContent(w, r, "", modTime, strings.NewReader(expect))
+	}))
+	defer srv.Close()
+
+	g, err := NewHTTPGetter(WithURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cg := g.(ConditionalGetter)
+
+	body, v, err := cg.GetIfModified(srv.URL, Validators{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != expect {
+		t.Errorf("Expected %q, got %q", expect, got)
+	}
+	want := Validators{ETag: `"ishmael"`, LastModified: modTime.Format(http.TimeFormat)}
+	if v != want {
+		t.Errorf("Expected validators %+v, got %+v", want, v)
+	}
+
+	for _, v := range []Validators{want, {ETag: want.ETag}, {LastModified: want.LastModified}} {
+		if _, _, err := cg.GetIfModified(srv.URL, v); err != ErrNotModified {
+			t.Errorf("Expected ErrNotModified with validators %+v, got %v", v, err)
+		}
+	}
+
+	body, _, err = cg.GetIfModified(srv.URL, Validators{ETag: `"ahab"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+}
+
 func TestDownloadTLS(t *testing.T) {
 	cd := "../../testdata"
 	ca, pub, priv := filepath.Join(cd, "rootca.crt"), filepath.Join(cd, "crt.pem"), filepath.Join(cd, "key.pem")
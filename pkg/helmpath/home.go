@@ -34,6 +34,15 @@ func CacheIndexFile(name string) string {
 	return name + "index.yaml"
 }
 
+// CacheCompactIndexFile returns the path to the compact cache of the index
+// for the given named repository.
+func CacheCompactIndexFile(name string) string {
+	if name != "" {
+		name += "-"
+	}
+	return name + "index.cache"
+}
+
 // CacheChartsFile returns the path to a text file listing all the charts
 // within the given named repository.
 func CacheChartsFile(name string) string {
@@ -42,6 +42,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
@@ -57,10 +58,23 @@ var ErrNoObjectsVisited = errors.New("no objects visited")
 
 var metadataAccessor = meta.NewAccessor()
 
+// recreatePollInterval controls how often a deleted resource is polled while
+// waiting for it to be gone. recreateTimeout is how long Update and
+// UpdateServerSide wait for a resource with the recreate policy to be deleted
+// before it is created again.
+var (
+	recreatePollInterval = time.Second
+	recreateTimeout      = 5 * time.Minute
+)
+
 // ManagedFieldsManager is the name of the manager of Kubernetes managedFields
 // first introduced in Kubernetes 1.18
 var ManagedFieldsManager string
 
+// ServerSideApplyFieldManager is the field manager used when resources are
+// updated using server-side apply.
+const ServerSideApplyFieldManager = "helm"
+
 // Client represents a client capable of communicating with the Kubernetes API.
 type Client struct {
 	Factory Factory
@@ -134,32 +148,44 @@ func (c *Client) Create(resources ResourceList) (*Result, error) {
 
 // Wait waits up to the given timeout for the specified resources to be ready.
 func (c *Client) Wait(resources ResourceList, timeout time.Duration) error {
-	cs, err := c.getKubeClient()
-	if err != nil {
-		return err
-	}
-	checker := NewReadyChecker(cs, c.Log, PausedAsReady(true))
-	w := waiter{
-		c:       checker,
-		log:     c.Log,
-		timeout: timeout,
-	}
-	return w.waitForResources(resources)
+	return c.WaitWithContext(context.Background(), resources, timeout)
+}
+
+// WaitWithContext waits up to the given timeout for the specified resources to
+// be ready, or until the context is cancelled.
+func (c *Client) WaitWithContext(ctx context.Context, resources ResourceList, timeout time.Duration) error {
+	return c.WaitWithProgress(ctx, resources, timeout, false, nil)
 }
 
 // WaitWithJobs wait up to the given timeout for the specified resources to be ready, including jobs.
 func (c *Client) WaitWithJobs(resources ResourceList, timeout time.Duration) error {
+	return c.WaitWithJobsWithContext(context.Background(), resources, timeout)
+}
+
+// WaitWithJobsWithContext wait up to the given timeout for the specified
+// resources to be ready, including jobs, or until the context is cancelled.
+func (c *Client) WaitWithJobsWithContext(ctx context.Context, resources ResourceList, timeout time.Duration) error {
+	return c.WaitWithProgress(ctx, resources, timeout, true, nil)
+}
+
+// WaitWithProgress waits up to the given timeout for the specified resources
+// to be ready, including jobs if waitForJobs is set, or until the context is
+// cancelled. The readiness of the resources is reported to progress whenever
+// it changes. If the resources are not ready in time, a *WaitTimeoutError
+// listing the resources that are not ready is returned.
+func (c *Client) WaitWithProgress(ctx context.Context, resources ResourceList, timeout time.Duration, waitForJobs bool, progress WaitProgressFunc) error {
 	cs, err := c.getKubeClient()
 	if err != nil {
 		return err
 	}
-	checker := NewReadyChecker(cs, c.Log, PausedAsReady(true), CheckJobs(true))
+	checker := NewReadyChecker(cs, c.Log, PausedAsReady(true), CheckJobs(waitForJobs))
 	w := waiter{
-		c:       checker,
-		log:     c.Log,
-		timeout: timeout,
+		c:        checker,
+		log:      c.Log,
+		timeout:  timeout,
+		progress: progress,
 	}
-	return w.waitForResources(resources)
+	return w.waitForResources(ctx, resources)
 }
 
 func (c *Client) namespace() string {
@@ -203,8 +229,51 @@ func (c *Client) Build(reader io.Reader, validate bool) (ResourceList, error) {
 // resource updates, creations, and deletions that were attempted. These can be
 // used for cleanup or other logging purposes.
 func (c *Client) Update(original, target ResourceList, force bool) (*Result, error) {
-	updateErrors := []string{}
+	return c.UpdateWithContext(context.Background(), original, target, force, recreateTimeout)
+}
+
+// UpdateWithContext behaves like Update, waiting up to the given timeout, or
+// until the context is cancelled, for the resources with the recreate policy
+// to be deleted before they are created again.
+func (c *Client) UpdateWithContext(ctx context.Context, original, target ResourceList, force bool, timeout time.Duration) (*Result, error) {
+	return c.update(ctx, &Result{}, original, target, timeout, createResource, func(info *resource.Info, current runtime.Object) error {
+		return updateResource(c, info, current, force)
+	})
+}
+
+// UpdateServerSide behaves like Update, except that resources are created and
+// updated using server-side apply with the ServerSideApplyFieldManager field
+// manager instead of a client-side three-way merge patch. When the API server
+// reports that fields are owned by another field manager, the conflict is
+// recorded in Result.Conflicts and returned as an error, unless forceConflicts
+// is set, in which case Helm takes ownership of the conflicting fields.
+func (c *Client) UpdateServerSide(original, target ResourceList, forceConflicts bool) (*Result, error) {
+	return c.UpdateServerSideWithContext(context.Background(), original, target, forceConflicts, recreateTimeout)
+}
+
+// UpdateServerSideWithContext behaves like UpdateServerSide, waiting up to
+// the given timeout, or until the context is cancelled, for the resources with
+// the recreate policy to be deleted before they are created again.
+func (c *Client) UpdateServerSideWithContext(ctx context.Context, original, target ResourceList, forceConflicts bool, timeout time.Duration) (*Result, error) {
 	res := &Result{}
+	apply := func(info *resource.Info) error {
+		err := applyResource(info, forceConflicts)
+		if conflict, ok := conflictFor(info, err); ok {
+			res.Conflicts = append(res.Conflicts, conflict)
+		}
+		return err
+	}
+	return c.update(ctx, res, original, target, timeout, apply, func(info *resource.Info, _ runtime.Object) error {
+		if err := apply(info); err != nil {
+			return errors.Wrapf(err, "cannot apply %q with kind %s", info.Name, info.Mapping.GroupVersionKind.Kind)
+		}
+		c.Log("Applied %s %q", info.Mapping.GroupVersionKind.Kind, info.Name)
+		return nil
+	})
+}
+
+func (c *Client) update(ctx context.Context, res *Result, original, target ResourceList, timeout time.Duration, create func(*resource.Info) error, update func(*resource.Info, runtime.Object) error) (*Result, error) {
+	updateErrors := []string{}
 
 	c.Log("checking %d resources for changes", len(target))
 	err := target.Visit(func(info *resource.Info, err error) error {
@@ -222,7 +291,7 @@ func (c *Client) Update(original, target ResourceList, force bool) (*Result, err
 			res.Created = append(res.Created, info)
 
 			// Since the resource does not exist, create it.
-			if err := createResource(info); err != nil {
+			if err := create(info); err != nil {
 				return errors.Wrap(err, "failed to create resource")
 			}
 
@@ -237,9 +306,30 @@ func (c *Client) Update(original, target ResourceList, force bool) (*Result, err
 			return errors.Errorf("no %s with the name %q found", kind, info.Name)
 		}
 
-		if err := updateResource(c, info, originalInfo.Object, force); err != nil {
-			c.Log("error updating the resource %q:\n\t %v", info.Name, err)
-			updateErrors = append(updateErrors, err.Error())
+		switch {
+		case resourceHasPolicy(info, NeverUpdatePolicy):
+			c.Log("Skipping update of %q due to annotation [%s=%s]", info.Name, ResourcePolicyAnno, NeverUpdatePolicy)
+			// Refresh the resource so that Helm has the live object
+			if err := info.Get(); err != nil {
+				updateErrors = append(updateErrors, errors.Wrap(err, "failed to refresh resource information").Error())
+			}
+			res.Skipped = append(res.Skipped, info)
+			return nil
+		case resourceHasPolicy(info, RecreatePolicy):
+			recreated, err := c.recreateResource(ctx, info, originalInfo.Object, timeout, create)
+			if err != nil {
+				c.Log("error recreating the resource %q:\n\t %v", info.Name, err)
+				updateErrors = append(updateErrors, err.Error())
+			}
+			if recreated {
+				res.Recreated = append(res.Recreated, info)
+				return nil
+			}
+		default:
+			if err := update(info, originalInfo.Object); err != nil {
+				c.Log("error updating the resource %q:\n\t %v", info.Name, err)
+				updateErrors = append(updateErrors, err.Error())
+			}
 		}
 		// Because we check for errors later, append the info regardless
 		res.Updated = append(res.Updated, info)
@@ -265,8 +355,18 @@ func (c *Client) Update(original, target ResourceList, force bool) (*Result, err
 		if err != nil {
 			c.Log("Unable to get annotations on %q, err: %s", info.Name, err)
 		}
-		if annotations != nil && annotations[ResourcePolicyAnno] == KeepPolicy {
+		if HasResourcePolicy(annotations, KeepPolicy) {
 			c.Log("Skipping delete of %q due to annotation [%s=%s]", info.Name, ResourcePolicyAnno, KeepPolicy)
+			res.Kept = append(res.Kept, info)
+			continue
+		}
+		if HasResourcePolicy(annotations, OrphanAndStripPolicy) {
+			c.Log("Orphaning %q due to annotation [%s=%s]", info.Name, ResourcePolicyAnno, OrphanAndStripPolicy)
+			if err := stripOwnership(info); err != nil {
+				c.Log("Failed to orphan %q, err: %s", info.ObjectName(), err)
+				continue
+			}
+			res.Orphaned = append(res.Orphaned, info)
 			continue
 		}
 		if err := deleteResource(info); err != nil {
@@ -314,6 +414,69 @@ func (c *Client) Delete(resources ResourceList) (*Result, []error) {
 	return res, nil
 }
 
+// Orphan strips the labels and annotations recording the ownership of the
+// resources by a release, leaving them in the cluster without being managed by
+// any release. It will attempt to orphan all resources even if one or more
+// fail and collect any errors. All successfully orphaned items will be
+// returned in the `Orphaned` ResourceList that is part of the result.
+func (c *Client) Orphan(resources ResourceList) (*Result, []error) {
+	var errs []error
+	res := &Result{}
+	mtx := sync.Mutex{}
+	err := perform(resources, func(info *resource.Info) error {
+		c.Log("Orphaning %q %s", info.Name, info.Mapping.GroupVersionKind.Kind)
+		err := c.skipIfNotFound(stripOwnership(info))
+		mtx.Lock()
+		defer mtx.Unlock()
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			res.Orphaned = append(res.Orphaned, info)
+		}
+		return nil
+	})
+	if err != nil {
+		if err == ErrNoObjectsVisited {
+			err = errors.New("object not found, skipping orphan")
+		}
+		errs = append(errs, err)
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return res, nil
+}
+
+// Transfer rewrites the labels and annotations recording the ownership of the
+// resources so that they are owned by the given release. Nothing but the
+// ownership metadata of the live objects is changed.
+//
+// The returned result lists the resources transferred before any error.
+func (c *Client) Transfer(resources ResourceList, releaseName, releaseNamespace string) (*Result, error) {
+	var transferErrors []string
+	res := &Result{}
+	mtx := sync.Mutex{}
+	err := perform(resources, func(info *resource.Info) error {
+		c.Log("Transferring %q %s to release %s in namespace %s", info.Name, info.Mapping.GroupVersionKind.Kind, releaseName, releaseNamespace)
+		err := setOwnership(info, releaseName, releaseNamespace)
+		mtx.Lock()
+		defer mtx.Unlock()
+		if err != nil {
+			transferErrors = append(transferErrors, err.Error())
+		} else {
+			res.Updated = append(res.Updated, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	if len(transferErrors) > 0 {
+		return res, errors.Errorf(strings.Join(transferErrors, " && "))
+	}
+	return res, nil
+}
+
 func (c *Client) skipIfNotFound(err error) error {
 	if apierrors.IsNotFound(err) {
 		c.Log("%v", err)
@@ -322,9 +485,9 @@ func (c *Client) skipIfNotFound(err error) error {
 	return err
 }
 
-func (c *Client) watchTimeout(t time.Duration) func(*resource.Info) error {
+func (c *Client) watchTimeout(ctx context.Context, t time.Duration) func(*resource.Info) error {
 	return func(info *resource.Info) error {
-		return c.watchUntilReady(t, info)
+		return c.watchUntilReady(ctx, t, info)
 	}
 }
 
@@ -343,9 +506,15 @@ func (c *Client) watchTimeout(t time.Duration) func(*resource.Info) error {
 //
 // Handling for other kinds will be added as necessary.
 func (c *Client) WatchUntilReady(resources ResourceList, timeout time.Duration) error {
+	return c.WatchUntilReadyWithContext(context.Background(), resources, timeout)
+}
+
+// WatchUntilReadyWithContext behaves like WatchUntilReady, but stops watching
+// as soon as the context is cancelled.
+func (c *Client) WatchUntilReadyWithContext(ctx context.Context, resources ResourceList, timeout time.Duration) error {
 	// For jobs, there's also the option to do poll c.Jobs(namespace).Get():
 	// https://github.com/adamreese/kubernetes/blob/master/test/e2e/job.go#L291-L300
-	return perform(resources, c.watchTimeout(timeout))
+	return perform(resources, c.watchTimeout(ctx, timeout))
 }
 
 func perform(infos ResourceList, fn func(*resource.Info) error) error {
@@ -417,6 +586,37 @@ func deleteResource(info *resource.Info) error {
 	return err
 }
 
+func applyResource(target *resource.Info, forceConflicts bool) error {
+	data, err := json.Marshal(target.Object)
+	if err != nil {
+		return errors.Wrap(err, "serializing target configuration")
+	}
+	obj, err := resource.NewHelper(target.Client, target.Mapping).
+		WithFieldManager(ServerSideApplyFieldManager).
+		Patch(target.Namespace, target.Name, types.ApplyPatchType, data, &metav1.PatchOptions{Force: &forceConflicts})
+	if err != nil {
+		return err
+	}
+	return target.Refresh(obj, true)
+}
+
+// conflictFor converts a server-side apply conflict error into a Conflict.
+func conflictFor(info *resource.Info, err error) (Conflict, bool) {
+	if err == nil || !apierrors.IsConflict(err) {
+		return Conflict{}, false
+	}
+	conflict := Conflict{Resource: info, Message: err.Error()}
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if cause.Type == metav1.CauseTypeFieldManagerConflict {
+				conflict.Fields = append(conflict.Fields, cause.Field)
+			}
+		}
+	}
+	return conflict, true
+}
+
 func createPatch(target *resource.Info, current runtime.Object) ([]byte, types.PatchType, error) {
 	oldData, err := json.Marshal(current)
 	if err != nil {
@@ -508,7 +708,76 @@ func updateResource(c *Client, target *resource.Info, currentObj runtime.Object,
 	return nil
 }
 
-func (c *Client) watchUntilReady(timeout time.Duration, info *resource.Info) error {
+// recreateResource deletes the resource and creates it again if it changed,
+// waiting up to the given timeout, or until the context is cancelled, for it
+// to be deleted, and reports whether it was recreated.
+func (c *Client) recreateResource(ctx context.Context, target *resource.Info, current runtime.Object, timeout time.Duration, create func(*resource.Info) error) (bool, error) {
+	kind := target.Mapping.GroupVersionKind.Kind
+	patch, _, err := createPatch(target, current)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create patch")
+	}
+	if patch == nil || string(patch) == "{}" {
+		c.Log("Looks like there are no changes for %s %q", kind, target.Name)
+		if err := target.Get(); err != nil {
+			return false, errors.Wrap(err, "failed to refresh resource information")
+		}
+		return false, nil
+	}
+
+	if err := c.skipIfNotFound(deleteResource(target)); err != nil {
+		return false, errors.Wrapf(err, "cannot delete %q with kind %s for recreation", target.Name, kind)
+	}
+	if timeout <= 0 {
+		timeout = recreateTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := waitDeleted(ctx, target); err != nil {
+		return false, errors.Wrap(err, "unable to recreate resource")
+	}
+	if err := create(target); err != nil {
+		return false, errors.Wrapf(err, "cannot recreate %q with kind %s", target.Name, kind)
+	}
+	c.Log("Recreated %s %q", kind, target.Name)
+	return true, nil
+}
+
+// WaitForDelete waits up to the given timeout for the specified resources to
+// be gone from the cluster, or until the context is cancelled.
+func (c *Client) WaitForDelete(ctx context.Context, resources ResourceList, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for _, info := range resources {
+		if err := waitDeleted(ctx, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitDeleted polls the resource until it is gone from the cluster or the
+// context is done.
+func waitDeleted(ctx context.Context, info *resource.Info) error {
+	kind := info.Mapping.GroupVersionKind.Kind
+	helper := resource.NewHelper(info.Client, info.Mapping)
+	err := wait.PollImmediateUntil(recreatePollInterval, func() (bool, error) {
+		_, err := helper.Get(info.Namespace, info.Name)
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}, ctx.Done())
+	if err == wait.ErrWaitTimeout {
+		if ctx.Err() == context.Canceled {
+			return context.Canceled
+		}
+		return errors.Errorf("timed out waiting for %q with kind %s to be deleted", info.Name, kind)
+	}
+	return err
+}
+
+func (c *Client) watchUntilReady(ctx context.Context, timeout time.Duration, info *resource.Info) error {
 	kind := info.Mapping.GroupVersionKind.Kind
 	switch kind {
 	case "Job", "Pod":
@@ -532,7 +801,7 @@ func (c *Client) watchUntilReady(timeout time.Duration, info *resource.Info) err
 	// In the future, we might want to add some special logic for types
 	// like Ingress, Volume, etc.
 
-	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), timeout)
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(ctx, timeout)
 	defer cancel()
 	_, err = watchtools.UntilWithSync(ctx, lw, &unstructured.Unstructured{}, nil, func(e watch.Event) (bool, error) {
 		// Make sure the incoming object is versioned as we use unstructured
@@ -627,28 +896,46 @@ func scrubValidationError(err error) error {
 // WaitAndGetCompletedPodPhase waits up to a timeout until a pod enters a completed phase
 // and returns said phase (PodSucceeded or PodFailed qualify).
 func (c *Client) WaitAndGetCompletedPodPhase(name string, timeout time.Duration) (v1.PodPhase, error) {
+	return c.WaitAndGetCompletedPodPhaseWithContext(context.Background(), name, timeout)
+}
+
+// WaitAndGetCompletedPodPhaseWithContext waits up to a timeout until a pod
+// enters a completed phase and returns said phase (PodSucceeded or PodFailed
+// qualify). It returns early with the context's error if the context is
+// cancelled.
+func (c *Client) WaitAndGetCompletedPodPhaseWithContext(ctx context.Context, name string, timeout time.Duration) (v1.PodPhase, error) {
 	client, err := c.getKubeClient()
 	if err != nil {
 		return v1.PodUnknown, err
 	}
 	to := int64(timeout)
-	watcher, err := client.CoreV1().Pods(c.namespace()).Watch(context.Background(), metav1.ListOptions{
+	watcher, err := client.CoreV1().Pods(c.namespace()).Watch(ctx, metav1.ListOptions{
 		FieldSelector:  fmt.Sprintf("metadata.name=%s", name),
 		TimeoutSeconds: &to,
 	})
+	if err != nil {
+		return v1.PodUnknown, err
+	}
+	defer watcher.Stop()
 
-	for event := range watcher.ResultChan() {
-		p, ok := event.Object.(*v1.Pod)
-		if !ok {
-			return v1.PodUnknown, fmt.Errorf("%s not a pod", name)
-		}
-		switch p.Status.Phase {
-		case v1.PodFailed:
-			return v1.PodFailed, nil
-		case v1.PodSucceeded:
-			return v1.PodSucceeded, nil
+	for {
+		select {
+		case <-ctx.Done():
+			return v1.PodUnknown, ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return v1.PodUnknown, nil
+			}
+			p, ok := event.Object.(*v1.Pod)
+			if !ok {
+				return v1.PodUnknown, fmt.Errorf("%s not a pod", name)
+			}
+			switch p.Status.Phase {
+			case v1.PodFailed:
+				return v1.PodFailed, nil
+			case v1.PodSucceeded:
+				return v1.PodSucceeded, nil
+			}
 		}
 	}
-
-	return v1.PodUnknown, err
 }
@@ -18,15 +18,19 @@ package kube
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest/fake"
@@ -214,6 +218,363 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestUpdateResourcePolicies(t *testing.T) {
+	recreatePollInterval = time.Millisecond
+	defer func() { recreatePollInterval = time.Second }()
+
+	withPolicy := func(pod v1.Pod, policy string) v1.Pod {
+		pod.Annotations = map[string]string{ResourcePolicyAnno: policy}
+		return pod
+	}
+	listA := newPodList()
+	listA.Items = []v1.Pod{
+		withPolicy(newPod("starfish"), NeverUpdatePolicy),
+		withPolicy(newPod("otter"), RecreatePolicy),
+		withPolicy(newPod("squid"), "keep, never-update"),
+		withPolicy(newPod("crab"), OrphanAndStripPolicy),
+	}
+	listB := newPodList()
+	listB.Items = []v1.Pod{listA.Items[0], listA.Items[1]}
+	for i := range listB.Items {
+		listB.Items[i].Spec = *listB.Items[i].Spec.DeepCopy()
+		listB.Items[i].Spec.Containers[0].Ports = []v1.ContainerPort{{Name: "https", ContainerPort: 443}}
+	}
+
+	var actions []string
+	otterDeleted := false
+
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			p, m := req.URL.Path, req.Method
+			actions = append(actions, p+":"+m)
+			switch {
+			case p == "/namespaces/default/pods/starfish" && m == "GET":
+				return newResponse(200, &listA.Items[0])
+			case p == "/namespaces/default/pods/otter" && m == "GET":
+				if otterDeleted {
+					return newResponse(404, notFoundBody())
+				}
+				return newResponse(200, &listA.Items[1])
+			case p == "/namespaces/default/pods/otter" && m == "DELETE":
+				otterDeleted = true
+				return newResponse(200, &listA.Items[1])
+			case p == "/namespaces/default/pods" && m == "POST":
+				return newResponse(200, &listB.Items[1])
+			case p == "/namespaces/default/pods/squid" && m == "GET":
+				return newResponse(200, &listA.Items[2])
+			case p == "/namespaces/default/pods/crab" && m == "GET":
+				return newResponse(200, &listA.Items[3])
+			case p == "/namespaces/default/pods/crab" && m == "PATCH":
+				data, err := ioutil.ReadAll(req.Body)
+				if err != nil {
+					t.Fatalf("could not dump request: %s", err)
+				}
+				req.Body.Close()
+				expected := `{"metadata":{"annotations":{"meta.helm.sh/release-name":null,"meta.helm.sh/release-namespace":null},"labels":{"app.kubernetes.io/managed-by":null}}}`
+				if string(data) != expected {
+					t.Errorf("expected patch\n%s\ngot\n%s", expected, string(data))
+				}
+				return newResponse(200, &listA.Items[3])
+			default:
+				t.Fatalf("unexpected request: %s %s", req.Method, req.URL.Path)
+				return nil, nil
+			}
+		}),
+	}
+	first, err := c.Build(objBody(&listA), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Build(objBody(&listB), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := c.Update(first, second, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, list := range map[string]ResourceList{
+		"updated":   result.Updated,
+		"deleted":   result.Deleted,
+		"skipped":   result.Skipped,
+		"recreated": result.Recreated,
+		"kept":      result.Kept,
+		"orphaned":  result.Orphaned,
+	} {
+		expected := map[string]int{"skipped": 1, "recreated": 1, "kept": 1, "orphaned": 1}[name]
+		if len(list) != expected {
+			t.Errorf("expected %d resources %s, got %d", expected, name, len(list))
+		}
+	}
+
+	expectedActions := []string{
+		"/namespaces/default/pods/starfish:GET",
+		"/namespaces/default/pods/starfish:GET",
+		"/namespaces/default/pods/otter:GET",
+		"/namespaces/default/pods/otter:GET",
+		"/namespaces/default/pods/otter:DELETE",
+		"/namespaces/default/pods/otter:GET",
+		"/namespaces/default/pods:POST",
+		"/namespaces/default/pods/squid:GET",
+		"/namespaces/default/pods/crab:GET",
+		"/namespaces/default/pods/crab:PATCH",
+	}
+	if strings.Join(expectedActions, "\n") != strings.Join(actions, "\n") {
+		t.Errorf("expected requests\n%s\ngot\n%s", strings.Join(expectedActions, "\n"), strings.Join(actions, "\n"))
+	}
+}
+
+func TestUpdateRecreateTimeout(t *testing.T) {
+	recreatePollInterval = time.Millisecond
+	defer func() { recreatePollInterval = time.Second }()
+
+	listA := newPodList()
+	listA.Items = []v1.Pod{newPod("otter")}
+	listA.Items[0].Annotations = map[string]string{ResourcePolicyAnno: RecreatePolicy}
+	listB := newPodList()
+	listB.Items = []v1.Pod{listA.Items[0]}
+	listB.Items[0].Spec = *listB.Items[0].Spec.DeepCopy()
+	listB.Items[0].Spec.Containers[0].Ports = []v1.ContainerPort{{Name: "https", ContainerPort: 443}}
+
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			p, m := req.URL.Path, req.Method
+			switch {
+			case p == "/namespaces/default/pods/otter" && (m == "GET" || m == "DELETE"):
+				// the pod is never gone, as if held by a finalizer
+				return newResponse(200, &listA.Items[0])
+			default:
+				t.Fatalf("unexpected request: %s %s", req.Method, req.URL.Path)
+				return nil, nil
+			}
+		}),
+	}
+	first, err := c.Build(objBody(&listA), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Build(objBody(&listB), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.UpdateWithContext(context.Background(), first, second, false, 20*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), `timed out waiting for "otter" with kind Pod to be deleted`) {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+	// the wait ends as soon as the operation is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.UpdateWithContext(ctx, first, second, false, time.Hour)
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("expected a cancellation error, got %v", err)
+	}
+}
+
+func TestWaitForDelete(t *testing.T) {
+	recreatePollInterval = time.Millisecond
+	defer func() { recreatePollInterval = time.Second }()
+
+	list := newPodList()
+	list.Items = []v1.Pod{newPod("starfish")}
+	gets, goneAfter := 0, 3
+
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path != "/namespaces/default/pods/starfish" || req.Method != "GET" {
+				t.Fatalf("unexpected request: %s %s", req.Method, req.URL.Path)
+			}
+			gets++
+			if gets >= goneAfter {
+				return newResponse(404, notFoundBody())
+			}
+			return newResponse(200, &list.Items[0])
+		}),
+	}
+	resources, err := c.Build(objBody(&list), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.WaitForDelete(context.Background(), resources, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if gets != goneAfter {
+		t.Errorf("expected the pod to be polled until it is gone, got %d requests", gets)
+	}
+
+	gets, goneAfter = 0, math.MaxInt32
+	err = c.WaitForDelete(context.Background(), resources, 20*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), `timed out waiting for "starfish" with kind Pod to be deleted`) {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.WaitForDelete(ctx, resources, time.Minute); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	pods := newPodList("starfish")
+	var patched []string
+
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if req.Method != "PATCH" {
+				t.Fatalf("unexpected request: %s %s", req.Method, req.URL.Path)
+			}
+			data, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				t.Fatalf("could not dump request: %s", err)
+			}
+			req.Body.Close()
+			expected := `{"metadata":{"annotations":{"meta.helm.sh/release-name":"new","meta.helm.sh/release-namespace":"apps"},"labels":{"app.kubernetes.io/managed-by":"Helm"}}}`
+			if string(data) != expected {
+				t.Errorf("expected patch\n%s\ngot\n%s", expected, string(data))
+			}
+			patched = append(patched, req.URL.Path)
+			return newResponse(200, &pods.Items[0])
+		}),
+	}
+	resources, err := c.Build(objBody(&pods), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := c.Transfer(resources, "new", "apps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Updated) != 1 || len(patched) != 1 {
+		t.Errorf("expected 1 resource transferred, got %d with %d patches", len(result.Updated), len(patched))
+	}
+}
+
+func TestHasResourcePolicy(t *testing.T) {
+	annotations := map[string]string{ResourcePolicyAnno: "Keep, never-update"}
+	if !HasResourcePolicy(annotations, KeepPolicy) || !HasResourcePolicy(annotations, NeverUpdatePolicy) {
+		t.Errorf("expected %q to contain the keep and never-update policies", annotations[ResourcePolicyAnno])
+	}
+	if HasResourcePolicy(annotations, RecreatePolicy) || HasResourcePolicy(nil, KeepPolicy) {
+		t.Error("expected no recreate policy")
+	}
+}
+
+func TestUpdateServerSide(t *testing.T) {
+	listA := newPodList("starfish", "otter")
+	listB := newPodList("starfish", "otter", "dolphin")
+
+	var actions []string
+
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			p, m := req.URL.Path, req.Method
+			actions = append(actions, p+":"+m)
+			t.Logf("got request %s %s", p, m)
+			if m == "PATCH" {
+				if ct := req.Header.Get("Content-Type"); ct != string(types.ApplyPatchType) {
+					t.Errorf("expected content type %q, got %q", types.ApplyPatchType, ct)
+				}
+				if fm := req.URL.Query().Get("fieldManager"); fm != ServerSideApplyFieldManager {
+					t.Errorf("expected field manager %q, got %q", ServerSideApplyFieldManager, fm)
+				}
+				if force := req.URL.Query().Get("force"); force != "false" {
+					t.Errorf("expected force=false, got %q", force)
+				}
+			}
+			switch {
+			case p == "/namespaces/default/pods/starfish" && m == "GET":
+				return newResponse(200, &listA.Items[0])
+			case p == "/namespaces/default/pods/starfish" && m == "PATCH":
+				return newResponse(200, &listB.Items[0])
+			case p == "/namespaces/default/pods/otter" && m == "GET":
+				return newResponse(200, &listA.Items[1])
+			case p == "/namespaces/default/pods/otter" && m == "PATCH":
+				return newResponse(409, &metav1.Status{
+					Code:    http.StatusConflict,
+					Status:  metav1.StatusFailure,
+					Reason:  metav1.StatusReasonConflict,
+					Message: "Apply failed with 1 conflict: conflict with \"kubectl\": .spec.containers",
+					Details: &metav1.StatusDetails{
+						Causes: []metav1.StatusCause{{
+							Type:    metav1.CauseTypeFieldManagerConflict,
+							Message: "conflict with \"kubectl\"",
+							Field:   ".spec.containers",
+						}},
+					},
+				})
+			case p == "/namespaces/default/pods/dolphin" && m == "GET":
+				return newResponse(404, notFoundBody())
+			case p == "/namespaces/default/pods/dolphin" && m == "PATCH":
+				return newResponse(201, &listB.Items[2])
+			default:
+				t.Fatalf("unexpected request: %s %s", req.Method, req.URL.Path)
+				return nil, nil
+			}
+		}),
+	}
+	first, err := c.Build(objBody(&listA), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Build(objBody(&listB), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := c.UpdateServerSide(first, second, false)
+	if err == nil {
+		t.Fatal("expected an error for the conflicting resource")
+	}
+
+	if len(result.Created) != 1 {
+		t.Errorf("expected 1 resource created, got %d", len(result.Created))
+	}
+	if len(result.Updated) != 2 {
+		t.Errorf("expected 2 resource updated, got %d", len(result.Updated))
+	}
+	if len(result.Conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %d", len(result.Conflicts))
+	}
+	if name := result.Conflicts[0].Resource.Name; name != "otter" {
+		t.Errorf("expected conflict for otter, got %s", name)
+	}
+	if fields := result.Conflicts[0].Fields; len(fields) != 1 || fields[0] != ".spec.containers" {
+		t.Errorf("unexpected conflicting fields %v", fields)
+	}
+
+	expectedActions := []string{
+		"/namespaces/default/pods/starfish:GET",
+		"/namespaces/default/pods/starfish:PATCH",
+		"/namespaces/default/pods/otter:GET",
+		"/namespaces/default/pods/otter:PATCH",
+		"/namespaces/default/pods/dolphin:GET",
+		"/namespaces/default/pods/dolphin:PATCH",
+	}
+	if len(expectedActions) != len(actions) {
+		t.Fatalf("unexpected number of requests, expected %d, got %d", len(expectedActions), len(actions))
+	}
+	for k, v := range expectedActions {
+		if actions[k] != v {
+			t.Errorf("expected %s request got %s", v, actions[k])
+		}
+	}
+}
+
 func TestBuild(t *testing.T) {
 	tests := []struct {
 		name      string
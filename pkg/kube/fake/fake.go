@@ -18,6 +18,7 @@ limitations under the License.
 package fake
 
 import (
+	"context"
 	"io"
 	"time"
 
@@ -40,6 +41,7 @@ type FailingKubeClient struct {
 	BuildError                       error
 	BuildUnstructuredError           error
 	WaitAndGetCompletedPodPhaseError error
+	TransferError                    error
 }
 
 // Create returns the configured error if set or prints
@@ -66,6 +68,30 @@ func (f *FailingKubeClient) WaitWithJobs(resources kube.ResourceList, d time.Dur
 	return f.PrintingKubeClient.Wait(resources, d)
 }
 
+// WaitWithContext returns the configured error if set or prints
+func (f *FailingKubeClient) WaitWithContext(ctx context.Context, resources kube.ResourceList, d time.Duration) error {
+	if f.WaitError != nil {
+		return f.WaitError
+	}
+	return f.PrintingKubeClient.WaitWithContext(ctx, resources, d)
+}
+
+// WaitWithJobsWithContext returns the configured error if set or prints
+func (f *FailingKubeClient) WaitWithJobsWithContext(ctx context.Context, resources kube.ResourceList, d time.Duration) error {
+	if f.WaitError != nil {
+		return f.WaitError
+	}
+	return f.PrintingKubeClient.WaitWithJobsWithContext(ctx, resources, d)
+}
+
+// WaitWithProgress returns the configured error if set or prints
+func (f *FailingKubeClient) WaitWithProgress(ctx context.Context, resources kube.ResourceList, d time.Duration, waitForJobs bool, progress kube.WaitProgressFunc) error {
+	if f.WaitError != nil {
+		return f.WaitError
+	}
+	return f.PrintingKubeClient.WaitWithProgress(ctx, resources, d, waitForJobs, progress)
+}
+
 // Delete returns the configured error if set or prints
 func (f *FailingKubeClient) Delete(resources kube.ResourceList) (*kube.Result, []error) {
 	if f.DeleteError != nil {
@@ -74,6 +100,14 @@ func (f *FailingKubeClient) Delete(resources kube.ResourceList) (*kube.Result, [
 	return f.PrintingKubeClient.Delete(resources)
 }
 
+// Transfer returns the configured error if set or prints
+func (f *FailingKubeClient) Transfer(resources kube.ResourceList, releaseName, releaseNamespace string) (*kube.Result, error) {
+	if f.TransferError != nil {
+		return nil, f.TransferError
+	}
+	return f.PrintingKubeClient.Transfer(resources, releaseName, releaseNamespace)
+}
+
 // WatchUntilReady returns the configured error if set or prints
 func (f *FailingKubeClient) WatchUntilReady(resources kube.ResourceList, d time.Duration) error {
 	if f.WatchUntilReadyError != nil {
@@ -82,6 +116,14 @@ func (f *FailingKubeClient) WatchUntilReady(resources kube.ResourceList, d time.
 	return f.PrintingKubeClient.WatchUntilReady(resources, d)
 }
 
+// WatchUntilReadyWithContext returns the configured error if set or prints
+func (f *FailingKubeClient) WatchUntilReadyWithContext(ctx context.Context, resources kube.ResourceList, d time.Duration) error {
+	if f.WatchUntilReadyError != nil {
+		return f.WatchUntilReadyError
+	}
+	return f.PrintingKubeClient.WatchUntilReadyWithContext(ctx, resources, d)
+}
+
 // Update returns the configured error if set or prints
 func (f *FailingKubeClient) Update(r, modified kube.ResourceList, ignoreMe bool) (*kube.Result, error) {
 	if f.UpdateError != nil {
@@ -90,6 +132,30 @@ func (f *FailingKubeClient) Update(r, modified kube.ResourceList, ignoreMe bool)
 	return f.PrintingKubeClient.Update(r, modified, ignoreMe)
 }
 
+// UpdateServerSide returns the configured error if set or prints
+func (f *FailingKubeClient) UpdateServerSide(r, modified kube.ResourceList, forceConflicts bool) (*kube.Result, error) {
+	if f.UpdateError != nil {
+		return &kube.Result{}, f.UpdateError
+	}
+	return f.PrintingKubeClient.UpdateServerSide(r, modified, forceConflicts)
+}
+
+// UpdateWithContext returns the configured error if set or prints
+func (f *FailingKubeClient) UpdateWithContext(ctx context.Context, r, modified kube.ResourceList, ignoreMe bool, timeout time.Duration) (*kube.Result, error) {
+	if f.UpdateError != nil {
+		return &kube.Result{}, f.UpdateError
+	}
+	return f.PrintingKubeClient.UpdateWithContext(ctx, r, modified, ignoreMe, timeout)
+}
+
+// UpdateServerSideWithContext returns the configured error if set or prints
+func (f *FailingKubeClient) UpdateServerSideWithContext(ctx context.Context, r, modified kube.ResourceList, forceConflicts bool, timeout time.Duration) (*kube.Result, error) {
+	if f.UpdateError != nil {
+		return &kube.Result{}, f.UpdateError
+	}
+	return f.PrintingKubeClient.UpdateServerSideWithContext(ctx, r, modified, forceConflicts, timeout)
+}
+
 // Build returns the configured error if set or prints
 func (f *FailingKubeClient) Build(r io.Reader, _ bool) (kube.ResourceList, error) {
 	if f.BuildError != nil {
@@ -105,3 +171,11 @@ func (f *FailingKubeClient) WaitAndGetCompletedPodPhase(s string, d time.Duratio
 	}
 	return f.PrintingKubeClient.WaitAndGetCompletedPodPhase(s, d)
 }
+
+// WaitAndGetCompletedPodPhaseWithContext returns the configured error if set or prints
+func (f *FailingKubeClient) WaitAndGetCompletedPodPhaseWithContext(ctx context.Context, s string, d time.Duration) (v1.PodPhase, error) {
+	if f.WaitAndGetCompletedPodPhaseError != nil {
+		return v1.PodSucceeded, f.WaitAndGetCompletedPodPhaseError
+	}
+	return f.PrintingKubeClient.WaitAndGetCompletedPodPhaseWithContext(ctx, s, d)
+}
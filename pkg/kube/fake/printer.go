@@ -17,6 +17,7 @@ limitations under the License.
 package fake
 
 import (
+	"context"
 	"io"
 	"strings"
 	"time"
@@ -57,6 +58,39 @@ func (p *PrintingKubeClient) WaitWithJobs(resources kube.ResourceList, _ time.Du
 	return err
 }
 
+// WaitWithContext implements KubeClient WaitWithContext.
+//
+// It returns the context's error if the context is already cancelled.
+func (p *PrintingKubeClient) WaitWithContext(ctx context.Context, resources kube.ResourceList, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return p.Wait(resources, d)
+}
+
+// WaitWithJobsWithContext implements KubeClient WaitWithJobsWithContext.
+func (p *PrintingKubeClient) WaitWithJobsWithContext(ctx context.Context, resources kube.ResourceList, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return p.WaitWithJobs(resources, d)
+}
+
+// WaitWithProgress implements KubeClient WaitWithProgress.
+//
+// It reports every resource as ready.
+func (p *PrintingKubeClient) WaitWithProgress(ctx context.Context, resources kube.ResourceList, d time.Duration, waitForJobs bool, progress kube.WaitProgressFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if progress != nil {
+		for _, r := range resources {
+			progress(kube.WaitEvent{Kind: r.Mapping.GroupVersionKind.Kind, Namespace: r.Namespace, Name: r.Name, Ready: true})
+		}
+	}
+	return p.Wait(resources, d)
+}
+
 // Delete implements KubeClient delete.
 //
 // It only prints out the content to be deleted.
@@ -68,12 +102,47 @@ func (p *PrintingKubeClient) Delete(resources kube.ResourceList) (*kube.Result,
 	return &kube.Result{Deleted: resources}, nil
 }
 
+// Orphan implements KubeClient Orphan.
+//
+// It only prints out the content to be orphaned.
+func (p *PrintingKubeClient) Orphan(resources kube.ResourceList) (*kube.Result, []error) {
+	_, err := io.Copy(p.Out, bufferize(resources))
+	if err != nil {
+		return nil, []error{err}
+	}
+	return &kube.Result{Orphaned: resources}, nil
+}
+
+// Transfer implements KubeClient Transfer.
+//
+// It only prints out the content to be transferred.
+func (p *PrintingKubeClient) Transfer(resources kube.ResourceList, _, _ string) (*kube.Result, error) {
+	_, err := io.Copy(p.Out, bufferize(resources))
+	if err != nil {
+		return nil, err
+	}
+	return &kube.Result{Updated: resources}, nil
+}
+
+// WaitForDelete implements KubeClient WaitForDelete.
+func (p *PrintingKubeClient) WaitForDelete(ctx context.Context, _ kube.ResourceList, _ time.Duration) error {
+	return ctx.Err()
+}
+
 // WatchUntilReady implements KubeClient WatchUntilReady.
 func (p *PrintingKubeClient) WatchUntilReady(resources kube.ResourceList, _ time.Duration) error {
 	_, err := io.Copy(p.Out, bufferize(resources))
 	return err
 }
 
+// WatchUntilReadyWithContext implements KubeClient WatchUntilReadyWithContext.
+func (p *PrintingKubeClient) WatchUntilReadyWithContext(ctx context.Context, resources kube.ResourceList, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return p.WatchUntilReady(resources, d)
+}
+
 // Update implements KubeClient Update.
 func (p *PrintingKubeClient) Update(_, modified kube.ResourceList, _ bool) (*kube.Result, error) {
 	_, err := io.Copy(p.Out, bufferize(modified))
@@ -86,6 +155,21 @@ func (p *PrintingKubeClient) Update(_, modified kube.ResourceList, _ bool) (*kub
 	return &kube.Result{Updated: modified}, nil
 }
 
+// UpdateServerSide implements KubeClient UpdateServerSide.
+func (p *PrintingKubeClient) UpdateServerSide(original, modified kube.ResourceList, _ bool) (*kube.Result, error) {
+	return p.Update(original, modified, false)
+}
+
+// UpdateWithContext implements KubeClient UpdateWithContext.
+func (p *PrintingKubeClient) UpdateWithContext(_ context.Context, original, modified kube.ResourceList, force bool, _ time.Duration) (*kube.Result, error) {
+	return p.Update(original, modified, force)
+}
+
+// UpdateServerSideWithContext implements KubeClient UpdateServerSideWithContext.
+func (p *PrintingKubeClient) UpdateServerSideWithContext(_ context.Context, original, modified kube.ResourceList, forceConflicts bool, _ time.Duration) (*kube.Result, error) {
+	return p.UpdateServerSide(original, modified, forceConflicts)
+}
+
 // Build implements KubeClient Build.
 func (p *PrintingKubeClient) Build(_ io.Reader, _ bool) (kube.ResourceList, error) {
 	return []*resource.Info{}, nil
@@ -96,6 +180,14 @@ func (p *PrintingKubeClient) WaitAndGetCompletedPodPhase(_ string, _ time.Durati
 	return v1.PodSucceeded, nil
 }
 
+// WaitAndGetCompletedPodPhaseWithContext implements KubeClient WaitAndGetCompletedPodPhaseWithContext.
+func (p *PrintingKubeClient) WaitAndGetCompletedPodPhaseWithContext(ctx context.Context, name string, d time.Duration) (v1.PodPhase, error) {
+	if err := ctx.Err(); err != nil {
+		return v1.PodUnknown, err
+	}
+	return p.WaitAndGetCompletedPodPhase(name, d)
+}
+
 func bufferize(resources kube.ResourceList) io.Reader {
 	var builder strings.Builder
 	for _, info := range resources {
@@ -17,6 +17,7 @@ limitations under the License.
 package kube
 
 import (
+	"context"
 	"io"
 	"time"
 
@@ -70,4 +71,99 @@ type Interface interface {
 	IsReachable() error
 }
 
+// InterfaceServerSideApply is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceServerSideApply and integrate its method(s) into the Interface.
+type InterfaceServerSideApply interface {
+	// UpdateServerSide updates one or more resources or creates the resource
+	// if it doesn't exist, using server-side apply instead of a client-side
+	// patch. If forceConflicts is true, fields owned by other field managers
+	// are taken over; otherwise conflicts are reported in the Result.
+	UpdateServerSide(original, target ResourceList, forceConflicts bool) (*Result, error)
+}
+
+// InterfaceWithContext is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// Its methods behave like their counterparts in Interface, but return as soon
+// as the given context is cancelled.
+//
+// TODO Helm 4: Remove InterfaceWithContext and integrate its method(s) into the Interface.
+type InterfaceWithContext interface {
+	// WaitWithContext waits up to the given timeout for the specified resources to be ready.
+	WaitWithContext(ctx context.Context, resources ResourceList, timeout time.Duration) error
+
+	// WaitWithJobsWithContext wait up to the given timeout for the specified resources to be ready, including jobs.
+	WaitWithJobsWithContext(ctx context.Context, resources ResourceList, timeout time.Duration) error
+
+	// WatchUntilReadyWithContext watches the resources given and waits until it is ready.
+	WatchUntilReadyWithContext(ctx context.Context, resources ResourceList, timeout time.Duration) error
+
+	// WaitAndGetCompletedPodPhaseWithContext waits up to a timeout until a pod enters a completed phase
+	// and returns said phase (PodSucceeded or PodFailed qualify).
+	WaitAndGetCompletedPodPhaseWithContext(ctx context.Context, name string, timeout time.Duration) (v1.PodPhase, error)
+}
+
+// InterfaceOrphan is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceOrphan and integrate its method(s) into the Interface.
+type InterfaceOrphan interface {
+	// Orphan strips the labels and annotations recording the ownership of
+	// the resources by a release, leaving them unmanaged in the cluster.
+	Orphan(resources ResourceList) (*Result, []error)
+}
+
+// InterfaceTransfer is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceTransfer and integrate its method(s) into the Interface.
+type InterfaceTransfer interface {
+	// Transfer rewrites the labels and annotations recording the ownership
+	// of the resources so that they are owned by the given release, without
+	// otherwise changing the live objects.
+	Transfer(resources ResourceList, releaseName, releaseNamespace string) (*Result, error)
+}
+
+// InterfaceWaitProgress is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceWaitProgress and integrate its method(s) into the Interface.
+type InterfaceWaitProgress interface {
+	// WaitWithProgress waits up to the given timeout for the specified
+	// resources to be ready, including jobs if waitForJobs is set, and
+	// reports the readiness of the resources to progress whenever it
+	// changes.
+	WaitWithProgress(ctx context.Context, resources ResourceList, timeout time.Duration, waitForJobs bool, progress WaitProgressFunc) error
+}
+
+// InterfaceDeletionWait is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceDeletionWait and integrate its method(s) into the Interface.
+type InterfaceDeletionWait interface {
+	// WaitForDelete waits up to the given timeout for the specified resources
+	// to be gone from the cluster, such as after Delete, which does not wait
+	// for finalizers and graceful deletion.
+	WaitForDelete(ctx context.Context, resources ResourceList, timeout time.Duration) error
+}
+
+// InterfaceUpdateWithContext is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceUpdateWithContext and integrate its method(s) into the Interface.
+type InterfaceUpdateWithContext interface {
+	// UpdateWithContext behaves like Update, waiting up to the given timeout,
+	// or until the context is cancelled, for the resources with the recreate
+	// policy to be deleted before they are created again.
+	UpdateWithContext(ctx context.Context, original, target ResourceList, force bool, timeout time.Duration) (*Result, error)
+
+	// UpdateServerSideWithContext behaves like UpdateServerSide, waiting up
+	// to the given timeout, or until the context is cancelled, for the
+	// resources with the recreate policy to be deleted before they are
+	// created again.
+	UpdateServerSideWithContext(ctx context.Context, original, target ResourceList, forceConflicts bool, timeout time.Duration) (*Result, error)
+}
+
 var _ Interface = (*Client)(nil)
+var _ InterfaceServerSideApply = (*Client)(nil)
+var _ InterfaceWithContext = (*Client)(nil)
+var _ InterfaceOrphan = (*Client)(nil)
+var _ InterfaceTransfer = (*Client)(nil)
+var _ InterfaceWaitProgress = (*Client)(nil)
+var _ InterfaceDeletionWait = (*Client)(nil)
+var _ InterfaceUpdateWithContext = (*Client)(nil)
@@ -18,6 +18,8 @@ package kube // import "helm.sh/helm/v3/pkg/kube"
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	appsv1beta1 "k8s.io/api/apps/v1beta1"
@@ -83,8 +85,12 @@ type ReadyChecker struct {
 
 // IsReady checks if v is ready. It supports checking readiness for pods,
 // deployments, persistent volume claims, services, daemon sets, custom
-// resource definitions, stateful sets, replication controllers, and replica
-// sets. All other resource kinds are always considered ready.
+// resource definitions, stateful sets, replication controllers, replica
+// sets, ingresses, horizontal pod autoscalers, pod disruption budgets and API
+// services. Any resource can also be annotated with the readiness rules of
+// ReadyConditionAnno and ReadyJSONPathAnno, such as custom resources
+// reporting their readiness in their status. All other resource kinds are
+// always considered ready.
 //
 // IsReady will fetch the latest state of the object from the server prior to
 // performing readiness checks, and it will return any error encountered.
@@ -186,6 +192,14 @@ func (c *ReadyChecker) IsReady(ctx context.Context, v *resource.Info) (bool, err
 	if !ok || err != nil {
 		return false, err
 	}
+	reason, err := c.statusNotReadyReason(v)
+	if err != nil {
+		return false, err
+	}
+	if reason != "" {
+		c.log("Resource is not ready: %s/%s: %s", v.Namespace, v.Name, reason)
+		return false, nil
+	}
 	return true, nil
 }
 
@@ -389,6 +403,101 @@ func (c *ReadyChecker) statefulSetReady(sts *appsv1.StatefulSet) bool {
 	return true
 }
 
+// NotReadyReasons returns the reasons why v is not ready. For pods and the
+// resources managing pods, deployments, replica sets, replication
+// controllers, stateful sets, daemon sets and jobs, these are the reasons the
+// pods are not ready, such as image pull errors and crash loops. For the other
+// resources checked against their status, such as ingresses and resources
+// with readiness rules, this is the status that is not satisfied.
+//
+// v must hold the latest state of the object, as the selectors of some
+// resources, such as jobs, are set by the server.
+func (c *ReadyChecker) NotReadyReasons(ctx context.Context, v *resource.Info) ([]string, error) {
+	var reasons []string
+	switch value := AsVersioned(v).(type) {
+	case *corev1.Pod:
+		pod, err := c.client.CoreV1().Pods(v.Namespace).Get(ctx, v.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		reasons = PodNotReadyReasons(pod)
+	case *appsv1.Deployment, *appsv1beta1.Deployment, *appsv1beta2.Deployment, *extensionsv1beta1.Deployment,
+		*corev1.ReplicationController, *extensionsv1beta1.ReplicaSet, *appsv1beta2.ReplicaSet, *appsv1.ReplicaSet,
+		*appsv1.StatefulSet, *appsv1beta1.StatefulSet, *appsv1beta2.StatefulSet,
+		*extensionsv1beta1.DaemonSet, *appsv1.DaemonSet, *appsv1beta2.DaemonSet, *batchv1.Job:
+		pods, err := c.podsforObject(ctx, v.Namespace, value)
+		if err != nil {
+			return nil, err
+		}
+		for i := range pods {
+			for _, r := range PodNotReadyReasons(&pods[i]) {
+				reasons = append(reasons, fmt.Sprintf("pod %s: %s", pods[i].Name, r))
+			}
+		}
+	}
+	reason, err := c.statusNotReadyReason(v)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		reasons = append(reasons, reason)
+	}
+	return reasons, nil
+}
+
+// PodNotReadyReasons returns the reasons why a pod is not ready: the reasons
+// its containers are waiting or terminated, why it cannot be scheduled, or why
+// it failed. Ready and succeeded pods have no reasons.
+func PodNotReadyReasons(pod *corev1.Pod) []string {
+	if pod.Status.Phase == corev1.PodSucceeded {
+		return nil
+	}
+	var reasons []string
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+			return nil
+		}
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
+			reasons = append(reasons, joinReason(cond.Reason, cond.Message))
+		}
+	}
+	if pod.Status.Phase == corev1.PodFailed {
+		reasons = append(reasons, joinReason("Failed", joinReason(pod.Status.Reason, pod.Status.Message)))
+	}
+
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	for _, cs := range append(statuses, pod.Status.ContainerStatuses...) {
+		switch {
+		case cs.State.Waiting != nil && cs.State.Waiting.Reason != "" && cs.State.Waiting.Reason != "PodInitializing":
+			reasons = append(reasons, fmt.Sprintf("container %s: %s", cs.Name, joinReason(cs.State.Waiting.Reason, cs.State.Waiting.Message)))
+		case cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0:
+			reasons = append(reasons, fmt.Sprintf("container %s: %s with exit code %d", cs.Name,
+				joinReason(cs.State.Terminated.Reason, cs.State.Terminated.Message), cs.State.Terminated.ExitCode))
+		case cs.State.Running != nil && !cs.Ready:
+			reasons = append(reasons, fmt.Sprintf("container %s: not ready", cs.Name))
+		}
+	}
+	if len(reasons) == 0 {
+		phase := "not ready"
+		if pod.Status.Phase != "" {
+			phase = strings.ToLower(string(pod.Status.Phase))
+		}
+		reasons = append(reasons, "pod is "+phase)
+	}
+	return reasons
+}
+
+// joinReason joins a reason and its message.
+func joinReason(reason, message string) string {
+	switch {
+	case reason == "":
+		return message
+	case message == "":
+		return reason
+	}
+	return reason + ": " + message
+}
+
 func getPods(ctx context.Context, client kubernetes.Interface, namespace, selector string) ([]corev1.Pod, error) {
 	list, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: selector,
@@ -0,0 +1,242 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// ReadyConditionAnno is the annotation name for the type of a status condition
+// that must be True for a resource to be ready, such as "Ready" or "Available".
+//
+// The condition is looked up in status.conditions, the convention followed by
+// most operators for their custom resources.
+const ReadyConditionAnno = "helm.sh/ready-condition"
+
+// ReadyJSONPathAnno is the annotation name for a JSONPath expression, such as
+// "{.status.phase}", evaluated against a resource to check that it is ready.
+//
+// The resource is ready when the expression evaluates to the value of the
+// ReadyValueAnno annotation or, if that annotation is not set, to a value
+// other than an empty string and "false".
+const ReadyJSONPathAnno = "helm.sh/ready-jsonpath"
+
+// ReadyValueAnno is the annotation name for the value the expression of the
+// ReadyJSONPathAnno annotation must evaluate to for a resource to be ready.
+const ReadyValueAnno = "helm.sh/ready-value"
+
+// readyRules are the rules set by annotations that a resource must satisfy to
+// be ready.
+type readyRules struct {
+	condition string
+	jsonPath  string
+	value     *string
+}
+
+// readyRulesFor returns the readiness rules of a resource, and whether it has
+// any. API services must report the Available condition unless annotated
+// otherwise.
+func readyRulesFor(info *resource.Info) (readyRules, bool) {
+	var rules readyRules
+	if annotations, err := metadataAccessor.Annotations(info.Object); err == nil {
+		rules.condition = annotations[ReadyConditionAnno]
+		rules.jsonPath = annotations[ReadyJSONPathAnno]
+		if value, ok := annotations[ReadyValueAnno]; ok {
+			rules.value = &value
+		}
+	}
+	if rules.condition == "" && info.Mapping != nil {
+		gvk := info.Mapping.GroupVersionKind
+		if gvk.Group == "apiregistration.k8s.io" && gvk.Kind == "APIService" {
+			rules.condition = "Available"
+		}
+	}
+	return rules, rules.condition != "" || rules.jsonPath != ""
+}
+
+// notReadyReason returns why the given object does not satisfy the rules, or
+// an empty string if it does.
+func (r readyRules) notReadyReason(obj map[string]interface{}) (string, error) {
+	if r.condition != "" {
+		if reason := conditionNotReadyReason(obj, r.condition); reason != "" {
+			return reason, nil
+		}
+	}
+	if r.jsonPath == "" {
+		return "", nil
+	}
+	expr := r.jsonPath
+	if !strings.Contains(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+	jp := jsonpath.New("ready").AllowMissingKeys(true)
+	if err := jp.Parse(expr); err != nil {
+		return "", errors.Wrapf(err, "invalid %s annotation %q", ReadyJSONPathAnno, r.jsonPath)
+	}
+	var buf bytes.Buffer
+	if err := jp.Execute(&buf, obj); err != nil {
+		return "", errors.Wrapf(err, "cannot evaluate %s annotation %q", ReadyJSONPathAnno, r.jsonPath)
+	}
+	got := buf.String()
+	switch {
+	case r.value != nil && got != *r.value:
+		return fmt.Sprintf("%s is %q, expected %q", r.jsonPath, got, *r.value), nil
+	case r.value == nil && (got == "" || got == "false"):
+		return fmt.Sprintf("%s is %q", r.jsonPath, got), nil
+	}
+	return "", nil
+}
+
+// conditionNotReadyReason returns why the condition of the given type in
+// status.conditions is not True for the current generation of the object, or
+// an empty string if it is.
+func conditionNotReadyReason(obj map[string]interface{}, conditionType string) string {
+	conditions, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != conditionType {
+			continue
+		}
+		status, _ := cond["status"].(string)
+		if status != string(corev1.ConditionTrue) {
+			reason, _ := cond["reason"].(string)
+			message, _ := cond["message"].(string)
+			return joinReason(fmt.Sprintf("condition %s is %s", conditionType, status), joinReason(reason, message))
+		}
+		generation, _, _ := unstructured.NestedInt64(obj, "metadata", "generation")
+		if observed, ok, _ := unstructured.NestedInt64(cond, "observedGeneration"); ok && observed < generation {
+			return fmt.Sprintf("condition %s has not observed generation %d", conditionType, generation)
+		}
+		return ""
+	}
+	return fmt.Sprintf("condition %s is not reported", conditionType)
+}
+
+// hasStatusReadiness reports whether the readiness of a resource is checked
+// against its status by statusNotReadyReason.
+func hasStatusReadiness(obj runtime.Object) bool {
+	switch obj.(type) {
+	case *networkingv1.Ingress, *networkingv1beta1.Ingress, *extensionsv1beta1.Ingress,
+		*autoscalingv1.HorizontalPodAutoscaler, *autoscalingv2beta1.HorizontalPodAutoscaler, *autoscalingv2beta2.HorizontalPodAutoscaler,
+		*policyv1.PodDisruptionBudget, *policyv1beta1.PodDisruptionBudget:
+		return true
+	}
+	return false
+}
+
+// statusNotReadyReason fetches the latest state of ingresses, horizontal pod
+// autoscalers, pod disruption budgets and resources with readiness rules, and
+// returns why they are not ready, or an empty string if they are. Other
+// resources are not fetched. The given resource is left unchanged.
+func (c *ReadyChecker) statusNotReadyReason(info *resource.Info) (string, error) {
+	rules, hasRules := readyRulesFor(info)
+	if !hasRules && !hasStatusReadiness(AsVersioned(info)) {
+		return "", nil
+	}
+	latest := *info
+	v := &latest
+	if err := v.Get(); err != nil {
+		return "", err
+	}
+
+	var reason string
+	switch obj := AsVersioned(v).(type) {
+	case *networkingv1.Ingress:
+		reason = ingressNotReadyReason(obj.Status.LoadBalancer)
+	case *networkingv1beta1.Ingress:
+		reason = ingressNotReadyReason(obj.Status.LoadBalancer)
+	case *extensionsv1beta1.Ingress:
+		reason = ingressNotReadyReason(obj.Status.LoadBalancer)
+	case *autoscalingv1.HorizontalPodAutoscaler:
+		reason = hpaNotReadyReason(obj.Spec.MinReplicas, obj.Status.CurrentReplicas, obj.Status.DesiredReplicas)
+	case *autoscalingv2beta1.HorizontalPodAutoscaler:
+		reason = hpaNotReadyReason(obj.Spec.MinReplicas, obj.Status.CurrentReplicas, obj.Status.DesiredReplicas)
+		for _, cond := range obj.Status.Conditions {
+			if reason == "" && cond.Type == autoscalingv2beta1.ScalingActive && cond.Status == corev1.ConditionFalse {
+				reason = joinReason("ScalingActive is False", joinReason(cond.Reason, cond.Message))
+			}
+		}
+	case *autoscalingv2beta2.HorizontalPodAutoscaler:
+		reason = hpaNotReadyReason(obj.Spec.MinReplicas, obj.Status.CurrentReplicas, obj.Status.DesiredReplicas)
+		for _, cond := range obj.Status.Conditions {
+			if reason == "" && cond.Type == autoscalingv2beta2.ScalingActive && cond.Status == corev1.ConditionFalse {
+				reason = joinReason("ScalingActive is False", joinReason(cond.Reason, cond.Message))
+			}
+		}
+	case *policyv1.PodDisruptionBudget:
+		reason = pdbNotReadyReason(obj.Generation, obj.Status.ObservedGeneration, obj.Status.CurrentHealthy, obj.Status.DesiredHealthy)
+	case *policyv1beta1.PodDisruptionBudget:
+		reason = pdbNotReadyReason(obj.Generation, obj.Status.ObservedGeneration, obj.Status.CurrentHealthy, obj.Status.DesiredHealthy)
+	}
+	if reason != "" || !hasRules {
+		return reason, nil
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(v.Object)
+	if err != nil {
+		return "", err
+	}
+	return rules.notReadyReason(content)
+}
+
+func ingressNotReadyReason(status corev1.LoadBalancerStatus) string {
+	if len(status.Ingress) == 0 {
+		return "no load balancer address assigned"
+	}
+	return ""
+}
+
+func hpaNotReadyReason(minReplicas *int32, currentReplicas, desiredReplicas int32) string {
+	min := int32(1)
+	if minReplicas != nil {
+		min = *minReplicas
+	}
+	if desiredReplicas < min {
+		return "desired replicas not computed yet"
+	}
+	if currentReplicas < min {
+		return fmt.Sprintf("%d of minimum %d replicas running", currentReplicas, min)
+	}
+	return ""
+}
+
+func pdbNotReadyReason(generation, observedGeneration int64, currentHealthy, desiredHealthy int32) string {
+	if observedGeneration < generation {
+		return fmt.Sprintf("generation %d not observed yet", generation)
+	}
+	if currentHealthy < desiredHealthy {
+		return fmt.Sprintf("%d of %d desired pods healthy", currentHealthy, desiredHealthy)
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+)
+
+// liveInfo returns the resource of the given manifest object, whose live
+// state is the given object.
+func liveInfo(t *testing.T, manifest, live string) *resource.Info {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON([]byte(manifest)); err != nil {
+		t.Fatal(err)
+	}
+	gvk := obj.GroupVersionKind()
+	client := &fake.RESTClient{
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			header := http.Header{"Content-Type": []string{"application/json"}}
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: ioutil.NopCloser(strings.NewReader(live))}, nil
+		}),
+	}
+	return &resource.Info{
+		Client:    client,
+		Name:      obj.GetName(),
+		Namespace: defaultNamespace,
+		Object:    obj,
+		Mapping: &meta.RESTMapping{
+			Resource:         schema.GroupVersionResource{Group: gvk.Group, Version: gvk.Version, Resource: strings.ToLower(gvk.Kind) + "s"},
+			GroupVersionKind: gvk,
+			Scope:            meta.RESTScopeNamespace,
+		},
+	}
+}
+
+func TestStatusNotReadyReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		live     string
+		want     string
+	}{
+		{
+			name:     "ingress without load balancer",
+			manifest: `{"apiVersion":"networking.k8s.io/v1","kind":"Ingress","metadata":{"name":"web"}}`,
+			live:     `{"apiVersion":"networking.k8s.io/v1","kind":"Ingress","metadata":{"name":"web"},"status":{"loadBalancer":{}}}`,
+			want:     "no load balancer address assigned",
+		},
+		{
+			name:     "ingress with load balancer",
+			manifest: `{"apiVersion":"networking.k8s.io/v1beta1","kind":"Ingress","metadata":{"name":"web"}}`,
+			live:     `{"apiVersion":"networking.k8s.io/v1beta1","kind":"Ingress","metadata":{"name":"web"},"status":{"loadBalancer":{"ingress":[{"ip":"10.0.0.1"}]}}}`,
+		},
+		{
+			name:     "autoscaler without desired replicas",
+			manifest: `{"apiVersion":"autoscaling/v1","kind":"HorizontalPodAutoscaler","metadata":{"name":"web"},"spec":{"minReplicas":2,"maxReplicas":5}}`,
+			live:     `{"apiVersion":"autoscaling/v1","kind":"HorizontalPodAutoscaler","metadata":{"name":"web"},"spec":{"minReplicas":2,"maxReplicas":5},"status":{"currentReplicas":1}}`,
+			want:     "desired replicas not computed yet",
+		},
+		{
+			name:     "autoscaler unable to fetch metrics",
+			manifest: `{"apiVersion":"autoscaling/v2beta2","kind":"HorizontalPodAutoscaler","metadata":{"name":"web"},"spec":{"maxReplicas":5}}`,
+			live: `{"apiVersion":"autoscaling/v2beta2","kind":"HorizontalPodAutoscaler","metadata":{"name":"web"},"spec":{"maxReplicas":5},
+				"status":{"currentReplicas":1,"desiredReplicas":1,"conditions":[{"type":"ScalingActive","status":"False","reason":"FailedGetResourceMetric"}]}}`,
+			want: "ScalingActive is False: FailedGetResourceMetric",
+		},
+		{
+			name:     "disruption budget with unhealthy pods",
+			manifest: `{"apiVersion":"policy/v1beta1","kind":"PodDisruptionBudget","metadata":{"name":"web"}}`,
+			live:     `{"apiVersion":"policy/v1beta1","kind":"PodDisruptionBudget","metadata":{"name":"web","generation":1},"status":{"observedGeneration":1,"currentHealthy":1,"desiredHealthy":2}}`,
+			want:     "1 of 2 desired pods healthy",
+		},
+		{
+			name:     "unavailable API service",
+			manifest: `{"apiVersion":"apiregistration.k8s.io/v1","kind":"APIService","metadata":{"name":"v1beta1.metrics.k8s.io"}}`,
+			live: `{"apiVersion":"apiregistration.k8s.io/v1","kind":"APIService","metadata":{"name":"v1beta1.metrics.k8s.io"},
+				"status":{"conditions":[{"type":"Available","status":"False","reason":"MissingEndpoints","message":"endpoints for service/metrics-server in \"kube-system\" have no addresses"}]}}`,
+			want: `condition Available is False: MissingEndpoints: endpoints for service/metrics-server in "kube-system" have no addresses`,
+		},
+		{
+			name:     "custom resource with ready condition",
+			manifest: `{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db","annotations":{"helm.sh/ready-condition":"Ready"}}}`,
+			live:     `{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db","generation":2},"status":{"conditions":[{"type":"Ready","status":"True","observedGeneration":2}]}}`,
+		},
+		{
+			name:     "custom resource with stale ready condition",
+			manifest: `{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db","annotations":{"helm.sh/ready-condition":"Ready"}}}`,
+			live:     `{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db","generation":3},"status":{"conditions":[{"type":"Ready","status":"True","observedGeneration":2}]}}`,
+			want:     "condition Ready has not observed generation 3",
+		},
+		{
+			name:     "custom resource without ready condition",
+			manifest: `{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db","annotations":{"helm.sh/ready-condition":"Ready"}}}`,
+			live:     `{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db"}}`,
+			want:     "condition Ready is not reported",
+		},
+		{
+			name:     "custom resource with jsonpath value",
+			manifest: `{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db","annotations":{"helm.sh/ready-jsonpath":"{.status.phase}","helm.sh/ready-value":"Running"}}}`,
+			live:     `{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db"},"status":{"phase":"Provisioning"}}`,
+			want:     `{.status.phase} is "Provisioning", expected "Running"`,
+		},
+		{
+			name:     "custom resource with jsonpath without braces",
+			manifest: `{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db","annotations":{"helm.sh/ready-jsonpath":".status.ready"}}}`,
+			live:     `{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db"},"status":{"ready":true}}`,
+		},
+		{
+			name:     "custom resource with false jsonpath",
+			manifest: `{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db","annotations":{"helm.sh/ready-jsonpath":"{.status.ready}"}}}`,
+			live:     `{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db"},"status":{"ready":false}}`,
+			want:     `{.status.ready} is "false"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewReadyChecker(nil, nil)
+			info := liveInfo(t, tt.manifest, tt.live)
+			obj := info.Object
+			got, err := c.statusNotReadyReason(info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("statusNotReadyReason() = %q, want %q", got, tt.want)
+			}
+			if info.Object != obj {
+				t.Error("expected the resource not to be replaced by the fetched object")
+			}
+		})
+	}
+}
+
+func TestStatusNotReadyReasonInvalidJSONPath(t *testing.T) {
+	info := liveInfo(t,
+		`{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db","annotations":{"helm.sh/ready-jsonpath":"{.status[}"}}}`,
+		`{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"db"}}`)
+	c := NewReadyChecker(nil, nil)
+	if _, err := c.statusNotReadyReason(info); err == nil || !strings.Contains(err.Error(), "invalid helm.sh/ready-jsonpath annotation") {
+		t.Errorf("expected an invalid annotation error, got %v", err)
+	}
+}
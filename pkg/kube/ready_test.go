@@ -18,6 +18,7 @@ package kube // import "helm.sh/helm/v3/pkg/kube"
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -26,6 +27,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
@@ -230,6 +232,119 @@ func Test_ReadyChecker_podsReadyForObject(t *testing.T) {
 	}
 }
 
+func Test_ReadyChecker_NotReadyReasons(t *testing.T) {
+	crashing := newPodWithCondition("foo-1", corev1.ConditionFalse)
+	crashing.Labels = map[string]string{"name": "foo"}
+	crashing.Status.Phase = corev1.PodRunning
+	crashing.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "web",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 10s restarting failed container"}},
+	}}
+	ready := newPodWithCondition("foo-2", corev1.ConditionTrue)
+	ready.Labels = map[string]string{"name": "foo"}
+
+	c := NewReadyChecker(fake.NewSimpleClientset(crashing, ready), nil)
+	got, err := c.NotReadyReasons(context.TODO(), &resource.Info{
+		Namespace: defaultNamespace,
+		Name:      "foo",
+		Object:    newReplicaSet("foo", 2, 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"pod foo-1: container web: CrashLoopBackOff: back-off 10s restarting failed container"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NotReadyReasons() = %q, want %q", got, want)
+	}
+}
+
+func Test_PodNotReadyReasons(t *testing.T) {
+	tests := []struct {
+		name   string
+		status corev1.PodStatus
+		want   []string
+	}{
+		{
+			name: "ready pod",
+			status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		},
+		{
+			name:   "succeeded pod",
+			status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+		},
+		{
+			name: "image pull error",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				InitContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "init",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed"}},
+				}},
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "web",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: `Back-off pulling image "nginx:nope"`}},
+				}},
+			},
+			want: []string{`container web: ImagePullBackOff: Back-off pulling image "nginx:nope"`},
+		},
+		{
+			name: "failed init container",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				InitContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "init",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 2, Reason: "Error"}},
+				}},
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "web",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}},
+				}},
+			},
+			want: []string{"container init: Error with exit code 2"},
+		},
+		{
+			name: "unschedulable pod",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{{
+					Type:    corev1.PodScheduled,
+					Status:  corev1.ConditionFalse,
+					Reason:  "Unschedulable",
+					Message: "0/3 nodes are available: 3 Insufficient cpu.",
+				}},
+			},
+			want: []string{"Unschedulable: 0/3 nodes are available: 3 Insufficient cpu."},
+		},
+		{
+			name: "failing readiness probe",
+			status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "web",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}},
+			},
+			want: []string{"container web: not ready"},
+		},
+		{
+			name:   "pending pod without details",
+			status: corev1.PodStatus{Phase: corev1.PodPending},
+			want:   []string{"pod is pending"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PodNotReadyReasons(&corev1.Pod{Status: tt.status})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PodNotReadyReasons() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_ReadyChecker_jobReady(t *testing.T) {
 	type args struct {
 		job *batchv1.Job
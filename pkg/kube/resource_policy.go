@@ -16,7 +16,19 @@ limitations under the License.
 
 package kube // import "helm.sh/helm/v3/pkg/kube"
 
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
 // ResourcePolicyAnno is the annotation name for a resource policy
+//
+// Several policies can be combined by separating them with commas, such as
+// "keep,never-update".
 const ResourcePolicyAnno = "helm.sh/resource-policy"
 
 // KeepPolicy is the resource policy type for keep
@@ -24,3 +36,95 @@ const ResourcePolicyAnno = "helm.sh/resource-policy"
 // This resource policy type allows resources to skip being deleted
 //   during an uninstallRelease action.
 const KeepPolicy = "keep"
+
+// NeverUpdatePolicy is the resource policy type for never-update
+//
+// This resource policy type creates resources once and never updates them
+//   afterwards, such as for generated secrets.
+const NeverUpdatePolicy = "never-update"
+
+// RecreatePolicy is the resource policy type for recreate
+//
+// This resource policy type deletes and creates resources again instead of
+//   patching them when they change, such as for objects with immutable fields.
+const RecreatePolicy = "recreate"
+
+// OrphanAndStripPolicy is the resource policy type for orphan-and-strip
+//
+// This resource policy type skips deleting resources removed from a release,
+//   and strips the labels and annotations recording their ownership by Helm so
+//   they are no longer managed by any release.
+const OrphanAndStripPolicy = "orphan-and-strip"
+
+// ownershipLabels and ownershipAnnotations record the release owning a resource.
+var (
+	ownershipLabels      = []string{"app.kubernetes.io/managed-by"}
+	ownershipAnnotations = []string{"meta.helm.sh/release-name", "meta.helm.sh/release-namespace"}
+)
+
+// HasResourcePolicy reports whether the resource policy annotation of the
+// given annotations contains the policy.
+func HasResourcePolicy(annotations map[string]string, policy string) bool {
+	for _, p := range strings.Split(annotations[ResourcePolicyAnno], ",") {
+		if strings.ToLower(strings.TrimSpace(p)) == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceHasPolicy reports whether the object of the resource has the policy.
+func resourceHasPolicy(info *resource.Info, policy string) bool {
+	annotations, err := metadataAccessor.Annotations(info.Object)
+	if err != nil {
+		return false
+	}
+	return HasResourcePolicy(annotations, policy)
+}
+
+// stripOwnership removes the labels and annotations recording the ownership of
+// the resource by a release.
+func stripOwnership(info *resource.Info) error {
+	remove := func(keys []string) map[string]interface{} {
+		m := map[string]interface{}{}
+		for _, k := range keys {
+			m[k] = nil
+		}
+		return m
+	}
+	return errors.Wrapf(patchMetadata(info, remove(ownershipLabels), remove(ownershipAnnotations)),
+		"cannot strip ownership of %q with kind %s", info.Name, info.Mapping.GroupVersionKind.Kind)
+}
+
+// setOwnership sets the labels and annotations recording the ownership of the
+// resource to the given release.
+func setOwnership(info *resource.Info, releaseName, releaseNamespace string) error {
+	labels := map[string]interface{}{ownershipLabels[0]: "Helm"}
+	annotations := map[string]interface{}{
+		ownershipAnnotations[0]: releaseName,
+		ownershipAnnotations[1]: releaseNamespace,
+	}
+	return errors.Wrapf(patchMetadata(info, labels, annotations),
+		"cannot transfer ownership of %q with kind %s", info.Name, info.Mapping.GroupVersionKind.Kind)
+}
+
+// patchMetadata merges the labels and annotations into the live object of the
+// resource, leaving the rest of the object untouched. Nil values remove keys.
+func patchMetadata(info *resource.Info, labels, annotations map[string]interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels":      labels,
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	obj, err := resource.NewHelper(info.Client, info.Mapping).
+		WithFieldManager(getManagedFieldsManager()).
+		Patch(info.Namespace, info.Name, types.MergePatchType, patch, nil)
+	if err != nil {
+		return err
+	}
+	return info.Refresh(obj, true)
+}
@@ -16,6 +16,8 @@ limitations under the License.
 
 package kube
 
+import "k8s.io/cli-runtime/pkg/resource"
+
 // Result contains the information of created, updated, and deleted resources
 // for various kube API calls along with helper methods for using those
 // resources
@@ -23,6 +25,31 @@ type Result struct {
 	Created ResourceList
 	Updated ResourceList
 	Deleted ResourceList
+	// Kept holds the resources removed from the release but not deleted
+	// because of the keep resource policy.
+	Kept ResourceList
+	// Skipped holds the resources not updated because of the never-update
+	// resource policy.
+	Skipped ResourceList
+	// Recreated holds the resources deleted and created again instead of being
+	// updated because of the recreate resource policy.
+	Recreated ResourceList
+	// Orphaned holds the resources removed from the release whose ownership
+	// was stripped instead of being deleted, because of the orphan-and-strip
+	// resource policy.
+	Orphaned ResourceList
+	// Conflicts holds the field ownership conflicts reported by the API server
+	// when resources are updated using server-side apply.
+	Conflicts []Conflict
+}
+
+// Conflict describes a server-side apply conflict for a single resource.
+type Conflict struct {
+	Resource *resource.Info
+	// Fields lists the paths of the fields owned by another field manager.
+	Fields []string
+	// Message is the error message returned by the API server.
+	Message string
 }
 
 // If needed, we can add methods to the Result type for things like diffing
@@ -19,6 +19,8 @@ package kube // import "helm.sh/helm/v3/pkg/kube"
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -33,31 +35,152 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/resource"
 )
 
+// WaitEvent reports the readiness of a resource while waiting for resources to
+// be ready.
+type WaitEvent struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Ready     bool
+	// Reasons explain why the resource is not ready, such as the image pull
+	// errors and crash loops of its pods.
+	Reasons []string
+}
+
+func (e WaitEvent) String() string {
+	res := fmt.Sprintf("%s %q", e.Kind, e.Name)
+	if e.Namespace != "" {
+		res += fmt.Sprintf(" in namespace %q", e.Namespace)
+	}
+	if e.Ready {
+		return res + " is ready"
+	}
+	if len(e.Reasons) == 0 {
+		return res + " is not ready"
+	}
+	return res + " is not ready: " + strings.Join(e.Reasons, ", ")
+}
+
+// WaitProgressFunc receives a WaitEvent whenever the readiness of a resource,
+// or the reasons why it is not ready, change while waiting.
+type WaitProgressFunc func(WaitEvent)
+
+// WaitTimeoutError is returned when resources are still not ready once the
+// timeout is reached or the wait is cancelled.
+type WaitTimeoutError struct {
+	// NotReady lists the resources that were not ready.
+	NotReady []WaitEvent
+}
+
+func (e *WaitTimeoutError) Error() string {
+	msgs := make([]string, 0, len(e.NotReady))
+	for _, r := range e.NotReady {
+		msgs = append(msgs, r.String())
+	}
+	return fmt.Sprintf("%s: %s", wait.ErrWaitTimeout, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns wait.ErrWaitTimeout, which was returned before the resources
+// that were not ready were reported.
+func (e *WaitTimeoutError) Unwrap() error {
+	return wait.ErrWaitTimeout
+}
+
 type waiter struct {
-	c       ReadyChecker
-	timeout time.Duration
-	log     func(string, ...interface{})
+	c        ReadyChecker
+	timeout  time.Duration
+	log      func(string, ...interface{})
+	progress WaitProgressFunc
 }
 
 // waitForResources polls to get the current status of all pods, PVCs, Services and
-// Jobs(optional) until all are ready or a timeout is reached
-func (w *waiter) waitForResources(created ResourceList) error {
+// Jobs(optional) until all are ready, a timeout is reached or the context is cancelled.
+//
+// The readiness of every resource is reported to the progress function when it
+// changes. If the resources are not ready in time, a *WaitTimeoutError listing
+// the resources that are not ready is returned.
+//
+// The reasons why resources are not ready are only looked up on every poll if
+// there is a progress function; otherwise they are looked up once the timeout
+// is reached.
+func (w *waiter) waitForResources(ctx context.Context, created ResourceList) error {
 	w.log("beginning wait for %d resources with timeout of %v", len(created), w.timeout)
 
-	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	waitCtx, cancel := context.WithTimeout(ctx, w.timeout)
 	defer cancel()
 
-	return wait.PollImmediateUntil(2*time.Second, func() (bool, error) {
+	reported := make(map[string]WaitEvent, len(created))
+	var notReady []*resource.Info
+	var events []WaitEvent
+	err := wait.PollImmediateUntil(2*time.Second, func() (bool, error) {
+		notReady, events = nil, nil
 		for _, v := range created {
-			ready, err := w.c.IsReady(ctx, v)
-			if !ready || err != nil {
+			ready, err := w.c.IsReady(waitCtx, v)
+			if err != nil {
 				return false, err
 			}
+			if !ready {
+				notReady = append(notReady, v)
+			}
+			if w.progress == nil {
+				continue
+			}
+			event := w.event(waitCtx, v, ready)
+			if !ready {
+				events = append(events, event)
+			}
+			w.report(reported, event)
+		}
+		return len(notReady) == 0, nil
+	}, waitCtx.Done())
+	// PollImmediateUntil reports a cancelled context as a timeout.
+	if err != nil && waitCtx.Err() == context.Canceled {
+		return context.Canceled
+	}
+	if err == wait.ErrWaitTimeout && len(notReady) > 0 {
+		if events == nil {
+			// The wait has timed out, but the caller's context has not.
+			for _, v := range notReady {
+				events = append(events, w.event(ctx, v, false))
+			}
+		}
+		return &WaitTimeoutError{NotReady: events}
+	}
+	return err
+}
+
+// event returns the readiness of a resource, with the reasons it is not ready.
+func (w *waiter) event(ctx context.Context, v *resource.Info, ready bool) WaitEvent {
+	event := WaitEvent{
+		Kind:      v.Mapping.GroupVersionKind.Kind,
+		Namespace: v.Namespace,
+		Name:      v.Name,
+		Ready:     ready,
+	}
+	if !ready {
+		var err error
+		if event.Reasons, err = w.c.NotReadyReasons(ctx, v); err != nil {
+			w.log("unable to get the reasons %s %q is not ready: %s", event.Kind, event.Name, err)
 		}
-		return true, nil
-	}, ctx.Done())
+	}
+	return event
+}
+
+// report passes an event to the progress function unless the same event was
+// last reported for the resource.
+func (w *waiter) report(reported map[string]WaitEvent, event WaitEvent) {
+	if w.progress == nil {
+		return
+	}
+	key := event.Kind + "/" + event.Namespace + "/" + event.Name
+	if last, ok := reported[key]; ok && reflect.DeepEqual(last, event) {
+		return
+	}
+	reported[key] = event
+	w.progress(event)
 }
 
 // SelectorsForObject returns the pod label selector for a given object
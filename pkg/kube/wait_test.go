@@ -0,0 +1,137 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func podInfo(pod *corev1.Pod) *resource.Info {
+	return &resource.Info{
+		Name:      pod.Name,
+		Namespace: pod.Namespace,
+		Object:    pod,
+		Mapping: &meta.RESTMapping{
+			GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Scope:            meta.RESTScopeNamespace,
+		},
+	}
+}
+
+func TestWaitForResources(t *testing.T) {
+	crashing := newPodWithCondition("crashing", corev1.ConditionFalse)
+	crashing.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "web",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}}
+	ready := newPodWithCondition("ready", corev1.ConditionTrue)
+	client := fake.NewSimpleClientset(crashing, ready)
+
+	t.Run("ready", func(t *testing.T) {
+		var events []WaitEvent
+		w := waiter{
+			c:        NewReadyChecker(client, nil),
+			log:      nopLogger,
+			timeout:  time.Minute,
+			progress: func(e WaitEvent) { events = append(events, e) },
+		}
+		if err := w.waitForResources(context.Background(), ResourceList{podInfo(ready)}); err != nil {
+			t.Fatal(err)
+		}
+		want := []WaitEvent{{Kind: "Pod", Namespace: defaultNamespace, Name: "ready", Ready: true}}
+		if !reflect.DeepEqual(events, want) {
+			t.Errorf("expected events %v, got %v", want, events)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		w := waiter{
+			c:       NewReadyChecker(client, nil),
+			log:     nopLogger,
+			timeout: time.Minute,
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := w.waitForResources(ctx, ResourceList{podInfo(crashing)})
+		if err != context.Canceled {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		var events []WaitEvent
+		w := waiter{
+			c:        NewReadyChecker(client, nil),
+			log:      nopLogger,
+			timeout:  10 * time.Millisecond,
+			progress: func(e WaitEvent) { events = append(events, e) },
+		}
+		err := w.waitForResources(context.Background(), ResourceList{podInfo(ready), podInfo(crashing)})
+		var timeoutErr *WaitTimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("expected a WaitTimeoutError, got %v", err)
+		}
+		if !errors.Is(err, wait.ErrWaitTimeout) {
+			t.Errorf("expected the error to wrap wait.ErrWaitTimeout")
+		}
+		notReady := WaitEvent{Kind: "Pod", Namespace: defaultNamespace, Name: "crashing", Reasons: []string{"container web: CrashLoopBackOff"}}
+		if !reflect.DeepEqual(timeoutErr.NotReady, []WaitEvent{notReady}) {
+			t.Errorf("expected not ready resources %v, got %v", []WaitEvent{notReady}, timeoutErr.NotReady)
+		}
+		const msg = `timed out waiting for the condition: Pod "crashing" in namespace "default" is not ready: container web: CrashLoopBackOff`
+		if err.Error() != msg {
+			t.Errorf("expected error %q, got %q", msg, err.Error())
+		}
+		want := []WaitEvent{{Kind: "Pod", Namespace: defaultNamespace, Name: "ready", Ready: true}, notReady}
+		if !reflect.DeepEqual(events, want) {
+			t.Errorf("expected events %v, got %v", want, events)
+		}
+	})
+
+	t.Run("timeout without progress", func(t *testing.T) {
+		client := fake.NewSimpleClientset(crashing)
+		w := waiter{
+			c:       NewReadyChecker(client, nil),
+			log:     nopLogger,
+			timeout: 3 * time.Second,
+		}
+		err := w.waitForResources(context.Background(), ResourceList{podInfo(crashing)})
+		var timeoutErr *WaitTimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("expected a WaitTimeoutError, got %v", err)
+		}
+		notReady := WaitEvent{Kind: "Pod", Namespace: defaultNamespace, Name: "crashing", Reasons: []string{"container web: CrashLoopBackOff"}}
+		if !reflect.DeepEqual(timeoutErr.NotReady, []WaitEvent{notReady}) {
+			t.Errorf("expected not ready resources %v, got %v", []WaitEvent{notReady}, timeoutErr.NotReady)
+		}
+		// the pod is fetched once per poll, and its reasons once at the timeout
+		if gets := len(client.Actions()); gets != 3 {
+			t.Errorf("expected the pod to be fetched 3 times, got %d", gets)
+		}
+	})
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package release
 
 import (
+	gotime "time"
+
 	"helm.sh/helm/v3/pkg/time"
 )
 
@@ -59,6 +61,15 @@ const HookWeightAnnotation = "helm.sh/hook-weight"
 // HookDeleteAnnotation is the label name for the delete policy for a hook
 const HookDeleteAnnotation = "helm.sh/hook-delete-policy"
 
+// HookRetriesAnnotation is the label name for the number of times a failed hook is retried
+const HookRetriesAnnotation = "helm.sh/hook-retries"
+
+// HookBackoffAnnotation is the label name for the delay before a failed hook is retried
+const HookBackoffAnnotation = "helm.sh/hook-backoff"
+
+// HookTestSuiteAnnotation is the label name for the test suites a test hook belongs to
+const HookTestSuiteAnnotation = "helm.sh/test-suite"
+
 // Hook defines a hook object.
 type Hook struct {
 	Name string `json:"name,omitempty"`
@@ -76,6 +87,12 @@ type Hook struct {
 	Weight int `json:"weight,omitempty"`
 	// DeletePolicies are the policies that indicate when to delete the hook
 	DeletePolicies []HookDeletePolicy `json:"delete_policies,omitempty"`
+	// Retries is the number of times the hook is deleted and recreated after a failure
+	Retries int `json:"retries,omitempty"`
+	// Backoff is the delay before a failed hook is recreated
+	Backoff gotime.Duration `json:"backoff,omitempty"`
+	// History records every attempt of the last execution of the hook, LastRun being the latest
+	History []HookExecution `json:"history,omitempty"`
 }
 
 // A HookExecution records the result for the last execution of a hook for a given release.
@@ -100,6 +117,9 @@ const (
 	HookPhaseSucceeded HookPhase = "Succeeded"
 	// HookPhaseFailed indicates that hook execution failed
 	HookPhaseFailed HookPhase = "Failed"
+	// HookPhaseCancelled indicates that a hook was stopped, or not started,
+	// because another hook failed
+	HookPhaseCancelled HookPhase = "Cancelled"
 )
 
 // String converts a hook phase to a printable string
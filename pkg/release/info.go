@@ -33,4 +33,16 @@ type Info struct {
 	Status Status `json:"status,omitempty"`
 	// Contains the rendered templates/NOTES.txt if available
 	Notes string `json:"notes,omitempty"`
+	// Lease is the lock held on the release while its status is pending
+	Lease *Lease `json:"lease,omitempty"`
+	// Adopted lists the existing resources the release took ownership of
+	Adopted []ResourceReference `json:"adopted,omitempty"`
+}
+
+// ResourceReference identifies a Kubernetes resource.
+type ResourceReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
 }
@@ -0,0 +1,47 @@
+/*
+Copyright The Helm Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	gotime "time"
+
+	"helm.sh/helm/v3/pkg/time"
+)
+
+// Lease records which client holds the lock on a release while an operation
+// on it is underway.
+type Lease struct {
+	// Holder identifies the client that acquired the lease.
+	Holder string `json:"holder,omitempty"`
+	// AcquiredAt is when the lease was acquired or last renewed.
+	AcquiredAt time.Time `json:"acquired_at,omitempty"`
+	// TTL is how long the lease is valid after it was acquired.
+	TTL gotime.Duration `json:"ttl,omitempty"`
+}
+
+// ExpiresAt returns the time the lease expires.
+func (l *Lease) ExpiresAt() time.Time {
+	return l.AcquiredAt.Add(l.TTL)
+}
+
+// Expired reports whether the lease has expired at the given time. A nil
+// lease, as written by clients that predate leases, is always expired.
+func (l *Lease) Expired(now gotime.Time) bool {
+	if l == nil {
+		return true
+	}
+	return !now.Before(l.ExpiresAt().Time)
+}
@@ -26,6 +26,9 @@ type Release struct {
 	Info *Info `json:"info,omitempty"`
 	// Chart is the chart that was released.
 	Chart *chart.Chart `json:"chart,omitempty"`
+	// ChartDigest is the digest of the chart when the chart is stored
+	// separately from the release.
+	ChartDigest string `json:"chart_digest,omitempty"`
 	// Config is the set of extra Values added to the chart.
 	// These values override the default values inside of the chart.
 	Config map[string]interface{} `json:"config,omitempty"`
@@ -22,6 +22,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"sigs.k8s.io/yaml"
@@ -175,6 +176,8 @@ func (file *manifestFile) sort(result *result) error {
 			Events:         []release.HookEvent{},
 			Weight:         hw,
 			DeletePolicies: []release.HookDeletePolicy{},
+			Retries:        calculateHookRetries(entry),
+			Backoff:        calculateHookBackoff(entry),
 		}
 
 		isUnknownHook := false
@@ -222,6 +225,32 @@ func calculateHookWeight(entry SimpleHead) int {
 	return hw
 }
 
+// calculateHookRetries finds the number of retries in the hook retries annotation.
+//
+// If no valid number of retries is found, the hook is not retried
+func calculateHookRetries(entry SimpleHead) int {
+	retries, err := strconv.Atoi(entry.Metadata.Annotations[release.HookRetriesAnnotation])
+	if err != nil || retries < 0 {
+		return 0
+	}
+	return retries
+}
+
+// calculateHookBackoff finds the delay between retries in the hook backoff annotation.
+//
+// The value is either a duration such as "30s" or a number of seconds. If no
+// valid delay is found, the hook is retried immediately
+func calculateHookBackoff(entry SimpleHead) time.Duration {
+	value := strings.TrimSpace(entry.Metadata.Annotations[release.HookBackoffAnnotation])
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if backoff, err := time.ParseDuration(value); err == nil && backoff > 0 {
+		return backoff
+	}
+	return 0
+}
+
 // operateAnnotationValues finds the given annotation and runs the operate function with the value of that annotation
 func operateAnnotationValues(entry SimpleHead, annotation string, operate func(p string)) {
 	if dps, ok := entry.Metadata.Annotations[annotation]; ok {
@@ -19,6 +19,7 @@ package releaseutil
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"sigs.k8s.io/yaml"
 
@@ -226,3 +227,61 @@ metadata:
 		}
 	}
 }
+
+func TestSortManifestsHookRetries(t *testing.T) {
+	manifests := map[string]string{
+		"templates/migrate.yaml": `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  annotations:
+    "helm.sh/hook": pre-install
+    "helm.sh/hook-retries": "3"
+    "helm.sh/hook-backoff": "1m"
+`,
+		"templates/seconds.yaml": `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: seconds
+  annotations:
+    "helm.sh/hook": pre-install
+    "helm.sh/hook-retries": "1"
+    "helm.sh/hook-backoff": "15"
+`,
+		"templates/invalid.yaml": `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: invalid
+  annotations:
+    "helm.sh/hook": pre-install
+    "helm.sh/hook-retries": "-1"
+    "helm.sh/hook-backoff": "soon"
+`,
+	}
+
+	hooks, _, err := SortManifests(manifests, chartutil.VersionSet{"v1", "batch/v1"}, InstallOrder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]struct {
+		retries int
+		backoff time.Duration
+	}{
+		"migrate": {3, time.Minute},
+		"seconds": {1, 15 * time.Second},
+		"invalid": {0, 0},
+	}
+	if len(hooks) != len(expected) {
+		t.Fatalf("expected %d hooks, got %d", len(expected), len(hooks))
+	}
+	for _, h := range hooks {
+		e := expected[h.Name]
+		if h.Retries != e.retries {
+			t.Errorf("%s: expected %d retries, got %d", h.Name, e.retries, h.Retries)
+		}
+		if h.Backoff != e.backoff {
+			t.Errorf("%s: expected backoff %s, got %s", h.Name, e.backoff, h.Backoff)
+		}
+	}
+}
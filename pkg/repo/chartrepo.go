@@ -17,10 +17,12 @@ limitations under the License.
 package repo // import "helm.sh/helm/v3/pkg/repo"
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/url"
@@ -115,6 +117,11 @@ func (r *ChartRepository) Load() error {
 }
 
 // DownloadIndexFile fetches the index from a repository.
+//
+// If the getter supports conditional requests, the index is only downloaded
+// if it changed since the previous download, and it is streamed to the cache
+// directory. A compact cache of the index, read by LoadIndexFile, is written
+// beside it.
 func (r *ChartRepository) DownloadIndexFile() (string, error) {
 	parsedURL, err := url.Parse(r.Config.URL)
 	if err != nil {
@@ -125,40 +132,98 @@ func (r *ChartRepository) DownloadIndexFile() (string, error) {
 
 	indexURL := parsedURL.String()
 	// TODO add user-agent
-	resp, err := r.Client.Get(indexURL,
+	options := []getter.Option{
 		getter.WithURL(r.Config.URL),
 		getter.WithInsecureSkipVerifyTLS(r.Config.InsecureSkipTLSverify),
 		getter.WithTLSClientConfig(r.Config.CertFile, r.Config.KeyFile, r.Config.CAFile),
 		getter.WithBasicAuth(r.Config.Username, r.Config.Password),
 		getter.WithPassCredentialsAll(r.Config.PassCredentialsAll),
-	)
-	if err != nil {
-		return "", err
 	}
 
-	index, err := ioutil.ReadAll(resp)
+	fname := filepath.Join(r.CachePath, helmpath.CacheIndexFile(r.Config.Name))
+	os.MkdirAll(filepath.Dir(fname), 0755)
+
+	var (
+		body       io.ReadCloser
+		validators getter.Validators
+	)
+	if cg, ok := r.Client.(getter.ConditionalGetter); ok {
+		// Only the validators of an index still matching its compact cache
+		// are sent, so that a modified or missing index is downloaded.
+		if c, err := openIndexCache(fname); err == nil {
+			validators = c.header.Validators
+		}
+		body, validators, err = cg.GetIfModified(indexURL, validators, options...)
+		if err == getter.ErrNotModified {
+			if _, err := openIndexCache(fname); err == nil {
+				return fname, nil
+			}
+			// The compact cache went away since the request was sent, so it
+			// is rebuilt from the unmodified index, or the index is
+			// downloaded again if it went away as well.
+			if names, err := cacheIndexFile(fname, fname, r.Config.URL, validators); err == nil {
+				r.writeChartsFile(names)
+				return fname, nil
+			}
+			body, validators, err = cg.GetIfModified(indexURL, getter.Validators{}, options...)
+		}
+	} else {
+		var resp *bytes.Buffer
+		if resp, err = r.Client.Get(indexURL, options...); err == nil {
+			body = ioutil.NopCloser(resp)
+		}
+	}
 	if err != nil {
 		return "", err
 	}
+	defer body.Close()
 
-	indexFile, err := loadIndex(index, r.Config.URL)
+	names, err := saveIndex(body, fname, r.Config.URL, validators)
 	if err != nil {
 		return "", err
 	}
+	r.writeChartsFile(names)
+	return fname, nil
+}
 
-	// Create the chart list file in the cache directory
+// writeChartsFile creates the chart list file in the cache directory.
+func (r *ChartRepository) writeChartsFile(names []string) {
 	var charts strings.Builder
-	for name := range indexFile.Entries {
+	for _, name := range names {
 		fmt.Fprintln(&charts, name)
 	}
 	chartsFile := filepath.Join(r.CachePath, helmpath.CacheChartsFile(r.Config.Name))
 	os.MkdirAll(filepath.Dir(chartsFile), 0755)
 	ioutil.WriteFile(chartsFile, []byte(charts.String()), 0644)
+}
 
-	// Create the index file in the cache directory
-	fname := filepath.Join(r.CachePath, helmpath.CacheIndexFile(r.Config.Name))
-	os.MkdirAll(filepath.Dir(fname), 0755)
-	return fname, ioutil.WriteFile(fname, index, 0644)
+// saveIndex streams an index to a temporary file and writes its compact cache
+// while decoding it, returning the names of its charts. The index only
+// replaces the file at fname if it is decoded successfully.
+func saveIndex(r io.Reader, fname, source string, v getter.Validators) ([]string, error) {
+	f, err := ioutil.TempFile(filepath.Dir(fname), filepath.Base(fname)+".*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return nil, err
+	}
+
+	// Renaming the index keeps the size and modification time its cache
+	// records.
+	names, err := cacheIndexFile(f.Name(), fname, source, v)
+	if err != nil {
+		return nil, err
+	}
+	return names, os.Rename(f.Name(), fname)
 }
 
 // Index generates an index for the chart repository and writes an index.yaml file.
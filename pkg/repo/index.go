@@ -88,6 +88,10 @@ type IndexFile struct {
 	// Annotations are additional mappings uninterpreted by Helm. They are made available for
 	// other applications to add information to the index file.
 	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// lazy reads the versions of the charts missing from Entries when they
+	// are looked up, for indexes loaded with LoadIndexFileLazy.
+	lazy *indexCache
 }
 
 // NewIndexFile initializes an index.
@@ -101,7 +105,15 @@ func NewIndexFile() *IndexFile {
 }
 
 // LoadIndexFile takes a file at the given path and returns an IndexFile object
+//
+// If the index was downloaded by ChartRepository.DownloadIndexFile and has not
+// changed since, it is read from its compact cache.
 func LoadIndexFile(path string) (*IndexFile, error) {
+	if c, err := openIndexCache(path); err == nil {
+		if i, err := c.indexFile(false); err == nil {
+			return i, nil
+		}
+	}
 	b, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -113,6 +125,19 @@ func LoadIndexFile(path string) (*IndexFile, error) {
 	return i, nil
 }
 
+// LoadIndexFileLazy is like LoadIndexFile, but if the index is read from its
+// compact cache, the versions of a chart are only read the first time the
+// chart is looked up with Get or Has. Entries then only holds the charts
+// looked up so far, and the index must not be used concurrently.
+//
+// Use it to look up a few charts in a large index.
+func LoadIndexFileLazy(path string) (*IndexFile, error) {
+	if c, err := openIndexCache(path); err == nil {
+		return c.indexFile(true)
+	}
+	return LoadIndexFile(path)
+}
+
 // MustAdd adds a file to the index
 // This can leave the index in an unsorted state
 func (i IndexFile) MustAdd(md *chart.Metadata, filename, baseURL, digest string) error {
@@ -176,6 +201,14 @@ func (i IndexFile) SortEntries() {
 // prerelease versions will be skipped.
 func (i IndexFile) Get(name, version string) (*ChartVersion, error) {
 	vs, ok := i.Entries[name]
+	if !ok && i.lazy != nil {
+		var err error
+		if vs, ok, err = i.lazy.load(name); err != nil {
+			return nil, err
+		} else if ok {
+			i.Entries[name] = vs
+		}
+	}
 	if !ok {
 		return nil, ErrNoChartName
 	}
@@ -331,15 +364,7 @@ func loadIndex(data []byte, source string) (*IndexFile, error) {
 	}
 
 	for name, cvs := range i.Entries {
-		for idx := len(cvs) - 1; idx >= 0; idx-- {
-			if cvs[idx].APIVersion == "" {
-				cvs[idx].APIVersion = chart.APIVersionV1
-			}
-			if err := cvs[idx].Validate(); err != nil {
-				log.Printf("skipping loading invalid entry for chart %q %q from %s: %s", name, cvs[idx].Version, source, err)
-				cvs = append(cvs[:idx], cvs[idx+1:]...)
-			}
-		}
+		i.Entries[name] = validChartVersions(name, cvs, source)
 	}
 	i.SortEntries()
 	if i.APIVersion == "" {
@@ -347,3 +372,18 @@ func loadIndex(data []byte, source string) (*IndexFile, error) {
 	}
 	return i, nil
 }
+
+// validChartVersions returns the valid versions of a chart, logging the
+// invalid ones. The source parameter is only used for logging.
+func validChartVersions(name string, cvs ChartVersions, source string) ChartVersions {
+	for idx := len(cvs) - 1; idx >= 0; idx-- {
+		if cvs[idx].APIVersion == "" {
+			cvs[idx].APIVersion = chart.APIVersionV1
+		}
+		if err := cvs[idx].Validate(); err != nil {
+			log.Printf("skipping loading invalid entry for chart %q %q from %s: %s", name, cvs[idx].Version, source, err)
+			cvs = append(cvs[:idx], cvs[idx+1:]...)
+		}
+	}
+	return cvs
+}
@@ -0,0 +1,479 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/getter"
+)
+
+// The compact index cache is derived from a cached index.yaml and stored
+// beside it. It is read much faster than the YAML document, and the versions
+// of a single chart can be read without reading the others.
+//
+// The file starts with indexCacheMagic, followed by the JSON encoded versions
+// of each chart on a line of their own, then by the JSON encoded
+// indexCacheHeader, and ends with the offset of the header as
+// indexCacheTrailerLen hexadecimal digits and a newline.
+const (
+	indexCacheMagic      = "helm-index-cache v1\n"
+	indexCacheTrailerLen = 17
+)
+
+// indexCacheHeader describes the index in a compact index cache.
+type indexCacheHeader struct {
+	// Source identifies the version of the YAML document the cache was
+	// derived from. The cache is ignored once the document changes.
+	Source indexCacheSource `json:"source"`
+	// Validators are the cache validators of the document when it was
+	// downloaded, used to make conditional requests for it.
+	Validators getter.Validators `json:"validators"`
+
+	APIVersion  string            `json:"apiVersion"`
+	Generated   time.Time         `json:"generated"`
+	PublicKeys  []string          `json:"publicKeys,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Entries locates the versions of each chart in the file.
+	Entries map[string]indexCacheSpan `json:"entries"`
+}
+
+type indexCacheSource struct {
+	Size    int64 `json:"size"`
+	ModTime int64 `json:"modTime"`
+}
+
+type indexCacheSpan struct {
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+}
+
+// indexCache is an open compact index cache.
+type indexCache struct {
+	path   string
+	header indexCacheHeader
+	// size and modTime detect the replacement of the file after it was
+	// opened, which invalidates the spans of the header.
+	size    int64
+	modTime time.Time
+}
+
+// indexCachePath returns the path of the compact cache of the index at path:
+// the cache of "<name>-index.yaml" is "<name>-index.cache", as returned by
+// helmpath.CacheCompactIndexFile.
+func indexCachePath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".cache"
+}
+
+// sourceOf identifies the version of the file at path.
+func sourceOf(path string) (indexCacheSource, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return indexCacheSource{}, err
+	}
+	return indexCacheSource{Size: fi.Size(), ModTime: fi.ModTime().UnixNano()}, nil
+}
+
+// cacheIndexFile writes from the index at src the compact cache of the index
+// at path. src must be the file stored at path, or be moved there once the
+// cache is written. Invalid chart versions are skipped and logged with source.
+// It returns the names of the charts of the index.
+//
+// Indexes in block style, as written by Helm and most repositories, are read
+// one chart at a time with streamIndexFile, so that only a single chart is
+// held in memory. Other indexes, and indexes failing to decode that way, are
+// decoded as a whole with loadIndex.
+func cacheIndexFile(src, path, source string, v getter.Validators) ([]string, error) {
+	header := indexCacheHeader{Validators: v}
+	var err error
+	if header.Source, err = sourceOf(src); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cw, err := newIndexCacheWriter(indexCachePath(path))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	i, err := streamIndexFile(f, func(name string, cvs ChartVersions) error {
+		cvs = validChartVersions(name, cvs, source)
+		sort.Sort(sort.Reverse(cvs))
+		names = append(names, name)
+		return cw.add(name, cvs)
+	})
+	if err != nil {
+		cw.abort()
+		if err != errIndexNotStreamable {
+			return nil, err
+		}
+		return cacheWholeIndexFile(src, path, source, header)
+	}
+	header.describe(i)
+	return names, cw.commit(header)
+}
+
+// cacheWholeIndexFile is cacheIndexFile for indexes that cannot be read one
+// chart at a time.
+func cacheWholeIndexFile(src, path, source string, header indexCacheHeader) ([]string, error) {
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	i, err := loadIndex(b, source)
+	if err != nil {
+		return nil, err
+	}
+
+	cw, err := newIndexCacheWriter(indexCachePath(path))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(i.Entries))
+	for name := range i.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := cw.add(name, i.Entries[name]); err != nil {
+			cw.abort()
+			return nil, err
+		}
+	}
+	header.describe(i)
+	return names, cw.commit(header)
+}
+
+// describe copies the description of an index, but not its entries, to the
+// header.
+func (h *indexCacheHeader) describe(i *IndexFile) {
+	h.APIVersion = i.APIVersion
+	h.Generated = i.Generated
+	h.PublicKeys = i.PublicKeys
+	h.Annotations = i.Annotations
+}
+
+// errIndexNotStreamable indicates an index that streamIndexFile cannot read
+// one chart at a time.
+var errIndexNotStreamable = errors.New("index cannot be read one chart at a time")
+
+// streamIndexFile reads an index in block style line by line, passing the
+// versions of each chart to add as soon as they are read, and returns the
+// index without its entries.
+//
+// The lines of the top level mapping outside of entries are decoded last, as
+// the rest of the index. Each chart of entries starts with a line at the
+// indentation of the first one that is not an item of a block sequence, and
+// is decoded on its own. An index, or a chart, that is not laid out that way
+// or that fails to decode on its own fails with errIndexNotStreamable: the
+// index must then be decoded as a whole to read it, or to report its error.
+func streamIndexFile(r io.Reader, add func(string, ChartVersions) error) (*IndexFile, error) {
+	var head, chart []byte
+	seen := map[string]bool{}
+	inEntries, hasEntries := false, false
+	indent := 0
+
+	flush := func() error {
+		if len(chart) == 0 {
+			return nil
+		}
+		var entry map[string]ChartVersions
+		if err := yaml.UnmarshalStrict(chart, &entry); err != nil || len(entry) != 1 {
+			return errIndexNotStreamable
+		}
+		chart = chart[:0]
+		for name, cvs := range entry {
+			if seen[name] {
+				return errIndexNotStreamable
+			}
+			seen[name] = true
+			return add(name, cvs)
+		}
+		return nil
+	}
+
+	br := bufio.NewReader(r)
+	for first := true; ; first = false {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if line == "" {
+			break
+		}
+		text := strings.TrimRight(line, "\r\n")
+		content := strings.TrimLeft(text, " ")
+		depth := len(text) - len(content)
+
+		switch {
+		case content == "" || content[0] == '#':
+			// blank lines and comments belong to the current chart
+		case content[0] == '\t' || content[0] == '%' ||
+			depth == 0 && (content == "..." || strings.HasPrefix(content, "---") && !first):
+			// tabs, directives and multiple documents
+			return nil, errIndexNotStreamable
+		case depth == 0 && isEntriesKey(content):
+			if hasEntries {
+				return nil, errIndexNotStreamable
+			}
+			inEntries, hasEntries = true, true
+			continue
+		case depth == 0:
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			inEntries = false
+		case !inEntries:
+		case indent == 0:
+			if isSequenceItem(content) {
+				return nil, errIndexNotStreamable
+			}
+			indent = depth
+		case depth < indent:
+			return nil, errIndexNotStreamable
+		case depth == indent && !isSequenceItem(content):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+
+		if inEntries {
+			chart = append(chart, line...)
+		} else {
+			head = append(head, line...)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	i := &IndexFile{}
+	if err := yaml.UnmarshalStrict(head, i); err != nil || len(i.Entries) > 0 || i.APIVersion == "" {
+		return nil, errIndexNotStreamable
+	}
+	return i, nil
+}
+
+// isEntriesKey reports whether a line of the top level mapping is the key of
+// entries in block style.
+func isEntriesKey(content string) bool {
+	if !strings.HasPrefix(content, "entries:") {
+		return false
+	}
+	rest := strings.TrimSpace(content[len("entries:"):])
+	return rest == "" || rest[0] == '#'
+}
+
+func isSequenceItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+// indexCacheWriter writes a compact index cache one chart at a time.
+type indexCacheWriter struct {
+	dest    string
+	f       *os.File
+	w       *bufio.Writer
+	offset  int64
+	entries map[string]indexCacheSpan
+}
+
+func newIndexCacheWriter(dest string) (*indexCacheWriter, error) {
+	f, err := ioutil.TempFile(filepath.Dir(dest), filepath.Base(dest)+".*")
+	if err != nil {
+		return nil, err
+	}
+	cw := &indexCacheWriter{
+		dest:    dest,
+		f:       f,
+		w:       bufio.NewWriter(f),
+		offset:  int64(len(indexCacheMagic)),
+		entries: map[string]indexCacheSpan{},
+	}
+	cw.w.WriteString(indexCacheMagic)
+	return cw, nil
+}
+
+// add writes the versions of a chart.
+func (cw *indexCacheWriter) add(name string, cvs ChartVersions) error {
+	b, err := json.Marshal(cvs)
+	if err != nil {
+		return err
+	}
+	cw.entries[name] = indexCacheSpan{Offset: cw.offset, Length: int64(len(b))}
+	cw.offset += int64(len(b)) + 1
+	_, err = cw.w.Write(append(b, '\n'))
+	return err
+}
+
+// commit writes the header locating the charts written so far and replaces
+// the cache at dest.
+func (cw *indexCacheWriter) commit(header indexCacheHeader) error {
+	defer os.Remove(cw.f.Name())
+	header.Entries = cw.entries
+	b, err := json.Marshal(header)
+	if err != nil {
+		cw.f.Close()
+		return err
+	}
+	cw.w.Write(append(b, '\n'))
+	fmt.Fprintf(cw.w, "%016x\n", cw.offset)
+
+	if err := cw.w.Flush(); err != nil {
+		cw.f.Close()
+		return err
+	}
+	if err := cw.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(cw.f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(cw.f.Name(), cw.dest)
+}
+
+// abort discards the cache.
+func (cw *indexCacheWriter) abort() {
+	cw.f.Close()
+	os.Remove(cw.f.Name())
+}
+
+// openIndexCache opens the compact cache of the index at path. It fails if
+// there is none, or if the index changed since the cache was written.
+func openIndexCache(path string) (*indexCache, error) {
+	source, err := sourceOf(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &indexCache{path: indexCachePath(path)}
+	f, err := os.Open(c.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	c.size, c.modTime = fi.Size(), fi.ModTime()
+
+	corrupt := errors.Errorf("corrupt index cache %s", c.path)
+	if c.size < int64(len(indexCacheMagic))+indexCacheTrailerLen {
+		return nil, corrupt
+	}
+	magic := make([]byte, len(indexCacheMagic))
+	if _, err := f.ReadAt(magic, 0); err != nil || string(magic) != indexCacheMagic {
+		return nil, corrupt
+	}
+	trailer := make([]byte, indexCacheTrailerLen)
+	if _, err := f.ReadAt(trailer, c.size-indexCacheTrailerLen); err != nil {
+		return nil, err
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(trailer)), 16, 64)
+	if err != nil || offset < int64(len(indexCacheMagic)) || offset > c.size-indexCacheTrailerLen {
+		return nil, corrupt
+	}
+	b := make([]byte, c.size-indexCacheTrailerLen-offset)
+	if _, err := f.ReadAt(b, offset); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &c.header); err != nil {
+		return nil, corrupt
+	}
+	if c.header.Source != source {
+		return nil, errors.Errorf("index cache %s is out of date", c.path)
+	}
+	return c, nil
+}
+
+// indexFile returns the index. If lazy is set, the versions of the charts
+// are only read when they are looked up.
+func (c *indexCache) indexFile(lazy bool) (*IndexFile, error) {
+	i := &IndexFile{
+		APIVersion:  c.header.APIVersion,
+		Generated:   c.header.Generated,
+		Entries:     make(map[string]ChartVersions, len(c.header.Entries)),
+		PublicKeys:  c.header.PublicKeys,
+		Annotations: c.header.Annotations,
+	}
+	if lazy {
+		i.lazy = c
+		return i, nil
+	}
+
+	f, err := os.Open(c.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	for name, span := range c.header.Entries {
+		var cvs ChartVersions
+		if err := decodeSpan(f, span, &cvs); err != nil {
+			return nil, errors.Wrapf(err, "error loading chart %s from %s", name, c.path)
+		}
+		i.Entries[name] = cvs
+	}
+	return i, nil
+}
+
+// load reads the versions of the named chart. It reports false if the index
+// has no such chart.
+func (c *indexCache) load(name string) (ChartVersions, bool, error) {
+	span, ok := c.header.Entries[name]
+	if !ok {
+		return nil, false, nil
+	}
+	f, err := os.Open(c.path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err != nil {
+		return nil, false, err
+	} else if fi.Size() != c.size || !fi.ModTime().Equal(c.modTime) {
+		return nil, false, errors.Errorf("index cache %s changed while in use", c.path)
+	}
+	var cvs ChartVersions
+	if err := decodeSpan(f, span, &cvs); err != nil {
+		return nil, false, errors.Wrapf(err, "error loading chart %s from %s", name, c.path)
+	}
+	return cvs, true, nil
+}
+
+func decodeSpan(r io.ReaderAt, span indexCacheSpan, v interface{}) error {
+	b := make([]byte, span.Length)
+	if _, err := r.ReadAt(b, span.Offset); err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
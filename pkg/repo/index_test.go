@@ -23,10 +23,12 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
 
+	"helm.sh/helm/v3/internal/test/ensure"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/getter"
@@ -310,6 +312,290 @@ func TestDownloadIndexFile(t *testing.T) {
 		}
 		verifyLocalChartsFile(t, b, i)
 	})
+
+	t.Run("should only download a modified index", func(t *testing.T) {
+		fileBytes, err := ioutil.ReadFile("testdata/local-index.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		etag := `"1"`
+		var downloads int
+		// removeCache is the compact cache to remove before responding
+		var removeCache string
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if removeCache != "" {
+				os.Remove(removeCache)
+			}
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			downloads++
+			w.Header().Set("ETag", etag)
+			w.Write(fileBytes)
+		})
+		srv, err := startLocalServerForTests(handler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer srv.Close()
+
+		r, err := NewChartRepository(&Entry{
+			Name: testRepo,
+			URL:  srv.URL,
+		}, getter.All(&cli.EnvSettings{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.CachePath = ensure.TempDir(t)
+
+		for _, want := range []int{1, 1} {
+			if _, err := r.DownloadIndexFile(); err != nil {
+				t.Fatal(err)
+			}
+			if downloads != want {
+				t.Fatalf("expected %d downloads, got %d", want, downloads)
+			}
+		}
+
+		// A modified index is downloaded again.
+		etag = `"2"`
+		if _, err := r.DownloadIndexFile(); err != nil {
+			t.Fatal(err)
+		}
+		if downloads != 2 {
+			t.Fatalf("expected 2 downloads, got %d", downloads)
+		}
+
+		// The compact cache is rebuilt if it went away before the index was
+		// reported unmodified.
+		cache := filepath.Join(r.CachePath, helmpath.CacheCompactIndexFile(r.Config.Name))
+		removeCache = cache
+		if _, err := r.DownloadIndexFile(); err != nil {
+			t.Fatal(err)
+		}
+		removeCache = ""
+		if downloads != 2 {
+			t.Fatalf("expected 2 downloads, got %d", downloads)
+		}
+		if _, err := os.Stat(cache); err != nil {
+			t.Fatalf("expected the compact cache to be rebuilt: %s", err)
+		}
+
+		// The validators are not sent once the cached index was changed.
+		idx := filepath.Join(r.CachePath, helmpath.CacheIndexFile(r.Config.Name))
+		if err := ioutil.WriteFile(idx, fileBytes[:len(fileBytes)-1], 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.DownloadIndexFile(); err != nil {
+			t.Fatal(err)
+		}
+		if downloads != 3 {
+			t.Fatalf("expected 3 downloads, got %d", downloads)
+		}
+	})
+}
+
+func TestLoadIndexFileLazy(t *testing.T) {
+	srv, err := startLocalServerForTests(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	r, err := NewChartRepository(&Entry{
+		Name: testRepo,
+		URL:  srv.URL,
+	}, getter.All(&cli.EnvSettings{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.CachePath = ensure.TempDir(t)
+	idx, err := r.DownloadIndexFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i, err := LoadIndexFileLazy(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(i.Entries) != 0 {
+		t.Fatalf("expected no chart to be loaded, got %d", len(i.Entries))
+	}
+	cv, err := i.Get("nginx", "0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cv.Version != "0.1.0" {
+		t.Errorf("expected nginx 0.1.0, got %s", cv.Version)
+	}
+	if len(i.Entries) != 1 || len(i.Entries["nginx"]) != 2 {
+		t.Errorf("expected only the versions of nginx to be loaded, got %v", i.Entries)
+	}
+	if _, err := i.Get("missing", ""); err != ErrNoChartName {
+		t.Errorf("expected ErrNoChartName, got %v", err)
+	}
+
+	// The same index is loaded from the cache and from the YAML document.
+	full, err := LoadIndexFile(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyLocalIndex(t, full)
+	b, err := ioutil.ReadFile(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromYAML, err := loadIndex(b, idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(full.Entries, fromYAML.Entries) {
+		t.Errorf("expected the cached entries to match the YAML document")
+	}
+
+	// An out of date cache is ignored.
+	if err := ioutil.WriteFile(idx, []byte("apiVersion: v1\nentries: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if i, err = LoadIndexFileLazy(idx); err != nil {
+		t.Fatal(err)
+	}
+	if i.Has("nginx", "0.1.0") {
+		t.Error("expected the out of date cache to be ignored")
+	}
+}
+
+func TestCacheIndexFile(t *testing.T) {
+	dir := ensure.TempDir(t)
+	for _, file := range []string{testfile, annotationstestfile, chartmuseumtestfile, unorderedTestfile} {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		idx := filepath.Join(dir, filepath.Base(file))
+		if err := ioutil.WriteFile(idx, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		names, err := cacheIndexFile(idx, idx, file, getter.Validators{ETag: `"1"`})
+		if err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+
+		fromYAML, err := loadIndex(b, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := openIndexCache(idx)
+		if err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+		if c.header.Validators.ETag != `"1"` {
+			t.Errorf("%s: expected the validators to be cached, got %v", file, c.header.Validators)
+		}
+		cached, err := c.indexFile(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != len(fromYAML.Entries) {
+			t.Errorf("%s: expected %d chart names, got %v", file, len(fromYAML.Entries), names)
+		}
+		if !reflect.DeepEqual(cached.Entries, fromYAML.Entries) {
+			t.Errorf("%s: expected the cached entries to match the YAML document", file)
+		}
+		if cached.APIVersion != fromYAML.APIVersion || !cached.Generated.Equal(fromYAML.Generated) ||
+			!reflect.DeepEqual(cached.Annotations, fromYAML.Annotations) || !reflect.DeepEqual(cached.PublicKeys, fromYAML.PublicKeys) {
+			t.Errorf("%s: expected the cached header to match the YAML document", file)
+		}
+	}
+
+	// indexes that cannot be read one chart at a time are decoded as a whole
+	idx := filepath.Join(dir, "json-index.yaml")
+	if err := ioutil.WriteFile(idx, []byte(`{"apiVersion": "v1", "entries": {"alpine": [{"name": "alpine", "version": "1.0.0"}]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	names, err := cacheIndexFile(idx, idx, "json", getter.Validators{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"alpine"}) {
+		t.Errorf("expected the chart names of the JSON index, got %v", names)
+	}
+	if i, err := LoadIndexFile(idx); err != nil || !i.Has("alpine", "1.0.0") {
+		t.Errorf("expected the JSON index to be cached, got %v", err)
+	}
+
+	for name, index := range map[string]string{
+		"duplicates":     indexWithDuplicates,
+		"no api version": "entries: {}\n",
+		"empty":          "",
+		"unknown field":  "apiVersion: v1\nentries: {}\nunknown: true\n",
+		"invalid chart":  "apiVersion: v1\nentries:\n  alpine:\n  - name: alpine\n    version: [1]\n",
+	} {
+		idx := filepath.Join(dir, "invalid-index.yaml")
+		if err := ioutil.WriteFile(idx, []byte(index), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cacheIndexFile(idx, idx, name, getter.Validators{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if _, err := os.Stat(indexCachePath(idx)); !os.IsNotExist(err) {
+			t.Errorf("%s: expected no cache to be written, got %v", name, err)
+		}
+	}
+}
+
+func TestStreamIndexFile(t *testing.T) {
+	// indexes in block style are read one chart at a time
+	blockStyle := "# generated\r\napiVersion: v1\r\nentries:\r\n" +
+		"  alpine:\r\n  - name: alpine\r\n    version: 1.0.0\r\n    description: |\r\n      Deploy a basic Alpine Linux pod\r\n\r\n      with a comment\r\n    urls:\r\n    - https://charts.example.com/alpine-1.0.0.tgz\r\n" +
+		"# trailing comment\r\n  \"mysql\":\r\n    - name: mysql\r\n      version: 0.1.0\r\n" +
+		"generated: \"2016-10-06T16:23:20.499029981-06:00\"\r\n"
+	for _, file := range []string{testfile, annotationstestfile, chartmuseumtestfile, unorderedTestfile, ""} {
+		b := []byte(blockStyle)
+		if file != "" {
+			var err error
+			if b, err = ioutil.ReadFile(file); err != nil {
+				t.Fatal(err)
+			}
+		}
+		entries := map[string]ChartVersions{}
+		i, err := streamIndexFile(bytes.NewReader(b), func(name string, cvs ChartVersions) error {
+			entries[name] = validChartVersions(name, cvs, file)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%q: %s", file, err)
+		}
+		i.Entries = entries
+		i.SortEntries()
+
+		fromYAML, err := loadIndex(b, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(i, fromYAML) {
+			t.Errorf("%q: expected the streamed index to match the YAML document", file)
+		}
+	}
+
+	// other indexes must be decoded as a whole
+	for name, index := range map[string]string{
+		"json":               `{"apiVersion": "v1", "entries": {"alpine": [{"name": "alpine", "version": "1.0.0"}]}}`,
+		"flow entries":       "apiVersion: v1\nentries: {alpine: [{name: alpine, version: 1.0.0}]}\n",
+		"duplicates":         indexWithDuplicates,
+		"duplicate charts":   "apiVersion: v1\nentries:\n  alpine: []\n  alpine: []\n",
+		"aliases":            "apiVersion: v1\nentries:\n  alpine: &alpine\n  - name: alpine\n    version: 1.0.0\n  other: *alpine\n",
+		"multiple documents": "apiVersion: v1\nentries: {}\n---\napiVersion: v1\n",
+		"no api version":     "entries: {}\n",
+		"empty":              "",
+	} {
+		_, err := streamIndexFile(strings.NewReader(index), func(string, ChartVersions) error { return nil })
+		if err != errIndexNotStreamable {
+			t.Errorf("%s: expected %v, got %v", name, errIndexNotStreamable, err)
+		}
+	}
 }
 
 func verifyLocalIndex(t *testing.T, i *IndexFile) {
@@ -18,6 +18,7 @@ package driver // import "helm.sh/helm/v3/pkg/storage/driver"
 
 import (
 	"context"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -30,10 +31,12 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
+	"helm.sh/helm/v3/pkg/chart"
 	rspb "helm.sh/helm/v3/pkg/release"
 )
 
 var _ Driver = (*ConfigMaps)(nil)
+var _ ChartStore = (*ConfigMaps)(nil)
 
 // ConfigMapsDriverName is the string name of the driver.
 const ConfigMapsDriverName = "ConfigMap"
@@ -43,6 +46,15 @@ const ConfigMapsDriverName = "ConfigMap"
 type ConfigMaps struct {
 	impl corev1.ConfigMapInterface
 	Log  func(string, ...interface{})
+
+	// ChunkSize is the maximum size of the encoded release stored in a
+	// single ConfigMap. Larger releases are split across several ConfigMaps.
+	// Values of 0 or less use DefaultChunkSize.
+	ChunkSize int
+
+	// Encryptor encrypts the releases and charts stored in ConfigMaps, if set.
+	// Unencrypted records stay readable.
+	Encryptor Encryptor
 }
 
 // NewConfigMaps initializes a new ConfigMaps wrapping an implementation of
@@ -73,11 +85,15 @@ func (cfgmaps *ConfigMaps) Get(key string) (*rspb.Release, error) {
 		return nil, err
 	}
 	// found the configmap, decode the base64 data string
-	r, err := decodeRelease(obj.Data["release"])
+	r, err := cfgmaps.decodeConfigMap(obj)
 	if err != nil {
 		cfgmaps.Log("get: failed to decode data %q: %s", key, err)
 		return nil, err
 	}
+	if err := resolveChart(cfgmaps, r); err != nil {
+		cfgmaps.Log("get: %s", err)
+		return nil, err
+	}
 	// return the release object
 	return r, nil
 }
@@ -99,8 +115,9 @@ func (cfgmaps *ConfigMaps) List(filter func(*rspb.Release) bool) ([]*rspb.Releas
 
 	// iterate over the configmaps object list
 	// and decode each release
-	for _, item := range list.Items {
-		rls, err := decodeRelease(item.Data["release"])
+	for i := range list.Items {
+		item := &list.Items[i]
+		rls, err := cfgmaps.decodeConfigMap(item)
 		if err != nil {
 			cfgmaps.Log("list: failed to decode release: %v: %s", item, err)
 			continue
@@ -109,6 +126,10 @@ func (cfgmaps *ConfigMaps) List(filter func(*rspb.Release) bool) ([]*rspb.Releas
 		rls.Labels = item.ObjectMeta.Labels
 
 		if filter(rls) {
+			if err := resolveChart(cfgmaps, rls); err != nil {
+				cfgmaps.Log("list: %s", err)
+				continue
+			}
 			results = append(results, rls)
 		}
 	}
@@ -139,12 +160,20 @@ func (cfgmaps *ConfigMaps) Query(labels map[string]string) ([]*rspb.Release, err
 	}
 
 	var results []*rspb.Release
-	for _, item := range list.Items {
-		rls, err := decodeRelease(item.Data["release"])
+	for i := range list.Items {
+		item := &list.Items[i]
+		if _, ok := item.Labels[chunkLabel]; ok {
+			continue
+		}
+		rls, err := cfgmaps.decodeConfigMap(item)
 		if err != nil {
 			cfgmaps.Log("query: failed to decode release: %s", err)
 			continue
 		}
+		if err := resolveChart(cfgmaps, rls); err != nil {
+			cfgmaps.Log("query: %s", err)
+			continue
+		}
 		results = append(results, rls)
 	}
 	return results, nil
@@ -152,6 +181,8 @@ func (cfgmaps *ConfigMaps) Query(labels map[string]string) ([]*rspb.Release, err
 
 // Create creates a new ConfigMap holding the release. If the
 // ConfigMap already exists, ErrReleaseExists is returned.
+//
+// Releases larger than the chunk size are split across several ConfigMaps.
 func (cfgmaps *ConfigMaps) Create(key string, rls *rspb.Release) error {
 	// set labels for configmaps object meta data
 	var lbs labels
@@ -159,14 +190,14 @@ func (cfgmaps *ConfigMaps) Create(key string, rls *rspb.Release) error {
 	lbs.init()
 	lbs.set("createdAt", strconv.Itoa(int(time.Now().Unix())))
 
-	// create a new configmap to hold the release
-	obj, err := newConfigMapsObject(key, rls, lbs)
+	// create new configmaps to hold the release
+	objs, err := newConfigMapsObjects(key, rls, lbs, 0, cfgmaps.ChunkSize, cfgmaps.Encryptor)
 	if err != nil {
 		cfgmaps.Log("create: failed to encode release %q: %s", rls.Name, err)
 		return err
 	}
 	// push the configmap object out into the kubiverse
-	if _, err := cfgmaps.impl.Create(context.Background(), obj, metav1.CreateOptions{}); err != nil {
+	if _, err := cfgmaps.impl.Create(context.Background(), objs[0], metav1.CreateOptions{}); err != nil {
 		if apierrors.IsAlreadyExists(err) {
 			return ErrReleaseExists
 		}
@@ -174,51 +205,300 @@ func (cfgmaps *ConfigMaps) Create(key string, rls *rspb.Release) error {
 		cfgmaps.Log("create: failed to create: %s", err)
 		return err
 	}
+	for _, obj := range objs[1:] {
+		if err := cfgmaps.putChunk(obj); err != nil {
+			cfgmaps.Log("create: failed to create chunk: %s", err)
+			cfgmaps.deleteChunks(key, 0, 0, len(objs))
+			return err
+		}
+	}
 	return nil
 }
 
-// Update updates the ConfigMap holding the release. If not found
-// the ConfigMap is created to hold the release.
+// Update updates the ConfigMap holding the release.
+//
+// Releases larger than the chunk size are split across several ConfigMaps.
+// The chunks are written under a new generation before the first ConfigMap
+// is switched over to them, and the chunks of the previous generation are
+// only deleted afterwards, so a failed update leaves the stored release
+// intact.
 func (cfgmaps *ConfigMaps) Update(key string, rls *rspb.Release) error {
+	prev, err := cfgmaps.impl.Get(context.Background(), key, metav1.GetOptions{})
+	if err != nil {
+		cfgmaps.Log("update: failed to get: %s", err)
+		return err
+	}
+	prevChunks, _ := chunkCount(prev.Labels)
+	prevGeneration, _ := chunkGeneration(prev.Labels)
+	generation := prevGeneration + 1
+
 	// set labels for configmaps object meta data
 	var lbs labels
 
 	lbs.init()
 	lbs.set("modifiedAt", strconv.Itoa(int(time.Now().Unix())))
 
-	// create a new configmap object to hold the release
-	obj, err := newConfigMapsObject(key, rls, lbs)
+	// create new configmap objects to hold the release
+	objs, err := newConfigMapsObjects(key, rls, lbs, generation, cfgmaps.ChunkSize, cfgmaps.Encryptor)
 	if err != nil {
 		cfgmaps.Log("update: failed to encode release %q: %s", rls.Name, err)
 		return err
 	}
+	// fail rather than reference chunks another update is replacing
+	objs[0].ResourceVersion = prev.ResourceVersion
+
+	// write the chunks before the first object, which references them
+	for _, obj := range objs[1:] {
+		if err := cfgmaps.putChunk(obj); err != nil {
+			cfgmaps.Log("update: failed to create chunk: %s", err)
+			cfgmaps.deleteChunks(key, generation, 1, len(objs))
+			return err
+		}
+	}
 	// push the configmap object out into the kubiverse
-	_, err = cfgmaps.impl.Update(context.Background(), obj, metav1.UpdateOptions{})
+	_, err = cfgmaps.impl.Update(context.Background(), objs[0], metav1.UpdateOptions{})
 	if err != nil {
 		cfgmaps.Log("update: failed to update: %s", err)
+		cfgmaps.deleteChunks(key, generation, 1, len(objs))
 		return err
 	}
+	cfgmaps.deleteChunks(key, prevGeneration, 1, prevChunks)
 	return nil
 }
 
 // Delete deletes the ConfigMap holding the release named by key.
 func (cfgmaps *ConfigMaps) Delete(key string) (rls *rspb.Release, err error) {
 	// fetch the release to check existence
-	if rls, err = cfgmaps.Get(key); err != nil {
+	obj, err := cfgmaps.impl.Get(context.Background(), key, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrReleaseNotFound
+		}
+		return nil, err
+	}
+	if rls, err = cfgmaps.decodeConfigMap(obj); err != nil {
 		return nil, err
 	}
+	if err := resolveChart(cfgmaps, rls); err != nil {
+		cfgmaps.Log("delete: %s", err)
+	}
 	// delete the release
 	if err = cfgmaps.impl.Delete(context.Background(), key, metav1.DeleteOptions{}); err != nil {
 		return rls, err
 	}
+	n, _ := chunkCount(obj.Labels)
+	generation, _ := chunkGeneration(obj.Labels)
+	cfgmaps.deleteChunks(key, generation, 1, n)
 	return rls, nil
 }
 
-// newConfigMapsObject constructs a kubernetes ConfigMap object
+// decodeConfigMap decodes the release stored in the given ConfigMap, joining
+// the chunks stored in further ConfigMaps if the release was split. The chart
+// of a release stored without its chart is not loaded.
+func (cfgmaps *ConfigMaps) decodeConfigMap(obj *v1.ConfigMap) (*rspb.Release, error) {
+	data, err := cfgmaps.joinChunks(obj, "release")
+	if err != nil {
+		return nil, err
+	}
+	return decryptRelease(cfgmaps.Encryptor, data)
+}
+
+// joinChunks returns the data entry of the given ConfigMap, joined with the
+// chunks stored in further ConfigMaps if the data was split.
+func (cfgmaps *ConfigMaps) joinChunks(obj *v1.ConfigMap, entry string) (string, error) {
+	n, err := chunkCount(obj.Labels)
+	if err != nil {
+		return "", err
+	}
+	generation, err := chunkGeneration(obj.Labels)
+	if err != nil {
+		return "", err
+	}
+
+	var data strings.Builder
+	data.WriteString(obj.Data[entry])
+	for i := 1; i < n; i++ {
+		chunk, err := cfgmaps.impl.Get(context.Background(), chunkKey(obj.Name, generation, i), metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get chunk %d of %d", i+1, n)
+		}
+		data.WriteString(chunk.Data[entry])
+	}
+	return data.String(), nil
+}
+
+// CreateChart stores a chart in ConfigMaps named by its digest.
+func (cfgmaps *ConfigMaps) CreateChart(digest string, ch *chart.Chart) error {
+	key := chartKey(digest)
+	if obj, err := cfgmaps.impl.Get(context.Background(), key, metav1.GetOptions{}); err == nil {
+		// refresh the time the chart was stored at, which protects it from
+		// being pruned before the release referencing it is stored
+		obj.Labels[storedAtLabel] = strconv.FormatInt(time.Now().Unix(), 10)
+		if _, err := cfgmaps.impl.Update(context.Background(), obj, metav1.UpdateOptions{}); err != nil {
+			cfgmaps.Log("create chart: failed to update %q: %s", key, err)
+			return err
+		}
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		cfgmaps.Log("create chart: failed to get %q: %s", key, err)
+		return err
+	}
+
+	objs, err := newChartConfigMaps(key, ch, 0, cfgmaps.ChunkSize, cfgmaps.Encryptor)
+	if err != nil {
+		cfgmaps.Log("create chart: failed to encode chart %s: %s", digest, err)
+		return err
+	}
+	// write the chunks before the first object, which references them
+	for _, obj := range objs[1:] {
+		if err := cfgmaps.putChunk(obj); err != nil {
+			cfgmaps.Log("create chart: failed to create chunk: %s", err)
+			return err
+		}
+	}
+	_, err = cfgmaps.impl.Create(context.Background(), objs[0], metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		cfgmaps.Log("create chart: failed to create: %s", err)
+		return err
+	}
+	return nil
+}
+
+// GetChart fetches the chart stored under the given digest.
+func (cfgmaps *ConfigMaps) GetChart(digest string) (*chart.Chart, error) {
+	key := chartKey(digest)
+	obj, err := cfgmaps.impl.Get(context.Background(), key, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrChartNotFound
+		}
+		cfgmaps.Log("get chart: failed to get %q: %s", key, err)
+		return nil, err
+	}
+	data, err := cfgmaps.joinChunks(obj, "chart")
+	if err != nil {
+		cfgmaps.Log("get chart: failed to get data %q: %s", key, err)
+		return nil, err
+	}
+	ch, err := decryptChart(cfgmaps.Encryptor, data)
+	if err != nil {
+		cfgmaps.Log("get chart: failed to decode data %q: %s", key, err)
+		return nil, err
+	}
+	return ch, nil
+}
+
+// UpdateChart rewrites the chart stored under the given digest. Like the
+// chunks of releases, the chunks of the chart are written under a new
+// generation before the first ConfigMap is switched over to them.
+func (cfgmaps *ConfigMaps) UpdateChart(digest string, ch *chart.Chart) error {
+	key := chartKey(digest)
+	prev, err := cfgmaps.impl.Get(context.Background(), key, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return ErrChartNotFound
+		}
+		cfgmaps.Log("update chart: failed to get %q: %s", key, err)
+		return err
+	}
+	prevChunks, _ := chunkCount(prev.Labels)
+	prevGeneration, _ := chunkGeneration(prev.Labels)
+	generation := prevGeneration + 1
+
+	objs, err := newChartConfigMaps(key, ch, generation, cfgmaps.ChunkSize, cfgmaps.Encryptor)
+	if err != nil {
+		cfgmaps.Log("update chart: failed to encode chart %s: %s", digest, err)
+		return err
+	}
+	objs[0].ResourceVersion = prev.ResourceVersion
+
+	// write the chunks before the first object, which references them
+	for _, obj := range objs[1:] {
+		if err := cfgmaps.putChunk(obj); err != nil {
+			cfgmaps.Log("update chart: failed to create chunk: %s", err)
+			cfgmaps.deleteChunks(key, generation, 1, len(objs))
+			return err
+		}
+	}
+	if _, err := cfgmaps.impl.Update(context.Background(), objs[0], metav1.UpdateOptions{}); err != nil {
+		cfgmaps.Log("update chart: failed to update: %s", err)
+		cfgmaps.deleteChunks(key, generation, 1, len(objs))
+		return err
+	}
+	cfgmaps.deleteChunks(key, prevGeneration, 1, prevChunks)
+	return nil
+}
+
+// DeleteChart deletes the ConfigMaps holding the chart stored under the
+// given digest.
+func (cfgmaps *ConfigMaps) DeleteChart(digest string) error {
+	key := chartKey(digest)
+	obj, err := cfgmaps.impl.Get(context.Background(), key, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return ErrChartNotFound
+		}
+		return err
+	}
+	if err := cfgmaps.impl.Delete(context.Background(), key, metav1.DeleteOptions{}); err != nil {
+		return err
+	}
+	n, _ := chunkCount(obj.Labels)
+	generation, _ := chunkGeneration(obj.Labels)
+	cfgmaps.deleteChunks(key, generation, 1, n)
+	return nil
+}
+
+// ListCharts returns all charts stored in ConfigMaps.
+func (cfgmaps *ConfigMaps) ListCharts() ([]StoredChart, error) {
+	lsel := kblabels.Set{"owner": chartOwner}.AsSelector()
+	opts := metav1.ListOptions{LabelSelector: lsel.String()}
+
+	list, err := cfgmaps.impl.List(context.Background(), opts)
+	if err != nil {
+		cfgmaps.Log("list charts: failed to list: %s", err)
+		return nil, err
+	}
+	var charts []StoredChart
+	for _, item := range list.Items {
+		charts = append(charts, StoredChart{
+			Digest:   chartDigestFromKey(item.Name),
+			StoredAt: chartStoredAt(item.Labels, item.CreationTimestamp.Time),
+		})
+	}
+	return charts, nil
+}
+
+// putChunk creates or updates a ConfigMap holding a chunk of a release.
+func (cfgmaps *ConfigMaps) putChunk(obj *v1.ConfigMap) error {
+	_, err := cfgmaps.impl.Create(context.Background(), obj, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = cfgmaps.impl.Update(context.Background(), obj, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// deleteChunks deletes the ConfigMaps holding the chunks from index start up
+// to end of the release named by key. Chunks that do not exist are ignored.
+func (cfgmaps *ConfigMaps) deleteChunks(key string, generation, start, end int) {
+	for i := start; i < end; i++ {
+		err := cfgmaps.impl.Delete(context.Background(), chunkKey(key, generation, i), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			cfgmaps.Log("failed to delete chunk %d of %q: %s", i+1, key, err)
+		}
+	}
+}
+
+// newConfigMapsObjects constructs the kubernetes ConfigMap objects
 // to store a release. Each configmap data entry is the base64
-// encoded gzipped string of a release.
+// encoded gzipped string of a release, encrypted if enc is set.
+// If the encoded release is larger than chunkSize, it is split
+// across several configmaps.
+// The first configmap is named by key, the remaining ones are named
+// by key with the suffix ".c<index>", or ".g<generation>.c<index>"
+// if generation is not 0.
 //
-// The following labels are used within each configmap:
+// The following labels are used within the first configmap:
 //
 //    "modifiedAt"     - timestamp indicating when this configmap was last modified. (set in Update)
 //    "createdAt"      - timestamp indicating when this configmap was created. (set in Create)
@@ -226,32 +506,104 @@ func (cfgmaps *ConfigMaps) Delete(key string) (rls *rspb.Release, err error) {
 //    "status"         - status of the release (see pkg/release/status.go for variants)
 //    "owner"          - owner of the configmap, currently "helm".
 //    "name"           - name of the release.
+//    "chunks"         - number of configmaps the release is split across, if more than one.
+//    "generation"     - generation of the remaining configmaps, if not 0.
 //
-func newConfigMapsObject(key string, rls *rspb.Release, lbs labels) (*v1.ConfigMap, error) {
-	const owner = "helm"
-
-	// encode the release
-	s, err := encodeRelease(rls)
+// The remaining configmaps are labelled with "name" and "version" of the
+// release, "owner" set to "helm-chunk" and "chunk" set to their index.
+func newConfigMapsObjects(key string, rls *rspb.Release, lbs labels, generation, chunkSize int, enc Encryptor) ([]*v1.ConfigMap, error) {
+	// encode and encrypt the release
+	s, err := encryptRelease(enc, rls)
 	if err != nil {
 		return nil, err
 	}
+	chunks := splitChunks(s, chunkSize)
 
 	if lbs == nil {
 		lbs.init()
 	}
+	if len(chunks) > 1 {
+		lbs.set(chunksLabel, strconv.Itoa(len(chunks)))
+		if generation > 0 {
+			lbs.set(generationLabel, strconv.Itoa(generation))
+		}
+	}
 
-	// apply labels
-	lbs.set("name", rls.Name)
-	lbs.set("owner", owner)
 	lbs.set("status", rls.Info.Status.String())
-	lbs.set("version", strconv.Itoa(rls.Version))
+	objs := []*v1.ConfigMap{newConfigMap(key, chunks[0], releaseLabels(rls, lbs, "helm"))}
+	for i, chunk := range chunks[1:] {
+		var chunkLbs labels
+		chunkLbs.init()
+		chunkLbs.set(chunkLabel, strconv.Itoa(i+1))
+		objs = append(objs, newConfigMap(chunkKey(key, generation, i+1), chunk, releaseLabels(rls, chunkLbs, chunkOwner)))
+	}
+	return objs, nil
+}
 
-	// create and return configmap object
+// newConfigMapsObject constructs a single kubernetes ConfigMap object
+// to store a release, regardless of the size of the release.
+// See newConfigMapsObjects for the labels used.
+func newConfigMapsObject(key string, rls *rspb.Release, lbs labels) (*v1.ConfigMap, error) {
+	objs, err := newConfigMapsObjects(key, rls, lbs, 0, math.MaxInt32, nil)
+	if err != nil {
+		return nil, err
+	}
+	return objs[0], nil
+}
+
+// newChartConfigMaps constructs the kubernetes ConfigMap objects to
+// store a chart separately from releases. The configmap data entry
+// is the base64 encoded gzipped string of the chart, split across
+// several configmaps like releases if it is larger than chunkSize.
+//
+// The first configmap is labelled with "owner" set to "helm-chart",
+// "storedAt" set to the current time, "chunks" if the chart is split
+// and "generation" if the chunks are of a generation other than 0. The remaining configmaps are
+// labelled with "owner" set to "helm-chunk" and "chunk" set to their
+// index.
+func newChartConfigMaps(key string, ch *chart.Chart, generation, chunkSize int, enc Encryptor) ([]*v1.ConfigMap, error) {
+	s, err := encryptChart(enc, ch)
+	if err != nil {
+		return nil, err
+	}
+	chunks := splitChunks(s, chunkSize)
+
+	var objs []*v1.ConfigMap
+	for i, chunk := range chunks {
+		var lbs labels
+		lbs.init()
+		if i == 0 {
+			lbs.set("owner", chartOwner)
+			lbs.set(storedAtLabel, strconv.FormatInt(time.Now().Unix(), 10))
+			if len(chunks) > 1 {
+				lbs.set(chunksLabel, strconv.Itoa(len(chunks)))
+				if generation > 0 {
+					lbs.set(generationLabel, strconv.Itoa(generation))
+				}
+			}
+		} else {
+			lbs.set("owner", chunkOwner)
+			lbs.set(chunkLabel, strconv.Itoa(i))
+		}
+		objs = append(objs, &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   chunkKey(key, generation, i),
+				Labels: lbs.toMap(),
+			},
+			Data: map[string]string{"chart": chunk},
+		})
+	}
+	return objs, nil
+}
+
+// newConfigMap constructs a kubernetes ConfigMap object holding
+// the data of a release.
+func newConfigMap(name, data string, lbs labels) *v1.ConfigMap {
 	return &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   key,
+			Name:   name,
 			Labels: lbs.toMap(),
 		},
-		Data: map[string]string{"release": s},
-	}, nil
+		Data: map[string]string{"release": data},
+	}
 }
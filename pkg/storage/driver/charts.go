@@ -0,0 +1,147 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver // import "helm.sh/helm/v3/pkg/storage/driver"
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/chart"
+	rspb "helm.sh/helm/v3/pkg/release"
+)
+
+// ErrChartNotFound indicates that a chart stored separately from releases is not found.
+var ErrChartNotFound = errors.New("chart: not found")
+
+// chartKeyPrefix is the prefix of the names of the storage objects holding
+// charts stored separately from releases.
+const chartKeyPrefix = "sh.helm.chart.v1."
+
+// chartOwner is the owner of the storage objects holding charts.
+const chartOwner = "helm-chart"
+
+// storedAtLabel is set on the storage objects holding charts to the time the
+// chart was last stored, in seconds since the epoch.
+const storedAtLabel = "storedAt"
+
+// StoredChart describes a chart stored separately from releases.
+type StoredChart struct {
+	// Digest is the digest the chart is stored under.
+	Digest string
+	// StoredAt is when the chart was last stored by CreateChart.
+	StoredAt time.Time
+}
+
+// ChartStore is implemented by drivers that can store the charts of releases
+// separately from the releases, once per chart digest.
+//
+// Releases stored without their chart reference it by their ChartDigest, and
+// drivers implementing ChartStore load the chart when such a release is read.
+type ChartStore interface {
+	// CreateChart stores the chart under the given digest. Storing a chart
+	// that is already stored is not an error, and refreshes the time it was
+	// stored at.
+	CreateChart(digest string, ch *chart.Chart) error
+	// GetChart returns the chart stored under the given digest, or
+	// ErrChartNotFound.
+	GetChart(digest string) (*chart.Chart, error)
+	// UpdateChart rewrites the chart stored under the given digest, for
+	// example to encrypt it with the current key, or returns
+	// ErrChartNotFound. The chart stays readable if the update fails.
+	UpdateChart(digest string, ch *chart.Chart) error
+	// DeleteChart deletes the chart stored under the given digest.
+	DeleteChart(digest string) error
+	// ListCharts returns all stored charts.
+	ListCharts() ([]StoredChart, error)
+}
+
+// ChartDigest returns the content digest of a chart.
+func ChartDigest(ch *chart.Chart) (string, error) {
+	b, err := json.Marshal(ch)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// chartKey returns the name of the storage object holding the chart with the
+// given digest.
+func chartKey(digest string) string {
+	return chartKeyPrefix + digest
+}
+
+// chartDigestFromKey returns the digest of the chart held by the storage
+// object with the given name.
+func chartDigestFromKey(key string) string {
+	return strings.TrimPrefix(key, chartKeyPrefix)
+}
+
+// chartStoredAt returns the time a chart was last stored, given the labels of
+// the storage object holding it. If the label is missing, created is used.
+func chartStoredAt(lbs map[string]string, created time.Time) time.Time {
+	if sec, err := strconv.ParseInt(lbs[storedAtLabel], 10, 64); err == nil {
+		return time.Unix(sec, 0)
+	}
+	return created
+}
+
+// encodeChart encodes a chart returning a base64 encoded
+// gzipped string representation, or error.
+func encodeChart(ch *chart.Chart) (string, error) {
+	return encodeJSON(ch)
+}
+
+// decodeChart decodes a chart encoded by encodeChart.
+func decodeChart(data string) (*chart.Chart, error) {
+	b, err := b64.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var ch chart.Chart
+	if err := json.NewDecoder(r).Decode(&ch); err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// resolveChart sets the chart of a release that is stored without it.
+func resolveChart(store ChartStore, rls *rspb.Release) error {
+	if rls.Chart != nil || rls.ChartDigest == "" {
+		return nil
+	}
+	ch, err := store.GetChart(rls.ChartDigest)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get chart %s of release %q", rls.ChartDigest, rls.Name)
+	}
+	rls.Chart = ch
+	return nil
+}
@@ -0,0 +1,273 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver // import "helm.sh/helm/v3/pkg/storage/driver"
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/chart"
+	rspb "helm.sh/helm/v3/pkg/release"
+)
+
+// ErrNoEncryptor indicates that an encrypted record was read by a driver
+// without an Encryptor.
+var ErrNoEncryptor = errors.New("record is encrypted but no encryption is configured")
+
+// encryptedPrefix marks encrypted record data. It cannot occur in
+// unencrypted data, which is base64 encoded.
+const encryptedPrefix = "helm.sh/encrypted.v1:"
+
+// Encryptor encrypts and decrypts the data keys protecting release records.
+//
+// Records are encrypted with envelope encryption: each record is encrypted
+// with a new random data key using AES-GCM, and the data key is encrypted by
+// the Encryptor and stored alongside the record together with the ID of the
+// key it was encrypted with. An Encryptor that can decrypt with previous keys
+// allows rotating keys and re-encrypting the history of releases.
+type Encryptor interface {
+	// Encrypt encrypts a data key with the current key, returning the ID of
+	// the current key and the encrypted data key.
+	Encrypt(plaintext []byte) (keyID string, ciphertext []byte, err error)
+	// Decrypt decrypts a data key encrypted with the key of the given ID.
+	Decrypt(keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// envelope is an encrypted record.
+type envelope struct {
+	// KeyID is the ID of the key the data key was encrypted with.
+	KeyID string `json:"kid"`
+	// Key is the encrypted data key.
+	Key []byte `json:"key"`
+	// Nonce is the AES-GCM nonce the data was encrypted with.
+	Nonce []byte `json:"nonce"`
+	// Data is the encrypted data.
+	Data []byte `json:"data"`
+}
+
+// sealData encrypts data with a new data key protected by enc. Data is
+// returned unchanged if enc is nil.
+func sealData(enc Encryptor, data string) (string, error) {
+	if enc == nil {
+		return data, nil
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	nonce, sealed, err := aesGCMSeal(key, []byte(data))
+	if err != nil {
+		return "", err
+	}
+	env := envelope{Nonce: nonce, Data: sealed}
+	if env.KeyID, env.Key, err = enc.Encrypt(key); err != nil {
+		return "", errors.Wrap(err, "failed to encrypt data key")
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		return "", err
+	}
+	return encryptedPrefix + b64.EncodeToString(b), nil
+}
+
+// openData decrypts data encrypted by sealData. Unencrypted data is returned
+// unchanged, so records written before encryption was enabled stay readable.
+func openData(enc Encryptor, data string) (string, error) {
+	if !strings.HasPrefix(data, encryptedPrefix) {
+		return data, nil
+	}
+	if enc == nil {
+		return "", ErrNoEncryptor
+	}
+	b, err := b64.DecodeString(strings.TrimPrefix(data, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+	var env envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return "", err
+	}
+	key, err := enc.Decrypt(env.KeyID, env.Key)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decrypt data key with key %q", env.KeyID)
+	}
+	plain, err := aesGCMOpen(key, env.Nonce, env.Data)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decrypt data with key %q", env.KeyID)
+	}
+	return string(plain), nil
+}
+
+// encryptRelease encodes a release and encrypts it if enc is set.
+func encryptRelease(enc Encryptor, rls *rspb.Release) (string, error) {
+	s, err := encodeRelease(rls)
+	if err != nil {
+		return "", err
+	}
+	return sealData(enc, s)
+}
+
+// decryptRelease decrypts a release if it is encrypted and decodes it.
+func decryptRelease(enc Encryptor, data string) (*rspb.Release, error) {
+	s, err := openData(enc, data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRelease(s)
+}
+
+// encryptChart encodes a chart and encrypts it if enc is set.
+func encryptChart(enc Encryptor, ch *chart.Chart) (string, error) {
+	s, err := encodeChart(ch)
+	if err != nil {
+		return "", err
+	}
+	return sealData(enc, s)
+}
+
+// decryptChart decrypts a chart if it is encrypted and decodes it.
+func decryptChart(enc Encryptor, data string) (*chart.Chart, error) {
+	s, err := openData(enc, data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeChart(s)
+}
+
+// aesGCMSeal encrypts plaintext with AES-GCM using a random nonce.
+func aesGCMSeal(key, plaintext []byte) (nonce, ciphertext []byte, err error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, nil), nil
+}
+
+// aesGCMOpen decrypts ciphertext encrypted by aesGCMSeal.
+func aesGCMOpen(key, nonce, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce size")
+	}
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// KeyFileEncryptor is an Encryptor using AES-GCM with keys read from a file.
+//
+// The key file is a YAML document listing the keys by ID. The first key is
+// the current key used to encrypt, the others are only used to decrypt
+// records encrypted before the keys were rotated:
+//
+//     keys:
+//     - id: "2021-06"
+//       secret: <base64 encoded 16, 24 or 32 byte AES key>
+//     - id: "2021-01"
+//       secret: <base64 encoded 16, 24 or 32 byte AES key>
+type KeyFileEncryptor struct {
+	current string
+	keys    map[string][]byte
+}
+
+// keyFile is the content of a key file.
+type keyFile struct {
+	Keys []struct {
+		ID     string `json:"id"`
+		Secret []byte `json:"secret"`
+	} `json:"keys"`
+}
+
+// NewKeyFileEncryptor reads the keys of a KeyFileEncryptor from the given file.
+func NewKeyFileEncryptor(path string) (*KeyFileEncryptor, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read encryption key file")
+	}
+	var f keyFile
+	if err := yaml.UnmarshalStrict(b, &f); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse encryption key file %s", path)
+	}
+	if len(f.Keys) == 0 {
+		return nil, errors.Errorf("encryption key file %s contains no keys", path)
+	}
+
+	enc := &KeyFileEncryptor{
+		current: f.Keys[0].ID,
+		keys:    map[string][]byte{},
+	}
+	for _, k := range f.Keys {
+		if k.ID == "" {
+			return nil, errors.Errorf("encryption key file %s contains a key without an id", path)
+		}
+		if _, ok := enc.keys[k.ID]; ok {
+			return nil, errors.Errorf("encryption key file %s contains the key %q more than once", path, k.ID)
+		}
+		if _, err := aes.NewCipher(k.Secret); err != nil {
+			return nil, errors.Wrapf(err, "invalid encryption key %q in %s", k.ID, path)
+		}
+		enc.keys[k.ID] = k.Secret
+	}
+	return enc, nil
+}
+
+// Encrypt encrypts a data key with the first key of the key file.
+func (e *KeyFileEncryptor) Encrypt(plaintext []byte) (string, []byte, error) {
+	nonce, ciphertext, err := aesGCMSeal(e.keys[e.current], plaintext)
+	if err != nil {
+		return "", nil, err
+	}
+	return e.current, append(nonce, ciphertext...), nil
+}
+
+// Decrypt decrypts a data key with the key of the given ID.
+func (e *KeyFileEncryptor) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	key, ok := e.keys[keyID]
+	if !ok {
+		return nil, errors.Errorf("unknown encryption key %q", keyID)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("encrypted data key is too short")
+	}
+	nonce, data := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, data, nil)
+}
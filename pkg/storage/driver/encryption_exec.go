@@ -0,0 +1,143 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver // import "helm.sh/helm/v3/pkg/storage/driver"
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ExecEncryptor is an Encryptor delegating to an external KMS plugin.
+//
+// The plugin is run once per data key with a JSON request on stdin and writes
+// a JSON response to stdout. Binary data is base64 encoded:
+//
+//     {"operation": "encrypt", "data": "<data key>"}
+//     => {"keyID": "<ID of the key used>", "data": "<encrypted data key>"}
+//
+//     {"operation": "decrypt", "keyID": "<ID of the key used>", "data": "<encrypted data key>"}
+//     => {"data": "<data key>"}
+//
+// A non-zero exit status indicates a failure, described by the plugin's
+// standard error.
+//
+// Data keys are cached for the lifetime of the encryptor, so the plugin is
+// run at most once per encrypted data key, even if the record is read several
+// times.
+type ExecEncryptor struct {
+	// Command is the path of the plugin executable.
+	Command string
+	// Args are the arguments passed to the plugin.
+	Args []string
+
+	mu       sync.Mutex
+	dataKeys map[execDataKey][]byte
+}
+
+// execDataKey identifies a data key by the ID of the key it was encrypted with
+// and its encrypted value.
+type execDataKey struct {
+	keyID      string
+	ciphertext string
+}
+
+// execRequest is the request written to a KMS plugin.
+type execRequest struct {
+	Operation string `json:"operation"`
+	KeyID     string `json:"keyID,omitempty"`
+	Data      []byte `json:"data"`
+}
+
+// execResponse is the response read from a KMS plugin.
+type execResponse struct {
+	KeyID string `json:"keyID,omitempty"`
+	Data  []byte `json:"data"`
+}
+
+// NewExecEncryptor creates an ExecEncryptor running the given plugin command.
+func NewExecEncryptor(command string, args ...string) *ExecEncryptor {
+	return &ExecEncryptor{Command: command, Args: args}
+}
+
+// Encrypt asks the plugin to encrypt a data key.
+func (e *ExecEncryptor) Encrypt(plaintext []byte) (string, []byte, error) {
+	res, err := e.run(execRequest{Operation: "encrypt", Data: plaintext})
+	if err != nil {
+		return "", nil, err
+	}
+	if res.KeyID == "" {
+		return "", nil, errors.Errorf("KMS plugin %s returned no key ID", e.Command)
+	}
+	e.cacheDataKey(execDataKey{keyID: res.KeyID, ciphertext: string(res.Data)}, plaintext)
+	return res.KeyID, res.Data, nil
+}
+
+// Decrypt asks the plugin to decrypt a data key, unless it was encrypted or
+// decrypted by the encryptor before.
+func (e *ExecEncryptor) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	k := execDataKey{keyID: keyID, ciphertext: string(ciphertext)}
+	e.mu.Lock()
+	plaintext, ok := e.dataKeys[k]
+	e.mu.Unlock()
+	if ok {
+		return plaintext, nil
+	}
+
+	res, err := e.run(execRequest{Operation: "decrypt", KeyID: keyID, Data: ciphertext})
+	if err != nil {
+		return nil, err
+	}
+	e.cacheDataKey(k, res.Data)
+	return res.Data, nil
+}
+
+func (e *ExecEncryptor) cacheDataKey(k execDataKey, plaintext []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.dataKeys == nil {
+		e.dataKeys = map[execDataKey][]byte{}
+	}
+	e.dataKeys[k] = plaintext
+}
+
+func (e *ExecEncryptor) run(req execRequest) (*execResponse, error) {
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(e.Command, e.Args...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.Wrapf(err, "KMS plugin %s failed to %s: %s", e.Command, req.Operation, msg)
+		}
+		return nil, errors.Wrapf(err, "KMS plugin %s failed to %s", e.Command, req.Operation)
+	}
+	var res execResponse
+	if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+		return nil, errors.Wrapf(err, "KMS plugin %s returned an invalid response", e.Command)
+	}
+	return &res, nil
+}
@@ -0,0 +1,222 @@
+/*
+Copyright The Helm Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	rspb "helm.sh/helm/v3/pkg/release"
+)
+
+const (
+	testKeyFile = `keys:
+- id: "2021-06"
+  secret: MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=
+- id: "2021-01"
+  secret: ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=
+`
+	testOldKeyFile = `keys:
+- id: "2021-01"
+  secret: ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=
+`
+)
+
+func newTestKeyFileEncryptor(t *testing.T, content string) *KeyFileEncryptor {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	enc, err := NewKeyFileEncryptor(path)
+	if err != nil {
+		t.Fatalf("Failed to read key file: %s", err)
+	}
+	return enc
+}
+
+func TestKeyFileEncryptor(t *testing.T) {
+	enc := newTestKeyFileEncryptor(t, testKeyFile)
+	old := newTestKeyFileEncryptor(t, testOldKeyFile)
+
+	rel := releaseStub("smug-pigeon", 1, "default", rspb.StatusDeployed)
+	data, err := encryptRelease(old, rel)
+	if err != nil {
+		t.Fatalf("Failed to encrypt release: %s", err)
+	}
+	if !strings.HasPrefix(data, encryptedPrefix) {
+		t.Errorf("Expected encrypted data, got %q", data)
+	}
+
+	// records encrypted before rotating keys are still readable
+	got, err := decryptRelease(enc, data)
+	if err != nil {
+		t.Fatalf("Failed to decrypt release: %s", err)
+	}
+	if !reflect.DeepEqual(rel, got) {
+		t.Errorf("Expected {%v}, got {%v}", rel, got)
+	}
+
+	data, err = encryptRelease(enc, rel)
+	if err != nil {
+		t.Fatalf("Failed to encrypt release: %s", err)
+	}
+	if _, err := decryptRelease(old, data); err == nil {
+		t.Error("Expected an error decrypting with an unknown key")
+	}
+	if _, err := decryptRelease(nil, data); err != ErrNoEncryptor {
+		t.Errorf("Expected %v, got %v", ErrNoEncryptor, err)
+	}
+
+	// unencrypted records are still readable
+	data, err = encryptRelease(nil, rel)
+	if err != nil {
+		t.Fatalf("Failed to encode release: %s", err)
+	}
+	if got, err = decryptRelease(enc, data); err != nil {
+		t.Fatalf("Failed to decode unencrypted release: %s", err)
+	}
+	if !reflect.DeepEqual(rel, got) {
+		t.Errorf("Expected {%v}, got {%v}", rel, got)
+	}
+}
+
+func TestNewKeyFileEncryptorInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"no keys":      "keys: []\n",
+		"no id":        "keys:\n- secret: MDEyMzQ1Njc4OWFiY2RlZg==\n",
+		"duplicate id": "keys:\n- id: a\n  secret: MDEyMzQ1Njc4OWFiY2RlZg==\n- id: a\n  secret: MDEyMzQ1Njc4OWFiY2RlZg==\n",
+		"short key":    "keys:\n- id: a\n  secret: MDEyMw==\n",
+		"unknown key":  "keys:\n- id: a\n  secret: MDEyMzQ1Njc4OWFiY2RlZg==\n  extra: true\n",
+	} {
+		path := filepath.Join(t.TempDir(), "keys.yaml")
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewKeyFileEncryptor(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSecretEncrypted(t *testing.T) {
+	vers := 1
+	name := "smug-pigeon"
+	namespace := "default"
+	key := testKey(name, vers)
+	rel := releaseStub(name, vers, namespace, rspb.StatusDeployed)
+
+	secrets := newTestFixtureSecrets(t)
+	secrets.Encryptor = newTestKeyFileEncryptor(t, testKeyFile)
+	if err := secrets.Create(key, rel); err != nil {
+		t.Fatalf("Failed to create release with key %q: %s", key, err)
+	}
+
+	obj := secrets.impl.(*MockSecretsInterface).objects[key]
+	if data := string(obj.Data["release"]); !strings.HasPrefix(data, encryptedPrefix) {
+		t.Errorf("Expected the release to be stored encrypted, got %q", data)
+	}
+
+	got, err := secrets.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to get release: %s", err)
+	}
+	if !reflect.DeepEqual(rel, got) {
+		t.Errorf("Expected {%v}, got {%v}", rel, got)
+	}
+
+	secrets.Encryptor = nil
+	if _, err := secrets.Get(key); err == nil {
+		t.Error("Expected an error reading an encrypted release without an encryptor")
+	}
+}
+
+// TestExecEncryptorPlugin is not a test, but a KMS plugin run by
+// TestExecEncryptor. It "encrypts" data keys by reversing them, and logs every
+// run to the file named by HELM_TEST_KMS_PLUGIN_LOG.
+func TestExecEncryptorPlugin(t *testing.T) {
+	log := os.Getenv("HELM_TEST_KMS_PLUGIN_LOG")
+	if log == "" {
+		return
+	}
+	var req execRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		os.Exit(1)
+	}
+	f, err := os.OpenFile(log, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		os.Exit(1)
+	}
+	f.WriteString(req.Operation + "\n")
+	f.Close()
+
+	res := execResponse{KeyID: "kms-1", Data: make([]byte, len(req.Data))}
+	for i, b := range req.Data {
+		res.Data[len(req.Data)-1-i] = b
+	}
+	json.NewEncoder(os.Stdout).Encode(res)
+	os.Exit(0)
+}
+
+func TestExecEncryptor(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "plugin.log")
+	os.Setenv("HELM_TEST_KMS_PLUGIN_LOG", log)
+	defer os.Unsetenv("HELM_TEST_KMS_PLUGIN_LOG")
+
+	newEncryptor := func() *ExecEncryptor {
+		return NewExecEncryptor(os.Args[0], "-test.run=TestExecEncryptorPlugin")
+	}
+	runs := func() []string {
+		b, err := ioutil.ReadFile(log)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		return strings.Fields(string(b))
+	}
+
+	rel := releaseStub("smug-pigeon", 1, "default", rspb.StatusDeployed)
+	enc := newEncryptor()
+	data, err := encryptRelease(enc, rel)
+	if err != nil {
+		t.Fatalf("Failed to encrypt release: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := decryptRelease(enc, data)
+		if err != nil {
+			t.Fatalf("Failed to decrypt release: %s", err)
+		}
+		if !reflect.DeepEqual(rel, got) {
+			t.Errorf("Expected {%v}, got {%v}", rel, got)
+		}
+	}
+	if want := []string{"encrypt"}; !reflect.DeepEqual(runs(), want) {
+		t.Errorf("Expected the plugin runs %v, got %v", want, runs())
+	}
+
+	// Another encryptor decrypts each data key once.
+	enc = newEncryptor()
+	for i := 0; i < 2; i++ {
+		if _, err := decryptRelease(enc, data); err != nil {
+			t.Fatalf("Failed to decrypt release: %s", err)
+		}
+	}
+	if want := []string{"encrypt", "decrypt"}; !reflect.DeepEqual(runs(), want) {
+		t.Errorf("Expected the plugin runs %v, got %v", want, runs())
+	}
+}
@@ -20,11 +20,16 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/chart"
 	rspb "helm.sh/helm/v3/pkg/release"
 )
 
 var _ Driver = (*Memory)(nil)
+var _ ChartStore = (*Memory)(nil)
 
 const (
 	// MemoryDriverName is the string name of this driver.
@@ -36,17 +41,31 @@ const (
 // A map of release names to list of release records
 type memReleases map[string]records
 
+// A map of digests to charts stored separately from releases
+type memCharts map[string]memChart
+
+type memChart struct {
+	chart    *chart.Chart
+	storedAt time.Time
+}
+
 // Memory is the in-memory storage driver implementation.
 type Memory struct {
 	sync.RWMutex
 	namespace string
 	// A map of namespaces to releases
 	cache map[string]memReleases
+	// A map of namespaces to charts stored separately from releases
+	charts map[string]memCharts
 }
 
 // NewMemory initializes a new memory driver.
 func NewMemory() *Memory {
-	return &Memory{cache: map[string]memReleases{}, namespace: "default"}
+	return &Memory{
+		cache:     map[string]memReleases{},
+		charts:    map[string]memCharts{},
+		namespace: "default",
+	}
 }
 
 // SetNamespace sets a specific namespace in which releases will be accessed.
@@ -73,7 +92,7 @@ func (mem *Memory) Get(key string) (*rspb.Release, error) {
 		}
 		if recs, ok := mem.cache[mem.namespace][name]; ok {
 			if r := recs.Get(key); r != nil {
-				return r.rls, nil
+				return mem.withChart(r.rls)
 			}
 		}
 		return nil, ErrReleaseNotFound
@@ -87,6 +106,7 @@ func (mem *Memory) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error
 	defer unlock(mem.rlock())
 
 	var ls []*rspb.Release
+	var err error
 	for namespace := range mem.cache {
 		if mem.namespace != "" {
 			// Should only list releases of this namespace
@@ -95,10 +115,17 @@ func (mem *Memory) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error
 		for _, recs := range mem.cache[namespace] {
 			recs.Iter(func(_ int, rec *record) bool {
 				if filter(rec.rls) {
-					ls = append(ls, rec.rls)
+					var rls *rspb.Release
+					if rls, err = mem.withChart(rec.rls); err != nil {
+						return false
+					}
+					ls = append(ls, rls)
 				}
 				return true
 			})
+			if err != nil {
+				return nil, err
+			}
 		}
 		if mem.namespace != "" {
 			// Should only list releases of this namespace
@@ -118,6 +145,7 @@ func (mem *Memory) Query(keyvals map[string]string) ([]*rspb.Release, error) {
 	lbs.fromMap(keyvals)
 
 	var ls []*rspb.Release
+	var err error
 	for namespace := range mem.cache {
 		if mem.namespace != "" {
 			// Should only query releases of this namespace
@@ -131,10 +159,17 @@ func (mem *Memory) Query(keyvals map[string]string) ([]*rspb.Release, error) {
 					return false
 				}
 				if rec.lbs.match(lbs) {
-					ls = append(ls, rec.rls)
+					var rls *rspb.Release
+					if rls, err = mem.withChart(rec.rls); err != nil {
+						return false
+					}
+					ls = append(ls, rls)
 				}
 				return true
 			})
+			if err != nil {
+				return nil, err
+			}
 		}
 		if mem.namespace != "" {
 			// Should only query releases of this namespace
@@ -215,6 +250,10 @@ func (mem *Memory) Delete(key string) (*rspb.Release, error) {
 			if r := recs.Remove(key); r != nil {
 				// recs.Remove changes the slice reference, so we have to re-assign it.
 				mem.cache[mem.namespace][name] = recs
+				// the release is deleted even if its chart is missing
+				if rls, err := mem.withChart(r.rls); err == nil {
+					return rls, nil
+				}
 				return r.rls, nil
 			}
 		}
@@ -222,6 +261,103 @@ func (mem *Memory) Delete(key string) (*rspb.Release, error) {
 	return nil, ErrReleaseNotFound
 }
 
+// withChart returns the release with its chart set if the release is stored
+// without its chart. The chart is looked up in the namespace of the release.
+func (mem *Memory) withChart(rls *rspb.Release) (*rspb.Release, error) {
+	if rls.Chart != nil || rls.ChartDigest == "" {
+		return rls, nil
+	}
+	namespace := rls.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	c, ok := mem.charts[namespace][rls.ChartDigest]
+	if !ok {
+		return nil, errors.Wrapf(ErrChartNotFound, "failed to get chart %s of release %q", rls.ChartDigest, rls.Name)
+	}
+	r := *rls
+	r.Chart = c.chart
+	return &r, nil
+}
+
+// chartNamespace returns the namespace charts are stored in. Like the
+// releases written, charts written without a namespace set are stored in
+// the default namespace.
+func (mem *Memory) chartNamespace() string {
+	if mem.namespace == "" {
+		return defaultNamespace
+	}
+	return mem.namespace
+}
+
+// CreateChart stores a chart under the given digest in the namespace of the
+// driver, or refreshes the time it was stored at if it is already stored.
+func (mem *Memory) CreateChart(digest string, ch *chart.Chart) error {
+	defer unlock(mem.wlock())
+
+	namespace := mem.chartNamespace()
+	if _, ok := mem.charts[namespace]; !ok {
+		mem.charts[namespace] = memCharts{}
+	}
+	c, ok := mem.charts[namespace][digest]
+	if !ok {
+		c.chart = ch
+	}
+	c.storedAt = time.Now()
+	mem.charts[namespace][digest] = c
+	return nil
+}
+
+// GetChart returns the chart stored under the given digest or returns
+// ErrChartNotFound.
+func (mem *Memory) GetChart(digest string) (*chart.Chart, error) {
+	defer unlock(mem.rlock())
+
+	if c, ok := mem.charts[mem.chartNamespace()][digest]; ok {
+		return c.chart, nil
+	}
+	return nil, ErrChartNotFound
+}
+
+// UpdateChart replaces the chart stored under the given digest or returns
+// ErrChartNotFound.
+func (mem *Memory) UpdateChart(digest string, ch *chart.Chart) error {
+	defer unlock(mem.wlock())
+
+	namespace := mem.chartNamespace()
+	c, ok := mem.charts[namespace][digest]
+	if !ok {
+		return ErrChartNotFound
+	}
+	c.chart = ch
+	mem.charts[namespace][digest] = c
+	return nil
+}
+
+// DeleteChart deletes the chart stored under the given digest or returns
+// ErrChartNotFound.
+func (mem *Memory) DeleteChart(digest string) error {
+	defer unlock(mem.wlock())
+
+	namespace := mem.chartNamespace()
+	if _, ok := mem.charts[namespace][digest]; !ok {
+		return ErrChartNotFound
+	}
+	delete(mem.charts[namespace], digest)
+	return nil
+}
+
+// ListCharts returns the charts stored in the namespace of the driver.
+func (mem *Memory) ListCharts() ([]StoredChart, error) {
+	defer unlock(mem.rlock())
+
+	var charts []StoredChart
+	for digest, c := range mem.charts[mem.chartNamespace()] {
+		charts = append(charts, StoredChart{Digest: digest, StoredAt: c.storedAt})
+	}
+	return charts, nil
+}
+
 // wlock locks mem for writing
 func (mem *Memory) wlock() func() {
 	mem.Lock()
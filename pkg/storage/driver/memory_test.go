@@ -21,6 +21,9 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/chart"
 	rspb "helm.sh/helm/v3/pkg/release"
 )
 
@@ -287,3 +290,51 @@ func TestMemoryDelete(t *testing.T) {
 	}
 
 }
+
+func TestMemoryCharts(t *testing.T) {
+	ch := &chart.Chart{Metadata: &chart.Metadata{Name: "a", Version: "1.0.0"}}
+	digest, err := ChartDigest(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mem := NewMemory()
+	mem.SetNamespace("one")
+	if err := mem.CreateChart(digest, ch); err != nil {
+		t.Fatalf("failed to create chart: %s", err)
+	}
+	rls := releaseStub("rls-a", 1, "one", rspb.StatusDeployed)
+	rls.ChartDigest = digest
+	if err := mem.Create(testKey(rls.Name, rls.Version), rls); err != nil {
+		t.Fatalf("failed to create release: %s", err)
+	}
+	got, err := mem.Get(testKey(rls.Name, rls.Version))
+	if err != nil {
+		t.Fatalf("failed to get release: %s", err)
+	}
+	if !reflect.DeepEqual(ch, got.Chart) {
+		t.Errorf("expected chart %v, got %v", ch, got.Chart)
+	}
+
+	// charts are stored per namespace
+	mem.SetNamespace("two")
+	if _, err := mem.GetChart(digest); err != ErrChartNotFound {
+		t.Errorf("expected %v in another namespace, got %v", ErrChartNotFound, err)
+	}
+	other := releaseStub("rls-b", 1, "two", rspb.StatusDeployed)
+	other.ChartDigest = digest
+	if err := mem.Create(testKey(other.Name, other.Version), other); err != nil {
+		t.Fatalf("failed to create release: %s", err)
+	}
+
+	// a release whose chart is missing cannot be read
+	if _, err := mem.Get(testKey(other.Name, other.Version)); !errors.Is(err, ErrChartNotFound) {
+		t.Errorf("expected %v, got %v", ErrChartNotFound, err)
+	}
+	if _, err := mem.List(func(*rspb.Release) bool { return true }); !errors.Is(err, ErrChartNotFound) {
+		t.Errorf("expected %v, got %v", ErrChartNotFound, err)
+	}
+	if _, err := mem.Query(map[string]string{"name": other.Name}); !errors.Is(err, ErrChartNotFound) {
+		t.Errorf("expected %v, got %v", ErrChartNotFound, err)
+	}
+}
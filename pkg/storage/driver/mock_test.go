@@ -250,6 +250,11 @@ func (mock *MockSecretsInterface) Delete(_ context.Context, name string, _ metav
 
 // newTestFixtureSQL mocks the SQL database (for testing purposes)
 func newTestFixtureSQL(t *testing.T, releases ...*rspb.Release) (*SQL, sqlmock.Sqlmock) {
+	return newTestFixtureSQLDialect(t, sqlDialects[postgreSQLDialect])
+}
+
+// newTestFixtureSQLDialect mocks the SQL database of the given dialect
+func newTestFixtureSQLDialect(t *testing.T, dialect *sqlDialect) (*SQL, sqlmock.Sqlmock) {
 	sqlDB, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("error when opening stub database connection: %v", err)
@@ -258,8 +263,9 @@ func newTestFixtureSQL(t *testing.T, releases ...*rspb.Release) (*SQL, sqlmock.S
 	sqlxDB := sqlx.NewDb(sqlDB, "sqlmock")
 	return &SQL{
 		db:               sqlxDB,
+		dialect:          dialect,
 		Log:              func(a string, b ...interface{}) {},
 		namespace:        "default",
-		statementBuilder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		statementBuilder: sq.StatementBuilder.PlaceholderFormat(dialect.placeholder),
 	}, mock
 }
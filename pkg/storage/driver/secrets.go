@@ -18,6 +18,7 @@ package driver // import "helm.sh/helm/v3/pkg/storage/driver"
 
 import (
 	"context"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -30,10 +31,12 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
+	"helm.sh/helm/v3/pkg/chart"
 	rspb "helm.sh/helm/v3/pkg/release"
 )
 
 var _ Driver = (*Secrets)(nil)
+var _ ChartStore = (*Secrets)(nil)
 
 // SecretsDriverName is the string name of the driver.
 const SecretsDriverName = "Secret"
@@ -43,6 +46,15 @@ const SecretsDriverName = "Secret"
 type Secrets struct {
 	impl corev1.SecretInterface
 	Log  func(string, ...interface{})
+
+	// ChunkSize is the maximum size of the encoded release stored in a
+	// single Secret. Larger releases are split across several Secrets.
+	// Values of 0 or less use DefaultChunkSize.
+	ChunkSize int
+
+	// Encryptor encrypts the releases and charts stored in Secrets, if set.
+	// Unencrypted records stay readable.
+	Encryptor Encryptor
 }
 
 // NewSecrets initializes a new Secrets wrapping an implementation of
@@ -71,8 +83,11 @@ func (secrets *Secrets) Get(key string) (*rspb.Release, error) {
 		return nil, errors.Wrapf(err, "get: failed to get %q", key)
 	}
 	// found the secret, decode the base64 data string
-	r, err := decodeRelease(string(obj.Data["release"]))
-	return r, errors.Wrapf(err, "get: failed to decode data %q", key)
+	r, err := secrets.decodeSecret(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get: failed to decode data %q", key)
+	}
+	return r, errors.Wrap(resolveChart(secrets, r), "get")
 }
 
 // List fetches all releases and returns the list releases such
@@ -91,8 +106,9 @@ func (secrets *Secrets) List(filter func(*rspb.Release) bool) ([]*rspb.Release,
 
 	// iterate over the secrets object list
 	// and decode each release
-	for _, item := range list.Items {
-		rls, err := decodeRelease(string(item.Data["release"]))
+	for i := range list.Items {
+		item := &list.Items[i]
+		rls, err := secrets.decodeSecret(item)
 		if err != nil {
 			secrets.Log("list: failed to decode release: %v: %s", item, err)
 			continue
@@ -101,6 +117,10 @@ func (secrets *Secrets) List(filter func(*rspb.Release) bool) ([]*rspb.Release,
 		rls.Labels = item.ObjectMeta.Labels
 
 		if filter(rls) {
+			if err := resolveChart(secrets, rls); err != nil {
+				secrets.Log("list: %s", err)
+				continue
+			}
 			results = append(results, rls)
 		}
 	}
@@ -130,12 +150,20 @@ func (secrets *Secrets) Query(labels map[string]string) ([]*rspb.Release, error)
 	}
 
 	var results []*rspb.Release
-	for _, item := range list.Items {
-		rls, err := decodeRelease(string(item.Data["release"]))
+	for i := range list.Items {
+		item := &list.Items[i]
+		if _, ok := item.Labels[chunkLabel]; ok {
+			continue
+		}
+		rls, err := secrets.decodeSecret(item)
 		if err != nil {
 			secrets.Log("query: failed to decode release: %s", err)
 			continue
 		}
+		if err := resolveChart(secrets, rls); err != nil {
+			secrets.Log("query: %s", err)
+			continue
+		}
 		results = append(results, rls)
 	}
 	return results, nil
@@ -143,6 +171,8 @@ func (secrets *Secrets) Query(labels map[string]string) ([]*rspb.Release, error)
 
 // Create creates a new Secret holding the release. If the
 // Secret already exists, ErrReleaseExists is returned.
+//
+// Releases larger than the chunk size are split across several Secrets.
 func (secrets *Secrets) Create(key string, rls *rspb.Release) error {
 	// set labels for secrets object meta data
 	var lbs labels
@@ -150,57 +180,288 @@ func (secrets *Secrets) Create(key string, rls *rspb.Release) error {
 	lbs.init()
 	lbs.set("createdAt", strconv.Itoa(int(time.Now().Unix())))
 
-	// create a new secret to hold the release
-	obj, err := newSecretsObject(key, rls, lbs)
+	// create new secrets to hold the release
+	objs, err := newSecretsObjects(key, rls, lbs, 0, secrets.ChunkSize, secrets.Encryptor)
 	if err != nil {
 		return errors.Wrapf(err, "create: failed to encode release %q", rls.Name)
 	}
 	// push the secret object out into the kubiverse
-	if _, err := secrets.impl.Create(context.Background(), obj, metav1.CreateOptions{}); err != nil {
+	if _, err := secrets.impl.Create(context.Background(), objs[0], metav1.CreateOptions{}); err != nil {
 		if apierrors.IsAlreadyExists(err) {
 			return ErrReleaseExists
 		}
 
 		return errors.Wrap(err, "create: failed to create")
 	}
+	for _, obj := range objs[1:] {
+		if err := secrets.putChunk(obj); err != nil {
+			secrets.deleteChunks(key, 0, 0, len(objs))
+			return errors.Wrap(err, "create: failed to create chunk")
+		}
+	}
 	return nil
 }
 
-// Update updates the Secret holding the release. If not found
-// the Secret is created to hold the release.
+// Update updates the Secret holding the release.
+//
+// Releases larger than the chunk size are split across several Secrets. The
+// chunks are written under a new generation before the first Secret is
+// switched over to them, and the chunks of the previous generation are only
+// deleted afterwards, so a failed update leaves the stored release intact.
 func (secrets *Secrets) Update(key string, rls *rspb.Release) error {
+	prev, err := secrets.impl.Get(context.Background(), key, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "update: failed to get")
+	}
+	prevChunks, _ := chunkCount(prev.Labels)
+	prevGeneration, _ := chunkGeneration(prev.Labels)
+	generation := prevGeneration + 1
+
 	// set labels for secrets object meta data
 	var lbs labels
 
 	lbs.init()
 	lbs.set("modifiedAt", strconv.Itoa(int(time.Now().Unix())))
 
-	// create a new secret object to hold the release
-	obj, err := newSecretsObject(key, rls, lbs)
+	// create new secret objects to hold the release
+	objs, err := newSecretsObjects(key, rls, lbs, generation, secrets.ChunkSize, secrets.Encryptor)
 	if err != nil {
 		return errors.Wrapf(err, "update: failed to encode release %q", rls.Name)
 	}
+	// fail rather than reference chunks another update is replacing
+	objs[0].ResourceVersion = prev.ResourceVersion
+
+	// write the chunks before the first object, which references them
+	for _, obj := range objs[1:] {
+		if err := secrets.putChunk(obj); err != nil {
+			secrets.deleteChunks(key, generation, 1, len(objs))
+			return errors.Wrap(err, "update: failed to create chunk")
+		}
+	}
 	// push the secret object out into the kubiverse
-	_, err = secrets.impl.Update(context.Background(), obj, metav1.UpdateOptions{})
-	return errors.Wrap(err, "update: failed to update")
+	if _, err = secrets.impl.Update(context.Background(), objs[0], metav1.UpdateOptions{}); err != nil {
+		secrets.deleteChunks(key, generation, 1, len(objs))
+		return errors.Wrap(err, "update: failed to update")
+	}
+	secrets.deleteChunks(key, prevGeneration, 1, prevChunks)
+	return nil
 }
 
 // Delete deletes the Secret holding the release named by key.
 func (secrets *Secrets) Delete(key string) (rls *rspb.Release, err error) {
 	// fetch the release to check existence
-	if rls, err = secrets.Get(key); err != nil {
-		return nil, err
+	obj, err := secrets.impl.Get(context.Background(), key, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrReleaseNotFound
+		}
+		return nil, errors.Wrapf(err, "delete: failed to get %q", key)
+	}
+	if rls, err = secrets.decodeSecret(obj); err != nil {
+		return nil, errors.Wrapf(err, "delete: failed to decode data %q", key)
+	}
+	if err := resolveChart(secrets, rls); err != nil {
+		secrets.Log("delete: %s", err)
 	}
 	// delete the release
-	err = secrets.impl.Delete(context.Background(), key, metav1.DeleteOptions{})
-	return rls, err
+	if err = secrets.impl.Delete(context.Background(), key, metav1.DeleteOptions{}); err != nil {
+		return rls, err
+	}
+	n, _ := chunkCount(obj.Labels)
+	generation, _ := chunkGeneration(obj.Labels)
+	secrets.deleteChunks(key, generation, 1, n)
+	return rls, nil
+}
+
+// decodeSecret decodes the release stored in the given Secret, joining the
+// chunks stored in further Secrets if the release was split. The chart of a
+// release stored without its chart is not loaded.
+func (secrets *Secrets) decodeSecret(obj *v1.Secret) (*rspb.Release, error) {
+	data, err := secrets.joinChunks(obj, "release")
+	if err != nil {
+		return nil, err
+	}
+	return decryptRelease(secrets.Encryptor, data)
+}
+
+// joinChunks returns the data entry of the given Secret, joined with the
+// chunks stored in further Secrets if the data was split.
+func (secrets *Secrets) joinChunks(obj *v1.Secret, entry string) (string, error) {
+	n, err := chunkCount(obj.Labels)
+	if err != nil {
+		return "", err
+	}
+	generation, err := chunkGeneration(obj.Labels)
+	if err != nil {
+		return "", err
+	}
+
+	var data strings.Builder
+	data.Write(obj.Data[entry])
+	for i := 1; i < n; i++ {
+		chunk, err := secrets.impl.Get(context.Background(), chunkKey(obj.Name, generation, i), metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get chunk %d of %d", i+1, n)
+		}
+		data.Write(chunk.Data[entry])
+	}
+	return data.String(), nil
+}
+
+// CreateChart stores a chart in Secrets named by its digest.
+func (secrets *Secrets) CreateChart(digest string, ch *chart.Chart) error {
+	key := chartKey(digest)
+	if obj, err := secrets.impl.Get(context.Background(), key, metav1.GetOptions{}); err == nil {
+		// refresh the time the chart was stored at, which protects it from
+		// being pruned before the release referencing it is stored
+		obj.Labels[storedAtLabel] = strconv.FormatInt(time.Now().Unix(), 10)
+		_, err = secrets.impl.Update(context.Background(), obj, metav1.UpdateOptions{})
+		return errors.Wrapf(err, "create chart: failed to update %q", key)
+	} else if !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "create chart: failed to get %q", key)
+	}
+
+	objs, err := newChartSecrets(key, ch, 0, secrets.ChunkSize, secrets.Encryptor)
+	if err != nil {
+		return errors.Wrapf(err, "create chart: failed to encode chart %s", digest)
+	}
+	// write the chunks before the first object, which references them
+	for _, obj := range objs[1:] {
+		if err := secrets.putChunk(obj); err != nil {
+			return errors.Wrap(err, "create chart: failed to create chunk")
+		}
+	}
+	_, err = secrets.impl.Create(context.Background(), objs[0], metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "create chart: failed to create")
+	}
+	return nil
+}
+
+// GetChart fetches the chart stored under the given digest.
+func (secrets *Secrets) GetChart(digest string) (*chart.Chart, error) {
+	key := chartKey(digest)
+	obj, err := secrets.impl.Get(context.Background(), key, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrChartNotFound
+		}
+		return nil, errors.Wrapf(err, "get chart: failed to get %q", key)
+	}
+	data, err := secrets.joinChunks(obj, "chart")
+	if err != nil {
+		return nil, errors.Wrapf(err, "get chart: failed to get data %q", key)
+	}
+	ch, err := decryptChart(secrets.Encryptor, data)
+	return ch, errors.Wrapf(err, "get chart: failed to decode data %q", key)
+}
+
+// UpdateChart rewrites the chart stored under the given digest. Like the
+// chunks of releases, the chunks of the chart are written under a new
+// generation before the first Secret is switched over to them.
+func (secrets *Secrets) UpdateChart(digest string, ch *chart.Chart) error {
+	key := chartKey(digest)
+	prev, err := secrets.impl.Get(context.Background(), key, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return ErrChartNotFound
+		}
+		return errors.Wrapf(err, "update chart: failed to get %q", key)
+	}
+	prevChunks, _ := chunkCount(prev.Labels)
+	prevGeneration, _ := chunkGeneration(prev.Labels)
+	generation := prevGeneration + 1
+
+	objs, err := newChartSecrets(key, ch, generation, secrets.ChunkSize, secrets.Encryptor)
+	if err != nil {
+		return errors.Wrapf(err, "update chart: failed to encode chart %s", digest)
+	}
+	objs[0].ResourceVersion = prev.ResourceVersion
+
+	// write the chunks before the first object, which references them
+	for _, obj := range objs[1:] {
+		if err := secrets.putChunk(obj); err != nil {
+			secrets.deleteChunks(key, generation, 1, len(objs))
+			return errors.Wrap(err, "update chart: failed to create chunk")
+		}
+	}
+	if _, err := secrets.impl.Update(context.Background(), objs[0], metav1.UpdateOptions{}); err != nil {
+		secrets.deleteChunks(key, generation, 1, len(objs))
+		return errors.Wrap(err, "update chart: failed to update")
+	}
+	secrets.deleteChunks(key, prevGeneration, 1, prevChunks)
+	return nil
+}
+
+// DeleteChart deletes the Secrets holding the chart stored under the given
+// digest.
+func (secrets *Secrets) DeleteChart(digest string) error {
+	key := chartKey(digest)
+	obj, err := secrets.impl.Get(context.Background(), key, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return ErrChartNotFound
+		}
+		return errors.Wrapf(err, "delete chart: failed to get %q", key)
+	}
+	if err := secrets.impl.Delete(context.Background(), key, metav1.DeleteOptions{}); err != nil {
+		return errors.Wrapf(err, "delete chart: failed to delete %q", key)
+	}
+	n, _ := chunkCount(obj.Labels)
+	generation, _ := chunkGeneration(obj.Labels)
+	secrets.deleteChunks(key, generation, 1, n)
+	return nil
 }
 
-// newSecretsObject constructs a kubernetes Secret object
+// ListCharts returns all charts stored in Secrets.
+func (secrets *Secrets) ListCharts() ([]StoredChart, error) {
+	lsel := kblabels.Set{"owner": chartOwner}.AsSelector()
+	opts := metav1.ListOptions{LabelSelector: lsel.String()}
+
+	list, err := secrets.impl.List(context.Background(), opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "list charts: failed to list")
+	}
+	var charts []StoredChart
+	for _, item := range list.Items {
+		charts = append(charts, StoredChart{
+			Digest:   chartDigestFromKey(item.Name),
+			StoredAt: chartStoredAt(item.Labels, item.CreationTimestamp.Time),
+		})
+	}
+	return charts, nil
+}
+
+// putChunk creates or updates a Secret holding a chunk of a release.
+func (secrets *Secrets) putChunk(obj *v1.Secret) error {
+	_, err := secrets.impl.Create(context.Background(), obj, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = secrets.impl.Update(context.Background(), obj, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// deleteChunks deletes the Secrets holding the chunks from index start up to
+// end of the release named by key. Chunks that do not exist are ignored.
+func (secrets *Secrets) deleteChunks(key string, generation, start, end int) {
+	for i := start; i < end; i++ {
+		err := secrets.impl.Delete(context.Background(), chunkKey(key, generation, i), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			secrets.Log("failed to delete chunk %d of %q: %s", i+1, key, err)
+		}
+	}
+}
+
+// newSecretsObjects constructs the kubernetes Secret objects
 // to store a release. Each secret data entry is the base64
-// encoded gzipped string of a release.
+// encoded gzipped string of a release, encrypted if enc is set.
+// If the encoded release is larger than chunkSize, it is split
+// across several secrets.
+// The first secret is named by key, the remaining ones are named
+// by key with the suffix ".c<index>", or ".g<generation>.c<index>"
+// if generation is not 0.
 //
-// The following labels are used within each secret:
+// The following labels are used within the first secret:
 //
 //    "modifiedAt"    - timestamp indicating when this secret was last modified. (set in Update)
 //    "createdAt"     - timestamp indicating when this secret was created. (set in Create)
@@ -208,26 +469,99 @@ func (secrets *Secrets) Delete(key string) (rls *rspb.Release, err error) {
 //    "status"         - status of the release (see pkg/release/status.go for variants)
 //    "owner"          - owner of the secret, currently "helm".
 //    "name"           - name of the release.
+//    "chunks"         - number of secrets the release is split across, if more than one.
+//    "generation"     - generation of the remaining secrets, if not 0.
 //
-func newSecretsObject(key string, rls *rspb.Release, lbs labels) (*v1.Secret, error) {
-	const owner = "helm"
-
-	// encode the release
-	s, err := encodeRelease(rls)
+// The remaining secrets are labelled with "name" and "version" of the
+// release, "owner" set to "helm-chunk" and "chunk" set to their index.
+func newSecretsObjects(key string, rls *rspb.Release, lbs labels, generation, chunkSize int, enc Encryptor) ([]*v1.Secret, error) {
+	// encode and encrypt the release
+	s, err := encryptRelease(enc, rls)
 	if err != nil {
 		return nil, err
 	}
+	chunks := splitChunks(s, chunkSize)
 
 	if lbs == nil {
 		lbs.init()
 	}
+	if len(chunks) > 1 {
+		lbs.set(chunksLabel, strconv.Itoa(len(chunks)))
+		if generation > 0 {
+			lbs.set(generationLabel, strconv.Itoa(generation))
+		}
+	}
 
-	// apply labels
-	lbs.set("name", rls.Name)
-	lbs.set("owner", owner)
 	lbs.set("status", rls.Info.Status.String())
-	lbs.set("version", strconv.Itoa(rls.Version))
+	objs := []*v1.Secret{newSecret(key, chunks[0], releaseLabels(rls, lbs, "helm"))}
+	for i, chunk := range chunks[1:] {
+		var chunkLbs labels
+		chunkLbs.init()
+		chunkLbs.set(chunkLabel, strconv.Itoa(i+1))
+		objs = append(objs, newSecret(chunkKey(key, generation, i+1), chunk, releaseLabels(rls, chunkLbs, chunkOwner)))
+	}
+	return objs, nil
+}
 
+// newSecretsObject constructs a single kubernetes Secret object
+// to store a release, regardless of the size of the release.
+// See newSecretsObjects for the labels used.
+func newSecretsObject(key string, rls *rspb.Release, lbs labels) (*v1.Secret, error) {
+	objs, err := newSecretsObjects(key, rls, lbs, 0, math.MaxInt32, nil)
+	if err != nil {
+		return nil, err
+	}
+	return objs[0], nil
+}
+
+// newChartSecrets constructs the kubernetes Secret objects to store
+// a chart separately from releases. The secret data entry is the
+// base64 encoded gzipped string of the chart, split across several
+// secrets like releases if it is larger than chunkSize.
+//
+// The first secret is labelled with "owner" set to "helm-chart",
+// "storedAt" set to the current time, "chunks" if the chart is split
+// and "generation" if the chunks are of a generation other than 0. The remaining secrets are labelled
+// with "owner" set to "helm-chunk" and "chunk" set to their index.
+func newChartSecrets(key string, ch *chart.Chart, generation, chunkSize int, enc Encryptor) ([]*v1.Secret, error) {
+	s, err := encryptChart(enc, ch)
+	if err != nil {
+		return nil, err
+	}
+	chunks := splitChunks(s, chunkSize)
+
+	var objs []*v1.Secret
+	for i, chunk := range chunks {
+		var lbs labels
+		lbs.init()
+		if i == 0 {
+			lbs.set("owner", chartOwner)
+			lbs.set(storedAtLabel, strconv.FormatInt(time.Now().Unix(), 10))
+			if len(chunks) > 1 {
+				lbs.set(chunksLabel, strconv.Itoa(len(chunks)))
+				if generation > 0 {
+					lbs.set(generationLabel, strconv.Itoa(generation))
+				}
+			}
+		} else {
+			lbs.set("owner", chunkOwner)
+			lbs.set(chunkLabel, strconv.Itoa(i))
+		}
+		objs = append(objs, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   chunkKey(key, generation, i),
+				Labels: lbs.toMap(),
+			},
+			Type: "helm.sh/chart.v1",
+			Data: map[string][]byte{"chart": []byte(chunk)},
+		})
+	}
+	return objs, nil
+}
+
+// newSecret constructs a kubernetes Secret object holding
+// the data of a release.
+func newSecret(name, data string, lbs labels) *v1.Secret {
 	// create and return secret object.
 	// Helm 3 introduced setting the 'Type' field
 	// in the Kubernetes storage object.
@@ -241,10 +575,10 @@ func newSecretsObject(key string, rls *rspb.Release, lbs labels) (*v1.Secret, er
 	// and should only happen between major versions.
 	return &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   key,
+			Name:   name,
 			Labels: lbs.toMap(),
 		},
 		Type: "helm.sh/release.v1",
-		Data: map[string][]byte{"release": []byte(s)},
-	}, nil
+		Data: map[string][]byte{"release": []byte(data)},
+	}
 }
@@ -14,13 +14,18 @@ limitations under the License.
 package driver
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"reflect"
+	"strconv"
 	"testing"
 
+	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"helm.sh/helm/v3/pkg/chart"
 	rspb "helm.sh/helm/v3/pkg/release"
 )
 
@@ -239,3 +244,271 @@ func TestSecretDelete(t *testing.T) {
 		t.Errorf("Expected {%v}, got {%v}", ErrReleaseNotFound, err)
 	}
 }
+
+func TestSecretChunked(t *testing.T) {
+	vers := 1
+	name := "smug-pigeon"
+	namespace := "default"
+	key := testKey(name, vers)
+	rel := releaseStub(name, vers, namespace, rspb.StatusPendingInstall)
+
+	secrets := newTestFixtureSecrets(t)
+	secrets.ChunkSize = 16
+	objects := secrets.impl.(*MockSecretsInterface).objects
+
+	if err := secrets.Create(key, rel); err != nil {
+		t.Fatalf("Failed to create release with key %q: %s", key, err)
+	}
+	chunks := len(objects)
+	if chunks < 2 {
+		t.Fatalf("Expected release to be split across several objects, got %d", chunks)
+	}
+	if n := objects[key].Labels[chunksLabel]; n != strconv.Itoa(chunks) {
+		t.Errorf("Expected %s label %d, got %q", chunksLabel, chunks, n)
+	}
+
+	// get, list and query reassemble the release
+	got, err := secrets.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to get release: %s", err)
+	}
+	if !reflect.DeepEqual(rel, got) {
+		t.Errorf("Expected {%v}, got {%v}", rel, got)
+	}
+	list, err := secrets.List(func(*rspb.Release) bool { return true })
+	if err != nil {
+		t.Fatalf("Failed to list releases: %s", err)
+	}
+	if len(list) != 1 || !reflect.DeepEqual(rel.Info, list[0].Info) {
+		t.Errorf("Expected the chunked release to be listed once, got %v", list)
+	}
+	query, err := secrets.Query(map[string]string{"name": name})
+	if err != nil {
+		t.Fatalf("Failed to query releases: %s", err)
+	}
+	if len(query) != 1 || !reflect.DeepEqual(rel, query[0]) {
+		t.Errorf("Expected the chunked release to be queried once, got %v", query)
+	}
+
+	// updating with a larger chunk size removes the chunks no longer needed
+	rel.Info.Status = rspb.StatusDeployed
+	secrets.ChunkSize = 64
+	if err := secrets.Update(key, rel); err != nil {
+		t.Fatalf("Failed to update release: %s", err)
+	}
+	if len(objects) >= chunks {
+		t.Errorf("Expected fewer than %d objects after update, got %d", chunks, len(objects))
+	}
+	if got, err = secrets.Get(key); err != nil {
+		t.Fatalf("Failed to get release: %s", err)
+	}
+	if got.Info.Status != rspb.StatusDeployed {
+		t.Errorf("Expected status %s, got %s", rspb.StatusDeployed, got.Info.Status)
+	}
+
+	if _, err := secrets.Delete(key); err != nil {
+		t.Fatalf("Failed to delete release: %s", err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("Expected all objects to be deleted, got %d", len(objects))
+	}
+}
+
+// failingUpdateSecrets fails the updates of the Secret named fail.
+type failingUpdateSecrets struct {
+	*MockSecretsInterface
+	fail string
+}
+
+func (f *failingUpdateSecrets) Update(ctx context.Context, obj *v1.Secret, opts metav1.UpdateOptions) (*v1.Secret, error) {
+	if obj.Name == f.fail {
+		return nil, errors.New("update failed")
+	}
+	return f.MockSecretsInterface.Update(ctx, obj, opts)
+}
+
+func TestSecretChunkedUpdate(t *testing.T) {
+	vers := 1
+	name := "smug-pigeon"
+	namespace := "default"
+	key := testKey(name, vers)
+	rel := releaseStub(name, vers, namespace, rspb.StatusPendingInstall)
+
+	secrets := newTestFixtureSecrets(t)
+	secrets.ChunkSize = 16
+	mock := secrets.impl.(*MockSecretsInterface)
+	objects := mock.objects
+
+	if err := secrets.Create(key, rel); err != nil {
+		t.Fatalf("Failed to create release with key %q: %s", key, err)
+	}
+	chunks := len(objects)
+
+	// a failed update leaves the stored release and its chunks untouched
+	secrets.impl = &failingUpdateSecrets{MockSecretsInterface: mock, fail: key}
+	updated := releaseStub(name, vers, namespace, rspb.StatusDeployed)
+	updated.Info.Description = "a description long enough to need more chunks"
+	if err := secrets.Update(key, updated); err == nil {
+		t.Fatal("Expected the update to fail")
+	}
+	if len(objects) != chunks {
+		t.Errorf("Expected %d objects after the failed update, got %d", chunks, len(objects))
+	}
+	got, err := secrets.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to get release: %s", err)
+	}
+	if !reflect.DeepEqual(rel, got) {
+		t.Errorf("Expected {%v}, got {%v}", rel, got)
+	}
+
+	// a successful update switches over to the chunks of a new generation
+	secrets.impl = mock
+	if err := secrets.Update(key, updated); err != nil {
+		t.Fatalf("Failed to update release: %s", err)
+	}
+	if g := objects[key].Labels[generationLabel]; g != "1" {
+		t.Errorf("Expected %s label 1, got %q", generationLabel, g)
+	}
+	n, _ := chunkCount(objects[key].Labels)
+	for i := 1; i < n; i++ {
+		if _, ok := objects[chunkKey(key, 1, i)]; !ok {
+			t.Errorf("Expected chunk %d of generation 1 to exist", i)
+		}
+	}
+	if len(objects) != n {
+		t.Errorf("Expected the chunks of generation 0 to be deleted, got %d objects for %d chunks", len(objects), n)
+	}
+	if got, err = secrets.Get(key); err != nil {
+		t.Fatalf("Failed to get release: %s", err)
+	}
+	if !reflect.DeepEqual(updated, got) {
+		t.Errorf("Expected {%v}, got {%v}", updated, got)
+	}
+}
+
+func TestSecretSeparateChart(t *testing.T) {
+	vers := 1
+	name := "smug-pigeon"
+	namespace := "default"
+	key := testKey(name, vers)
+	rel := releaseStub(name, vers, namespace, rspb.StatusDeployed)
+	rel.Chart = &chart.Chart{
+		Metadata:  &chart.Metadata{Name: "pigeon", Version: "0.1.0"},
+		Templates: []*chart.File{{Name: "templates/cm.yaml", Data: []byte("kind: ConfigMap")}},
+	}
+
+	secrets := newTestFixtureSecrets(t)
+	secrets.ChunkSize = 64
+
+	digest, err := ChartDigest(rel.Chart)
+	if err != nil {
+		t.Fatalf("Failed to compute chart digest: %s", err)
+	}
+	if err := secrets.CreateChart(digest, rel.Chart); err != nil {
+		t.Fatalf("Failed to create chart: %s", err)
+	}
+	// creating the same chart again is not an error
+	if err := secrets.CreateChart(digest, rel.Chart); err != nil {
+		t.Fatalf("Failed to create existing chart: %s", err)
+	}
+
+	stored := *rel
+	stored.Chart = nil
+	stored.ChartDigest = digest
+	if err := secrets.Create(key, &stored); err != nil {
+		t.Fatalf("Failed to create release with key %q: %s", key, err)
+	}
+
+	got, err := secrets.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to get release: %s", err)
+	}
+	if !reflect.DeepEqual(rel.Chart, got.Chart) {
+		t.Errorf("Expected chart {%v}, got {%v}", rel.Chart, got.Chart)
+	}
+
+	charts, err := secrets.ListCharts()
+	if err != nil {
+		t.Fatalf("Failed to list charts: %s", err)
+	}
+	if len(charts) != 1 || charts[0].Digest != digest || charts[0].StoredAt.IsZero() {
+		t.Errorf("Expected chart %s with the time it was stored at, got %v", digest, charts)
+	}
+	list, err := secrets.List(func(*rspb.Release) bool { return true })
+	if err != nil || len(list) != 1 {
+		t.Fatalf("Expected the chart not to be listed as a release, got %v: %v", list, err)
+	}
+
+	if err := secrets.DeleteChart(digest); err != nil {
+		t.Fatalf("Failed to delete chart: %s", err)
+	}
+	if _, err := secrets.GetChart(digest); err != ErrChartNotFound {
+		t.Errorf("Expected %v, got %v", ErrChartNotFound, err)
+	}
+	if _, err := secrets.Delete(key); err != nil {
+		t.Fatalf("Failed to delete release: %s", err)
+	}
+	if objects := secrets.impl.(*MockSecretsInterface).objects; len(objects) != 0 {
+		t.Errorf("Expected all objects to be deleted, got %d", len(objects))
+	}
+}
+
+func TestSecretUpdateChart(t *testing.T) {
+	ch := &chart.Chart{
+		Metadata:  &chart.Metadata{Name: "pigeon", Version: "0.1.0"},
+		Templates: []*chart.File{{Name: "templates/cm.yaml", Data: []byte("kind: ConfigMap")}},
+	}
+	digest, err := ChartDigest(ch)
+	if err != nil {
+		t.Fatalf("Failed to compute chart digest: %s", err)
+	}
+	key := chartKey(digest)
+
+	secrets := newTestFixtureSecrets(t)
+	secrets.ChunkSize = 16
+	mock := secrets.impl.(*MockSecretsInterface)
+	objects := mock.objects
+
+	if err := secrets.UpdateChart(digest, ch); err != ErrChartNotFound {
+		t.Errorf("Expected %v, got %v", ErrChartNotFound, err)
+	}
+	if err := secrets.CreateChart(digest, ch); err != nil {
+		t.Fatalf("Failed to create chart: %s", err)
+	}
+	chunks := len(objects)
+
+	// a failed update leaves the chart readable
+	secrets.impl = &failingUpdateSecrets{MockSecretsInterface: mock, fail: key}
+	if err := secrets.UpdateChart(digest, ch); err == nil {
+		t.Fatal("Expected the update to fail")
+	}
+	if len(objects) != chunks {
+		t.Errorf("Expected %d objects after the failed update, got %d", chunks, len(objects))
+	}
+	got, err := secrets.GetChart(digest)
+	if err != nil {
+		t.Fatalf("Failed to get chart: %s", err)
+	}
+	if !reflect.DeepEqual(ch, got) {
+		t.Errorf("Expected {%v}, got {%v}", ch, got)
+	}
+
+	// a successful update switches over to the chunks of a new generation
+	secrets.impl = mock
+	if err := secrets.UpdateChart(digest, ch); err != nil {
+		t.Fatalf("Failed to update chart: %s", err)
+	}
+	if g := objects[key].Labels[generationLabel]; g != "1" {
+		t.Errorf("Expected %s label 1, got %q", generationLabel, g)
+	}
+	if len(objects) != chunks {
+		t.Errorf("Expected the chunks of generation 0 to be deleted, got %d objects for %d chunks", len(objects), chunks)
+	}
+	if got, err = secrets.GetChart(digest); err != nil {
+		t.Fatalf("Failed to get chart: %s", err)
+	}
+	if !reflect.DeepEqual(ch, got) {
+		t.Errorf("Expected {%v}, got {%v}", ch, got)
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package driver // import "helm.sh/helm/v3/pkg/storage/driver"
 
 import (
+	"database/sql"
 	"fmt"
 	"sort"
 	"time"
@@ -25,6 +26,7 @@ import (
 	migrate "github.com/rubenv/sql-migrate"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
 
 	// Import pq for postgres dialect
 	_ "github.com/lib/pq"
@@ -71,10 +73,15 @@ const (
 // SQL is the sql storage driver implementation.
 type SQL struct {
 	db               *sqlx.DB
+	dialect          *sqlDialect
 	namespace        string
 	statementBuilder sq.StatementBuilderType
 
 	Log func(string, ...interface{})
+
+	// Encryptor encrypts the releases stored in the database, if set.
+	// Unencrypted records stay readable.
+	Encryptor Encryptor
 }
 
 // Name returns the name of the driver.
@@ -88,62 +95,7 @@ func (s *SQL) ensureDBSetup() error {
 		Migrations: []*migrate.Migration{
 			{
 				Id: "init",
-				Up: []string{
-					fmt.Sprintf(`
-						CREATE TABLE %s (
-							%s VARCHAR(67),
-							%s VARCHAR(64) NOT NULL,
-							%s TEXT NOT NULL,
-							%s VARCHAR(64) NOT NULL,
-							%s VARCHAR(64) NOT NULL,
-							%s INTEGER NOT NULL,
-							%s TEXT NOT NULL,
-							%s TEXT NOT NULL,
-							%s INTEGER NOT NULL,
-							%s INTEGER NOT NULL DEFAULT 0,
-							PRIMARY KEY(%s, %s)
-						);
-						CREATE INDEX ON %s (%s, %s);
-						CREATE INDEX ON %s (%s);
-						CREATE INDEX ON %s (%s);
-						CREATE INDEX ON %s (%s);
-						CREATE INDEX ON %s (%s);
-						CREATE INDEX ON %s (%s);
-						
-						GRANT ALL ON %s TO PUBLIC;
-
-						ALTER TABLE %s ENABLE ROW LEVEL SECURITY;
-					`,
-						sqlReleaseTableName,
-						sqlReleaseTableKeyColumn,
-						sqlReleaseTableTypeColumn,
-						sqlReleaseTableBodyColumn,
-						sqlReleaseTableNameColumn,
-						sqlReleaseTableNamespaceColumn,
-						sqlReleaseTableVersionColumn,
-						sqlReleaseTableStatusColumn,
-						sqlReleaseTableOwnerColumn,
-						sqlReleaseTableCreatedAtColumn,
-						sqlReleaseTableModifiedAtColumn,
-						sqlReleaseTableKeyColumn,
-						sqlReleaseTableNamespaceColumn,
-						sqlReleaseTableName,
-						sqlReleaseTableKeyColumn,
-						sqlReleaseTableNamespaceColumn,
-						sqlReleaseTableName,
-						sqlReleaseTableVersionColumn,
-						sqlReleaseTableName,
-						sqlReleaseTableStatusColumn,
-						sqlReleaseTableName,
-						sqlReleaseTableOwnerColumn,
-						sqlReleaseTableName,
-						sqlReleaseTableCreatedAtColumn,
-						sqlReleaseTableName,
-						sqlReleaseTableModifiedAtColumn,
-						sqlReleaseTableName,
-						sqlReleaseTableName,
-					),
-				},
+				Up: s.dialect.createTable(),
 				Down: []string{
 					fmt.Sprintf(`
 						DROP TABLE %s;
@@ -153,7 +105,7 @@ func (s *SQL) ensureDBSetup() error {
 		},
 	}
 
-	_, err := migrate.Exec(s.db.DB, postgreSQLDialect, migrations, migrate.Up)
+	_, err := migrate.Exec(s.db.DB, s.dialect.name, migrations, migrate.Up)
 	return err
 }
 
@@ -180,17 +132,61 @@ type SQLReleaseWrapper struct {
 	ModifiedAt int    `db:"modifiedAt"`
 }
 
-// NewSQL initializes a new sql driver.
+// NewSQL initializes a new sql driver. The database is selected by the scheme
+// of the connection string: "postgres://" (the default), "sqlite://" or
+// "mysql://". The database/sql driver of SQLite or MySQL must be registered
+// by the program, as the helm command does, PostgreSQL's is registered by this
+// package. An error naming the driver is returned if it is not registered.
 func NewSQL(connectionString string, logger func(string, ...interface{}), namespace string) (*SQL, error) {
-	db, err := sqlx.Connect(postgreSQLDialect, connectionString)
+	dialect, dataSourceName, err := parseSQLConnectionString(connectionString)
 	if err != nil {
 		return nil, err
 	}
 
+	if !sqlDriverRegistered(dialect.driver) {
+		return nil, errors.Errorf("database/sql driver %q is not registered, it must be imported by the program using the SQL storage driver", dialect.driver)
+	}
+
+	db, err := sql.Open(dialect.driver, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return newSQL(sqlx.NewDb(db, dialect.name), dialect, logger, namespace)
+}
+
+// sqlDriverRegistered reports whether a database/sql driver is registered
+// under the given name.
+func sqlDriverRegistered(name string) bool {
+	for _, d := range sql.Drivers() {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSQLFromDB initializes a new sql driver storing releases in an open
+// database, which is accessed with the given dialect: "postgres", "sqlite3"
+// or "mysql".
+func NewSQLFromDB(db *sql.DB, dialect string, logger func(string, ...interface{}), namespace string) (*SQL, error) {
+	d, ok := sqlDialects[dialect]
+	if !ok {
+		return nil, errors.Errorf("unsupported SQL dialect %q", dialect)
+	}
+	return newSQL(sqlx.NewDb(db, dialect), d, logger, namespace)
+}
+
+func newSQL(db *sqlx.DB, dialect *sqlDialect, logger func(string, ...interface{}), namespace string) (*SQL, error) {
 	driver := &SQL{
 		db:               db,
+		dialect:          dialect,
 		Log:              logger,
-		statementBuilder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		statementBuilder: sq.StatementBuilder.PlaceholderFormat(dialect.placeholder),
 	}
 
 	if err := driver.ensureDBSetup(); err != nil {
@@ -207,10 +203,10 @@ func (s *SQL) Get(key string) (*rspb.Release, error) {
 	var record SQLReleaseWrapper
 
 	qb := s.statementBuilder.
-		Select(sqlReleaseTableBodyColumn).
+		Select(s.dialect.column(sqlReleaseTableBodyColumn)).
 		From(sqlReleaseTableName).
-		Where(sq.Eq{sqlReleaseTableKeyColumn: key}).
-		Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace})
+		Where(sq.Eq{s.dialect.column(sqlReleaseTableKeyColumn): key}).
+		Where(sq.Eq{s.dialect.column(sqlReleaseTableNamespaceColumn): s.namespace})
 
 	query, args, err := qb.ToSql()
 	if err != nil {
@@ -224,7 +220,7 @@ func (s *SQL) Get(key string) (*rspb.Release, error) {
 		return nil, ErrReleaseNotFound
 	}
 
-	release, err := decodeRelease(record.Body)
+	release, err := decryptRelease(s.Encryptor, record.Body)
 	if err != nil {
 		s.Log("get: failed to decode data %q: %v", key, err)
 		return nil, err
@@ -236,13 +232,13 @@ func (s *SQL) Get(key string) (*rspb.Release, error) {
 // List returns the list of all releases such that filter(release) == true
 func (s *SQL) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
 	sb := s.statementBuilder.
-		Select(sqlReleaseTableBodyColumn).
+		Select(s.dialect.column(sqlReleaseTableBodyColumn)).
 		From(sqlReleaseTableName).
-		Where(sq.Eq{sqlReleaseTableOwnerColumn: sqlReleaseDefaultOwner})
+		Where(sq.Eq{s.dialect.column(sqlReleaseTableOwnerColumn): sqlReleaseDefaultOwner})
 
 	// If a namespace was specified, we only list releases from that namespace
 	if s.namespace != "" {
-		sb = sb.Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace})
+		sb = sb.Where(sq.Eq{s.dialect.column(sqlReleaseTableNamespaceColumn): s.namespace})
 	}
 
 	query, args, err := sb.ToSql()
@@ -259,7 +255,7 @@ func (s *SQL) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
 
 	var releases []*rspb.Release
 	for _, record := range records {
-		release, err := decodeRelease(record.Body)
+		release, err := decryptRelease(s.Encryptor, record.Body)
 		if err != nil {
 			s.Log("list: failed to decode release: %v: %v", record, err)
 			continue
@@ -275,7 +271,7 @@ func (s *SQL) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
 // Query returns the set of releases that match the provided set of labels.
 func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
 	sb := s.statementBuilder.
-		Select(sqlReleaseTableBodyColumn).
+		Select(s.dialect.column(sqlReleaseTableBodyColumn)).
 		From(sqlReleaseTableName)
 
 	keys := make([]string, 0, len(labels))
@@ -285,7 +281,7 @@ func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
 	sort.Strings(keys)
 	for _, key := range keys {
 		if _, ok := labelMap[key]; ok {
-			sb = sb.Where(sq.Eq{key: labels[key]})
+			sb = sb.Where(sq.Eq{s.dialect.column(key): labels[key]})
 		} else {
 			s.Log("unknown label %s", key)
 			return nil, fmt.Errorf("unknown label %s", key)
@@ -294,7 +290,7 @@ func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
 
 	// If a namespace was specified, we only list releases from that namespace
 	if s.namespace != "" {
-		sb = sb.Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace})
+		sb = sb.Where(sq.Eq{s.dialect.column(sqlReleaseTableNamespaceColumn): s.namespace})
 	}
 
 	// Build our query
@@ -316,7 +312,7 @@ func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
 
 	var releases []*rspb.Release
 	for _, record := range records {
-		release, err := decodeRelease(record.Body)
+		release, err := decryptRelease(s.Encryptor, record.Body)
 		if err != nil {
 			s.Log("list: failed to decode release: %v: %v", record, err)
 			continue
@@ -339,7 +335,7 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 	}
 	s.namespace = namespace
 
-	body, err := encodeRelease(rls)
+	body, err := encryptRelease(s.Encryptor, rls)
 	if err != nil {
 		s.Log("failed to encode release: %v", err)
 		return err
@@ -354,15 +350,15 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 	insertQuery, args, err := s.statementBuilder.
 		Insert(sqlReleaseTableName).
 		Columns(
-			sqlReleaseTableKeyColumn,
-			sqlReleaseTableTypeColumn,
-			sqlReleaseTableBodyColumn,
-			sqlReleaseTableNameColumn,
-			sqlReleaseTableNamespaceColumn,
-			sqlReleaseTableVersionColumn,
-			sqlReleaseTableStatusColumn,
-			sqlReleaseTableOwnerColumn,
-			sqlReleaseTableCreatedAtColumn,
+			s.dialect.column(sqlReleaseTableKeyColumn),
+			s.dialect.column(sqlReleaseTableTypeColumn),
+			s.dialect.column(sqlReleaseTableBodyColumn),
+			s.dialect.column(sqlReleaseTableNameColumn),
+			s.dialect.column(sqlReleaseTableNamespaceColumn),
+			s.dialect.column(sqlReleaseTableVersionColumn),
+			s.dialect.column(sqlReleaseTableStatusColumn),
+			s.dialect.column(sqlReleaseTableOwnerColumn),
+			s.dialect.column(sqlReleaseTableCreatedAtColumn),
 		).
 		Values(
 			key,
@@ -384,10 +380,10 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 		defer transaction.Rollback()
 
 		selectQuery, args, buildErr := s.statementBuilder.
-			Select(sqlReleaseTableKeyColumn).
+			Select(s.dialect.column(sqlReleaseTableKeyColumn)).
 			From(sqlReleaseTableName).
-			Where(sq.Eq{sqlReleaseTableKeyColumn: key}).
-			Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace}).
+			Where(sq.Eq{s.dialect.column(sqlReleaseTableKeyColumn): key}).
+			Where(sq.Eq{s.dialect.column(sqlReleaseTableNamespaceColumn): s.namespace}).
 			ToSql()
 		if buildErr != nil {
 			s.Log("failed to build select query: %v", buildErr)
@@ -416,35 +412,64 @@ func (s *SQL) Update(key string, rls *rspb.Release) error {
 	}
 	s.namespace = namespace
 
-	body, err := encodeRelease(rls)
+	body, err := encryptRelease(s.Encryptor, rls)
 	if err != nil {
 		s.Log("failed to encode release: %v", err)
 		return err
 	}
 
+	transaction, err := s.db.Beginx()
+	if err != nil {
+		s.Log("failed to start SQL transaction: %v", err)
+		return fmt.Errorf("error beginning transaction: %v", err)
+	}
+
+	// The number of updated rows cannot tell whether the release exists, as
+	// MySQL does not count the rows left unchanged.
+	selectQuery, args, err := s.statementBuilder.
+		Select(s.dialect.column(sqlReleaseTableKeyColumn)).
+		From(sqlReleaseTableName).
+		Where(sq.Eq{s.dialect.column(sqlReleaseTableKeyColumn): key}).
+		Where(sq.Eq{s.dialect.column(sqlReleaseTableNamespaceColumn): namespace}).
+		ToSql()
+	if err != nil {
+		s.Log("failed to build select query: %v", err)
+		transaction.Rollback()
+		return err
+	}
+
+	var record SQLReleaseWrapper
+	if err := transaction.Get(&record, selectQuery, args...); err != nil {
+		s.Log("release %s not found: %v", key, err)
+		transaction.Rollback()
+		return ErrReleaseNotFound
+	}
+
 	query, args, err := s.statementBuilder.
 		Update(sqlReleaseTableName).
-		Set(sqlReleaseTableBodyColumn, body).
-		Set(sqlReleaseTableNameColumn, rls.Name).
-		Set(sqlReleaseTableVersionColumn, int(rls.Version)).
-		Set(sqlReleaseTableStatusColumn, rls.Info.Status.String()).
-		Set(sqlReleaseTableOwnerColumn, sqlReleaseDefaultOwner).
-		Set(sqlReleaseTableModifiedAtColumn, int(time.Now().Unix())).
-		Where(sq.Eq{sqlReleaseTableKeyColumn: key}).
-		Where(sq.Eq{sqlReleaseTableNamespaceColumn: namespace}).
+		Set(s.dialect.column(sqlReleaseTableBodyColumn), body).
+		Set(s.dialect.column(sqlReleaseTableNameColumn), rls.Name).
+		Set(s.dialect.column(sqlReleaseTableVersionColumn), int(rls.Version)).
+		Set(s.dialect.column(sqlReleaseTableStatusColumn), rls.Info.Status.String()).
+		Set(s.dialect.column(sqlReleaseTableOwnerColumn), sqlReleaseDefaultOwner).
+		Set(s.dialect.column(sqlReleaseTableModifiedAtColumn), int(time.Now().Unix())).
+		Where(sq.Eq{s.dialect.column(sqlReleaseTableKeyColumn): key}).
+		Where(sq.Eq{s.dialect.column(sqlReleaseTableNamespaceColumn): namespace}).
 		ToSql()
 
 	if err != nil {
 		s.Log("failed to build update query: %v", err)
+		transaction.Rollback()
 		return err
 	}
 
-	if _, err := s.db.Exec(query, args...); err != nil {
+	if _, err := transaction.Exec(query, args...); err != nil {
 		s.Log("failed to update release %s in SQL database: %v", key, err)
+		transaction.Rollback()
 		return err
 	}
 
-	return nil
+	return transaction.Commit()
 }
 
 // Delete deletes a release or returns ErrReleaseNotFound.
@@ -456,10 +481,10 @@ func (s *SQL) Delete(key string) (*rspb.Release, error) {
 	}
 
 	selectQuery, args, err := s.statementBuilder.
-		Select(sqlReleaseTableBodyColumn).
+		Select(s.dialect.column(sqlReleaseTableBodyColumn)).
 		From(sqlReleaseTableName).
-		Where(sq.Eq{sqlReleaseTableKeyColumn: key}).
-		Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace}).
+		Where(sq.Eq{s.dialect.column(sqlReleaseTableKeyColumn): key}).
+		Where(sq.Eq{s.dialect.column(sqlReleaseTableNamespaceColumn): s.namespace}).
 		ToSql()
 	if err != nil {
 		s.Log("failed to build select query: %v", err)
@@ -473,7 +498,7 @@ func (s *SQL) Delete(key string) (*rspb.Release, error) {
 		return nil, ErrReleaseNotFound
 	}
 
-	release, err := decodeRelease(record.Body)
+	release, err := decryptRelease(s.Encryptor, record.Body)
 	if err != nil {
 		s.Log("failed to decode release %s: %v", key, err)
 		transaction.Rollback()
@@ -483,8 +508,8 @@ func (s *SQL) Delete(key string) (*rspb.Release, error) {
 
 	deleteQuery, args, err := s.statementBuilder.
 		Delete(sqlReleaseTableName).
-		Where(sq.Eq{sqlReleaseTableKeyColumn: key}).
-		Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace}).
+		Where(sq.Eq{s.dialect.column(sqlReleaseTableKeyColumn): key}).
+		Where(sq.Eq{s.dialect.column(sqlReleaseTableNamespaceColumn): s.namespace}).
 		ToSql()
 	if err != nil {
 		s.Log("failed to build select query: %v", err)
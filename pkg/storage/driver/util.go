@@ -21,7 +21,11 @@ import (
 	"compress/gzip"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"strconv"
+
+	"github.com/pkg/errors"
 
 	rspb "helm.sh/helm/v3/pkg/release"
 )
@@ -30,10 +34,105 @@ var b64 = base64.StdEncoding
 
 var magicGzip = []byte{0x1f, 0x8b, 0x08}
 
+// DefaultChunkSize is the default maximum size of the encoded release stored in
+// a single Secret or ConfigMap. It leaves room below the 1 MiB size limit of
+// Kubernetes objects for the object metadata.
+const DefaultChunkSize = 1000 * 1024
+
+const (
+	// chunksLabel is set on the first object of a release that is split
+	// across several objects to the total number of objects.
+	chunksLabel = "chunks"
+	// chunkLabel is set on the remaining objects of a split release to
+	// their index.
+	chunkLabel = "chunk"
+	// generationLabel is set on the first object of a split release to the
+	// generation of its remaining objects. Updates write the chunks of a new
+	// generation before switching the first object over to them, so that the
+	// chunks of the stored release are never changed.
+	generationLabel = "generation"
+	// chunkOwner is the owner of the remaining objects of a split release.
+	// It differs from the owner of release objects so that the remaining
+	// objects are never listed as releases.
+	chunkOwner = "helm-chunk"
+)
+
+// chunkKey returns the name of the object holding the chunk with the given
+// generation and index of the release named by key. The first chunk is stored
+// in the object named by key itself. The names of the chunks of generation 0
+// carry no generation.
+func chunkKey(key string, generation, index int) string {
+	switch {
+	case index == 0:
+		return key
+	case generation == 0:
+		return fmt.Sprintf("%s.c%d", key, index)
+	}
+	return fmt.Sprintf("%s.g%d.c%d", key, generation, index)
+}
+
+// splitChunks splits an encoded release into chunks of at most size bytes.
+func splitChunks(s string, size int) []string {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	chunks := make([]string, 0, len(s)/size+1)
+	for len(s) > size {
+		chunks = append(chunks, s[:size])
+		s = s[size:]
+	}
+	return append(chunks, s)
+}
+
+// releaseLabels sets the labels identifying the release and the owner of a
+// storage object.
+func releaseLabels(rls *rspb.Release, lbs labels, owner string) labels {
+	lbs.set("name", rls.Name)
+	lbs.set("owner", owner)
+	lbs.set("version", strconv.Itoa(rls.Version))
+	return lbs
+}
+
+// chunkCount returns the number of objects a release is stored in, given the
+// labels of its first object. Releases stored in the single object format
+// have no chunks label.
+func chunkCount(lbs map[string]string) (int, error) {
+	v, ok := lbs[chunksLabel]
+	if !ok {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, errors.Errorf("invalid %s label %q", chunksLabel, v)
+	}
+	return n, nil
+}
+
+// chunkGeneration returns the generation of the chunks of a release, given
+// the labels of its first object. Releases without a generation label are of
+// generation 0.
+func chunkGeneration(lbs map[string]string) (int, error) {
+	v, ok := lbs[generationLabel]
+	if !ok {
+		return 0, nil
+	}
+	g, err := strconv.Atoi(v)
+	if err != nil || g < 0 {
+		return 0, errors.Errorf("invalid %s label %q", generationLabel, v)
+	}
+	return g, nil
+}
+
 // encodeRelease encodes a release returning a base64 encoded
 // gzipped string representation, or error.
 func encodeRelease(rls *rspb.Release) (string, error) {
-	b, err := json.Marshal(rls)
+	return encodeJSON(rls)
+}
+
+// encodeJSON encodes v as JSON returning a base64 encoded
+// gzipped string representation, or error.
+func encodeJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
 	if err != nil {
 		return "", err
 	}
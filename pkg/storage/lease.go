@@ -0,0 +1,110 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage // import "helm.sh/helm/v3/pkg/storage"
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	rspb "helm.sh/helm/v3/pkg/release"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+// DefaultLeaseTTL is the default time a lease on a pending release is valid.
+const DefaultLeaseTTL = 30 * time.Minute
+
+var (
+	// ErrNotLocked indicates that the last revision of a release is not pending.
+	ErrNotLocked = errors.New("release is not locked by a pending operation")
+	// ErrLeaseHeld indicates that the lease on a pending release has not expired yet.
+	ErrLeaseHeld = errors.New("lease on release has not expired")
+)
+
+// defaultLeaseHolder identifies this process as the holder of a lease.
+func defaultLeaseHolder() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// stampLease sets the lease of a release being written. Pending releases are
+// leased to this client unless another client already holds their lease, and
+// leases held by this client are renewed for the TTL they were requested
+// with. Any other release has its lease released.
+func (s *Storage) stampLease(rls *rspb.Release) {
+	if rls.Info == nil {
+		return
+	}
+	if !rls.Info.Status.IsPending() {
+		rls.Info.Lease = nil
+		return
+	}
+	if lease := rls.Info.Lease; lease != nil && lease.Holder != s.LeaseHolder {
+		return
+	}
+	ttl := s.LeaseTTL
+	if lease := rls.Info.Lease; lease != nil && lease.TTL > 0 {
+		ttl = lease.TTL
+	}
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+	rls.Info.Lease = &rspb.Lease{
+		Holder:     s.LeaseHolder,
+		AcquiredAt: helmtime.Now(),
+		TTL:        ttl,
+	}
+}
+
+// Unlock marks the last revision of a release that is stuck in a pending
+// state as failed, so that further operations on the release are possible.
+//
+// ErrNotLocked is returned if the last revision is not pending. Unless force
+// is set, ErrLeaseHeld is returned if the lease held on the revision has not
+// expired yet. Revisions written by clients that predate leases can always be
+// unlocked.
+func (s *Storage) Unlock(name string, force bool) (*rspb.Release, error) {
+	s.Log("unlocking release %q", name)
+	rls, err := s.Last(name)
+	if err != nil {
+		return nil, err
+	}
+	if !rls.Info.Status.IsPending() {
+		return nil, errors.Wrapf(ErrNotLocked, "release %q has status %s", name, rls.Info.Status)
+	}
+
+	lease := rls.Info.Lease
+	if !force && !lease.Expired(time.Now()) {
+		return nil, errors.Wrapf(ErrLeaseHeld, "release %q is held by %q until %s",
+			name, lease.Holder, lease.ExpiresAt().Format(time.RFC3339))
+	}
+
+	desc := fmt.Sprintf("Recovered from interrupted %s", rls.Info.Status)
+	if lease != nil && lease.Holder != "" {
+		desc = fmt.Sprintf("%s held by %s", desc, lease.Holder)
+	}
+	rls.SetStatus(rspb.StatusFailed, desc)
+	if err := s.Update(rls); err != nil {
+		return nil, err
+	}
+	return rls, nil
+}
@@ -19,6 +19,7 @@ package storage // import "helm.sh/helm/v3/pkg/storage"
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -34,6 +35,10 @@ import (
 // This constant is used as a prefix for the Kubernetes storage object name.
 const HelmStorageType = "sh.helm.release.v1"
 
+// DefaultChartPruneGrace is the default time a chart stored separately from
+// releases is kept after it was last stored, even if no release references it.
+const DefaultChartPruneGrace = 10 * time.Minute
+
 // Storage represents a storage engine for a Release.
 type Storage struct {
 	driver.Driver
@@ -43,6 +48,22 @@ type Storage struct {
 	// ignored (meaning no limits are imposed).
 	MaxHistory int
 
+	// SeparateCharts stores the chart of each release once per chart digest,
+	// separately from the release, if the driver implements
+	// driver.ChartStore. Releases then reference their chart by digest.
+	SeparateCharts bool
+	// ChartPruneGrace is how long a chart stored separately from releases
+	// is kept after it was last stored, even if no release references it,
+	// so that the charts of releases being stored concurrently are not
+	// pruned. Init sets it to DefaultChartPruneGrace.
+	ChartPruneGrace time.Duration
+
+	// LeaseHolder identifies this client in the lease of pending releases.
+	LeaseHolder string
+	// LeaseTTL is how long the lease on a pending release is valid. Values of
+	// 0 or less use DefaultLeaseTTL.
+	LeaseTTL time.Duration
+
 	Log func(string, ...interface{})
 }
 
@@ -66,7 +87,12 @@ func (s *Storage) Create(rls *rspb.Release) error {
 			return err
 		}
 	}
-	return s.Driver.Create(makeKey(rls.Name, rls.Version), rls)
+	s.stampLease(rls)
+	stored, err := s.storedRelease(rls)
+	if err != nil {
+		return err
+	}
+	return s.Driver.Create(makeKey(rls.Name, rls.Version), stored)
 }
 
 // Update updates the release in storage. An error is returned if the
@@ -74,7 +100,12 @@ func (s *Storage) Create(rls *rspb.Release) error {
 // does not exist.
 func (s *Storage) Update(rls *rspb.Release) error {
 	s.Log("updating release %q", makeKey(rls.Name, rls.Version))
-	return s.Driver.Update(makeKey(rls.Name, rls.Version), rls)
+	s.stampLease(rls)
+	stored, err := s.storedRelease(rls)
+	if err != nil {
+		return err
+	}
+	return s.Driver.Update(makeKey(rls.Name, rls.Version), stored)
 }
 
 // Delete deletes the release from storage. An error is returned if
@@ -206,6 +237,12 @@ func (s *Storage) removeLeastRecent(name string, max int) error {
 		}
 	}
 
+	if len(toDelete) > len(errs) {
+		if err := s.PruneCharts(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	s.Log("Pruned %d record(s) from %s with %d error(s)", len(toDelete), name, len(errs))
 	switch c := len(errs); c {
 	case 0:
@@ -227,6 +264,116 @@ func (s *Storage) deleteReleaseVersion(name string, version int) error {
 	return nil
 }
 
+// storedRelease returns the release as it is written to the driver. If charts
+// are stored separately, the chart is stored under its digest and the returned
+// copy of the release references it instead of embedding it.
+func (s *Storage) storedRelease(rls *rspb.Release) (*rspb.Release, error) {
+	store, ok := s.Driver.(driver.ChartStore)
+	if !s.SeparateCharts || !ok || rls.Chart == nil {
+		return rls, nil
+	}
+	digest, err := driver.ChartDigest(rls.Chart)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to compute digest of chart of release %q", rls.Name)
+	}
+	if err := store.CreateChart(digest, rls.Chart); err != nil {
+		return nil, errors.Wrapf(err, "unable to store chart of release %q", rls.Name)
+	}
+	stored := *rls
+	stored.Chart = nil
+	stored.ChartDigest = digest
+	return &stored, nil
+}
+
+// PruneCharts deletes the charts stored separately from releases that are no
+// longer referenced by any release and were last stored more than
+// ChartPruneGrace ago. It does nothing if the driver does not implement
+// driver.ChartStore.
+func (s *Storage) PruneCharts() error {
+	store, ok := s.Driver.(driver.ChartStore)
+	if !ok {
+		return nil
+	}
+	charts, err := store.ListCharts()
+	if err != nil || len(charts) == 0 {
+		return err
+	}
+
+	// Collect the referenced digests without loading the charts.
+	referenced := map[string]bool{}
+	if _, err := s.Driver.List(func(rls *rspb.Release) bool {
+		referenced[rls.ChartDigest] = true
+		return false
+	}); err != nil {
+		return err
+	}
+
+	// Charts are stored before the releases referencing them, so recently
+	// stored charts may belong to releases that are being stored.
+	cutoff := time.Now().Add(-s.ChartPruneGrace)
+	var pruned int
+	for _, c := range charts {
+		if referenced[c.Digest] || c.StoredAt.After(cutoff) {
+			continue
+		}
+		if err := store.DeleteChart(c.Digest); err != nil && !errors.Is(err, driver.ErrChartNotFound) {
+			return errors.Wrapf(err, "unable to delete chart %s", c.Digest)
+		}
+		pruned++
+	}
+	s.Log("Pruned %d unreferenced chart(s)", pruned)
+	return nil
+}
+
+// Reencrypt rewrites all release records, and the charts stored separately
+// from them, so they are encrypted with the current key of the driver. It is
+// used to re-encrypt the history of releases after rotating keys or enabling
+// encryption. It returns the number of rewritten release records.
+func (s *Storage) Reencrypt() (int, error) {
+	s.Log("re-encrypting all releases in storage")
+	rels, err := s.Driver.List(func(_ *rspb.Release) bool { return true })
+	if err != nil {
+		return 0, err
+	}
+
+	if store, ok := s.Driver.(driver.ChartStore); ok {
+		charts, err := store.ListCharts()
+		if err != nil {
+			return 0, err
+		}
+		for _, c := range charts {
+			if err := reencryptChart(store, c.Digest); err != nil {
+				return 0, errors.Wrapf(err, "unable to re-encrypt chart %s", c.Digest)
+			}
+		}
+	}
+
+	for i, rls := range rels {
+		stored := rls
+		if rls.ChartDigest != "" {
+			// keep the release referencing its chart
+			r := *rls
+			r.Chart = nil
+			stored = &r
+		}
+		key := makeKey(rls.Name, rls.Version)
+		if err := s.Driver.Update(key, stored); err != nil {
+			return i, errors.Wrapf(err, "unable to re-encrypt release %q", key)
+		}
+	}
+	return len(rels), nil
+}
+
+// reencryptChart rewrites a chart stored separately from releases in place,
+// so that it stays readable if the rewrite fails.
+func reencryptChart(store driver.ChartStore, digest string) error {
+	ch, err := store.GetChart(digest)
+	if err != nil {
+		return err
+	}
+	return store.UpdateChart(digest, ch)
+}
+
 // Last fetches the last revision of the named release.
 func (s *Storage) Last(name string) (*rspb.Release, error) {
 	s.Log("getting last revision of %q", name)
@@ -260,7 +407,9 @@ func Init(d driver.Driver) *Storage {
 		d = driver.NewMemory()
 	}
 	return &Storage{
-		Driver: d,
-		Log:    func(_ string, _ ...interface{}) {},
+		Driver:          d,
+		ChartPruneGrace: DefaultChartPruneGrace,
+		LeaseHolder:     defaultLeaseHolder(),
+		Log:             func(_ string, _ ...interface{}) {},
 	}
 }
@@ -20,11 +20,14 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 
+	"helm.sh/helm/v3/pkg/chart"
 	rspb "helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	helmtime "helm.sh/helm/v3/pkg/time"
 )
 
 func TestStorageCreate(t *testing.T) {
@@ -444,6 +447,232 @@ func TestStorageLast(t *testing.T) {
 	}
 }
 
+func TestStorageSeparateCharts(t *testing.T) {
+	mem := driver.NewMemory()
+	storage := Init(mem)
+	storage.Log = t.Logf
+	storage.SeparateCharts = true
+	storage.ChartPruneGrace = 0
+	storage.MaxHistory = 2
+
+	const name = "angry-bird"
+	chartA := &chart.Chart{Metadata: &chart.Metadata{Name: "a", Version: "1.0.0"}}
+	chartB := &chart.Chart{Metadata: &chart.Metadata{Name: "a", Version: "2.0.0"}}
+
+	create := func(version int, ch *chart.Chart) {
+		rls := ReleaseTestData{Name: name, Version: version, Status: rspb.StatusDeployed}.ToRelease()
+		rls.Chart = ch
+		assertErrNil(t.Fatal, storage.Create(rls), fmt.Sprintf("Storing release %q (v%d)", name, version))
+	}
+	charts := func() []string {
+		stored, err := mem.ListCharts()
+		assertErrNil(t.Fatal, err, "ListCharts")
+		var digests []string
+		for _, c := range stored {
+			digests = append(digests, c.Digest)
+		}
+		return digests
+	}
+
+	create(1, chartA)
+	create(2, chartA)
+	if n := len(charts()); n != 1 {
+		t.Fatalf("expected the chart to be stored once, got %d charts", n)
+	}
+
+	// releases are stored without their chart, and read with it
+	if _, err := mem.List(func(rls *rspb.Release) bool {
+		if rls.Chart != nil || rls.ChartDigest == "" {
+			t.Errorf("expected release v%d to be stored without its chart", rls.Version)
+		}
+		return false
+	}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := storage.Get(name, 1)
+	assertErrNil(t.Fatal, err, "QueryRelease")
+	if !reflect.DeepEqual(chartA, res.Chart) {
+		t.Errorf("expected chart %v, got %v", chartA, res.Chart)
+	}
+
+	// pruning v1 keeps chart A, which is still referenced by v2
+	create(3, chartB)
+	if n := len(charts()); n != 2 {
+		t.Fatalf("expected 2 charts, got %d", n)
+	}
+
+	// pruning v2 removes chart A
+	create(4, chartB)
+	digestB, err := driver.ChartDigest(chartB)
+	assertErrNil(t.Fatal, err, "ChartDigest")
+	if digests := charts(); !reflect.DeepEqual(digests, []string{digestB}) {
+		t.Errorf("expected only chart %s to be kept, got %v", digestB, digests)
+	}
+}
+
+func TestStoragePruneChartsGrace(t *testing.T) {
+	mem := driver.NewMemory()
+	storage := Init(mem)
+	storage.Log = t.Logf
+	storage.SeparateCharts = true
+
+	rls := ReleaseTestData{Name: "angry-bird", Version: 1, Status: rspb.StatusDeployed}.ToRelease()
+	rls.Chart = &chart.Chart{Metadata: &chart.Metadata{Name: "a", Version: "1.0.0"}}
+	assertErrNil(t.Fatal, storage.Create(rls), "StoreRelease")
+	_, err := storage.Delete(rls.Name, rls.Version)
+	assertErrNil(t.Fatal, err, "DeleteRelease")
+
+	// a chart stored recently may belong to a release that is being stored
+	assertErrNil(t.Fatal, storage.PruneCharts(), "PruneCharts")
+	charts, err := mem.ListCharts()
+	assertErrNil(t.Fatal, err, "ListCharts")
+	if len(charts) != 1 {
+		t.Fatalf("expected the recently stored chart to be kept, got %v", charts)
+	}
+
+	storage.ChartPruneGrace = 0
+	assertErrNil(t.Fatal, storage.PruneCharts(), "PruneCharts")
+	charts, err = mem.ListCharts()
+	assertErrNil(t.Fatal, err, "ListCharts")
+	if len(charts) != 0 {
+		t.Errorf("expected the unreferenced chart to be pruned, got %v", charts)
+	}
+}
+
+func TestStorageReencrypt(t *testing.T) {
+	mem := driver.NewMemory()
+	storage := Init(mem)
+	storage.Log = t.Logf
+	storage.SeparateCharts = true
+
+	ch := &chart.Chart{Metadata: &chart.Metadata{Name: "a", Version: "1.0.0"}}
+	for _, version := range []int{1, 2} {
+		rls := ReleaseTestData{Name: "angry-bird", Version: version, Status: rspb.StatusSuperseded}.ToRelease()
+		rls.Chart = ch
+		assertErrNil(t.Fatal, storage.Create(rls), "StoreRelease")
+	}
+
+	n, err := storage.Reencrypt()
+	assertErrNil(t.Fatal, err, "Reencrypt")
+	if n != 2 {
+		t.Errorf("expected 2 re-encrypted releases, got %d", n)
+	}
+
+	// releases keep referencing their chart
+	for _, version := range []int{1, 2} {
+		res, err := storage.Get("angry-bird", version)
+		assertErrNil(t.Fatal, err, "QueryRelease")
+		if !reflect.DeepEqual(ch, res.Chart) {
+			t.Errorf("expected chart %v for v%d, got %v", ch, version, res.Chart)
+		}
+	}
+	charts, err := mem.ListCharts()
+	assertErrNil(t.Fatal, err, "ListCharts")
+	if len(charts) != 1 {
+		t.Errorf("expected the chart to be kept, got %v", charts)
+	}
+}
+
+func TestStorageLease(t *testing.T) {
+	storage := Init(driver.NewMemory())
+	storage.LeaseHolder = "test-holder"
+
+	rls := ReleaseTestData{
+		Name:    "angry-beaver",
+		Version: 1,
+		Status:  rspb.StatusPendingInstall,
+	}.ToRelease()
+	assertErrNil(t.Fatal, storage.Create(rls), "StoreRelease")
+
+	res, err := storage.Get(rls.Name, rls.Version)
+	assertErrNil(t.Fatal, err, "QueryRelease")
+	lease := res.Info.Lease
+	if lease == nil {
+		t.Fatal("expected a lease on the pending release")
+	}
+	if lease.Holder != "test-holder" || lease.TTL != DefaultLeaseTTL {
+		t.Errorf("unexpected lease %+v", lease)
+	}
+	if lease.Expired(time.Now()) {
+		t.Error("expected the lease not to be expired")
+	}
+
+	// A lease requested with a TTL keeps it when it is renewed.
+	rls.Info.Lease = &rspb.Lease{Holder: "test-holder", TTL: time.Minute}
+	assertErrNil(t.Fatal, storage.Update(rls), "UpdateRelease")
+	assertErrNil(t.Fatal, storage.Update(rls), "UpdateRelease")
+	res, err = storage.Get(rls.Name, rls.Version)
+	assertErrNil(t.Fatal, err, "QueryRelease")
+	if lease := res.Info.Lease; lease == nil || lease.Holder != "test-holder" || lease.TTL != time.Minute {
+		t.Errorf("expected the lease to be renewed for a minute, got %+v", lease)
+	}
+
+	rls.Info.Status = rspb.StatusDeployed
+	assertErrNil(t.Fatal, storage.Update(rls), "UpdateRelease")
+	res, err = storage.Get(rls.Name, rls.Version)
+	assertErrNil(t.Fatal, err, "QueryRelease")
+	if res.Info.Lease != nil {
+		t.Errorf("expected the lease to be released, got %+v", res.Info.Lease)
+	}
+}
+
+func TestStorageUnlock(t *testing.T) {
+	setup := func(lease *rspb.Lease) *Storage {
+		storage := Init(driver.NewMemory())
+		deployed := ReleaseTestData{Name: "angry-beaver", Version: 1, Status: rspb.StatusDeployed}.ToRelease()
+		pending := ReleaseTestData{Name: "angry-beaver", Version: 2, Status: rspb.StatusPendingUpgrade}.ToRelease()
+		pending.Info.Lease = lease
+		// Bypass Storage so the lease is stored as given.
+		assertErrNil(t.Fatal, storage.Driver.Create(makeKey(deployed.Name, deployed.Version), deployed), "StoreRelease")
+		assertErrNil(t.Fatal, storage.Driver.Create(makeKey(pending.Name, pending.Version), pending), "StoreRelease")
+		return storage
+	}
+
+	expired := &rspb.Lease{Holder: "gone", AcquiredAt: helmtime.Now().Add(-time.Hour), TTL: time.Minute}
+	held := &rspb.Lease{Holder: "busy", AcquiredAt: helmtime.Now(), TTL: time.Hour}
+
+	tests := []struct {
+		name    string
+		lease   *rspb.Lease
+		force   bool
+		wantErr error
+	}{
+		{name: "expired lease", lease: expired},
+		{name: "no lease", lease: nil},
+		{name: "held lease", lease: held, wantErr: ErrLeaseHeld},
+		{name: "held lease forced", lease: held, force: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := setup(tt.lease)
+			rls, err := storage.Unlock("angry-beaver", tt.force)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			assertErrNil(t.Fatal, err, "UnlockRelease")
+			if rls.Version != 2 {
+				t.Errorf("expected revision 2 to be unlocked, got %d", rls.Version)
+			}
+
+			res, err := storage.Get("angry-beaver", 2)
+			assertErrNil(t.Fatal, err, "QueryRelease")
+			if res.Info.Status != rspb.StatusFailed {
+				t.Errorf("expected status %s, got %s", rspb.StatusFailed, res.Info.Status)
+			}
+			if res.Info.Lease != nil {
+				t.Errorf("expected the lease to be released, got %+v", res.Info.Lease)
+			}
+
+			if _, err := storage.Unlock("angry-beaver", tt.force); !errors.Is(err, ErrNotLocked) {
+				t.Errorf("expected error %v, got %v", ErrNotLocked, err)
+			}
+		})
+	}
+}
+
 type ReleaseTestData struct {
 	Name      string
 	Version   int